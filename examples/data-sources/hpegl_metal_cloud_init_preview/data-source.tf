@@ -0,0 +1,35 @@
+# (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+provider "hpegl" {
+  metal {
+    gl_token = false
+  }
+}
+
+variable "location" {
+  default = "USA:Central:AFCDCC1"
+}
+
+locals {
+  host = {
+    name             = "web-0"
+    ssh              = ["User1 - Linux"]
+    networks         = ["Public", "Storage"]
+    network_untagged = "Public"
+  }
+}
+
+# The files that the service image writes to the host, rendered for the host below.
+data "hpegl_metal_cloud_init_preview" "web" {
+  service_yaml     = file("../../resources/hpegl_metal_image/service.yml")
+  name             = local.host.name
+  ssh              = local.host.ssh
+  location         = var.location
+  networks         = local.host.networks
+  network_untagged = local.host.network_untagged
+  interfaces       = ["eno1", "eno2"]
+}
+
+output "cloud_init" {
+  value = { for f in data.hpegl_metal_cloud_init_preview.web.files : f.path => f.content }
+}
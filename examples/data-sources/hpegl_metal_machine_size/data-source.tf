@@ -0,0 +1,37 @@
+# (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+provider "hpegl" {
+  metal {
+    gl_token = false
+  }
+}
+
+variable "location" {
+  default = "USA:Central:AFCDCC1"
+}
+
+# The smallest machine size with at least 256GB of memory and 32 cores that has machines in inventory.
+data "hpegl_metal_machine_size" "large" {
+  location      = var.location
+  min_memory_gb = 256
+  min_cores     = 32
+}
+
+resource "hpegl_metal_host" "large" {
+  name         = "large-0"
+  image        = "ubuntu@20.04-20201102"
+  machine_size = data.hpegl_metal_machine_size.large.id
+  ssh          = ["User1 - Linux"]
+  networks     = ["Public"]
+  location     = data.hpegl_metal_machine_size.large.location
+}
+
+output "machine_size" {
+  value = {
+    name      = data.hpegl_metal_machine_size.large.name
+    cpu       = data.hpegl_metal_machine_size.large.cpu
+    cores     = data.hpegl_metal_machine_size.large.cores
+    memory_gb = data.hpegl_metal_machine_size.large.memory_gb
+    quantity  = data.hpegl_metal_machine_size.large.quantity
+  }
+}
@@ -1,4 +1,4 @@
-# (C) Copyright 2020-2023 Hewlett Packard Enterprise Development LP
+# (C) Copyright 2020-2023, 2026 Hewlett Packard Enterprise Development LP
 
 provider "hpegl" {
   metal {
@@ -28,6 +28,10 @@ resource "hpegl_metal_host" "terra_host" {
   name               = "tformed-${count.index}"
   image              = "ubuntu@18.04-20201102"
   machine_size       = "A2atpq"
+  ## other machine sizes, and locations, to use when there are no A2atpq machines left
+  # machine_size_fallbacks {
+  #   machine_size = "G2i"
+  # }
   ssh                = [hpegl_metal_ssh_key.newssh_1.id]
   networks           = ["Public", "Storage"]
   network_route      = "Public"
@@ -35,6 +39,21 @@ resource "hpegl_metal_host" "terra_host" {
   description        = "Hello from Terraform"
   volume_attachments = [hpegl_metal_volume.iscsi_volume.id]
   host_action_async  = var.host_action_async
+  ## assemble MIME multipart user data from several parts, in place of user_data
+  # cloud_init {
+  #   part {
+  #     content_type = "text/cloud-config"
+  #     content      = file("cloud-config.yaml")
+  #   }
+  #   part {
+  #     content_type = "text/x-shellscript"
+  #     content      = file("setup.sh")
+  #     filename     = "setup.sh"
+  #   }
+  # }
+  # user_data_encoding = "gzip+base64"
+  ## set to "delete" to delete a host that fails to provision, or to "retry" to create it once more
+  # on_create_failure = "keep"
   ## uncomment below to override the 60m timeouts
   ## see https://developer.hashicorp.com/terraform/plugin/sdkv2/resources/retries-and-customizable-timeouts
   # timeouts {
@@ -85,4 +104,11 @@ resource "hpegl_metal_host" "terra_host_new_ssh" {
   description       = "Hello from Terraform"
   labels            = { "ServiceType" = "BMaaS" }
   host_action_async = var.host_action_async
+  ## set to "off" to power off an idle host without destroying it, or to "reset" to reset it
+  # power_state = "on"
+  ## uncomment below to change ssh without replacing the host, by pushing the keys to it
+  # ssh_key_push {
+  #   private_key = file("~/.ssh/id_rsa")
+  #   host_key    = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIJP0mD2tvKajcCFsRI4n6RvjxjoPMbPkgtqAn1HP8czD"
+  # }
 }
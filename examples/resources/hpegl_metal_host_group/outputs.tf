@@ -0,0 +1,8 @@
+output "ips" {
+  # Output a map of hostname with each network's IP address.
+  value = { for h in hpegl_metal_host_group.web.hosts : h.name => h.connections }
+}
+
+output "host_ids" {
+  value = hpegl_metal_host_group.web.host_ids
+}
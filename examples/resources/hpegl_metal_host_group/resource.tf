@@ -0,0 +1,36 @@
+# (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+provider "hpegl" {
+  metal {
+    gl_token = false
+  }
+}
+
+variable "location" {
+  default = "USA:Central:AFCDCC1"
+}
+
+variable "web_hosts" {
+  default = 4
+}
+
+resource "hpegl_metal_host_group" "web" {
+  name_template = "web-{index}"
+  size          = var.web_hosts
+  parallelism   = 2
+  image         = "ubuntu@20.04-20201102"
+  machine_size  = "G2i"
+  ssh           = ["User1 - Linux"]
+  networks      = ["Public", "Storage"]
+  network_route = "Public"
+  location      = var.location
+  labels        = { "role" = "web" }
+  ## set to false to wait for each host to be ready
+  # host_action_async = true
+  ## uncomment below to override the 60m timeouts
+  # timeouts {
+  #   create = "90m"
+  #   update = "90m"
+  #   delete = "30m"
+  # }
+}
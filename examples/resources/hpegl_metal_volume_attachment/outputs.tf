@@ -0,0 +1,10 @@
+# (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+output "data_vol_attachment" {
+  # Output the iSCSI details of the attachment.
+  value = {
+    target_iqn   = hpegl_metal_volume_attachment.data_vol.target_iqn
+    discovery_ip = hpegl_metal_volume_attachment.data_vol.discovery_ip
+    lun          = hpegl_metal_volume_attachment.data_vol.lun
+  }
+}
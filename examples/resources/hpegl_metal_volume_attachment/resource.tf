@@ -0,0 +1,30 @@
+# (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+provider "hpegl" {
+  metal {
+    gl_token = false
+  }
+}
+
+variable "location" {
+  default = "USA:Central:AFCDCC1"
+}
+
+variable "host" {
+  # name or ID of an existing host, e.g. one owned by another module
+  default = "tformed-0"
+}
+
+resource "hpegl_metal_volume" "data_vol" {
+  name        = "data-vol"
+  size        = 20
+  shareable   = false
+  flavor      = "Fast"
+  location    = var.location
+  description = "Terraformed data volume"
+}
+
+resource "hpegl_metal_volume_attachment" "data_vol" {
+  volume = hpegl_metal_volume.data_vol.id
+  host   = var.host
+}
@@ -9,6 +9,7 @@ require (
 	github.com/hewlettpackard/hpegl-metal-client v1.5.35
 	github.com/hewlettpackard/hpegl-provider-lib v0.0.22
 	github.com/stretchr/testify v1.10.0
+	golang.org/x/crypto v0.38.0
 	gopkg.in/yaml.v2 v2.4.0
 )
 
@@ -232,7 +233,6 @@ require (
 	go.uber.org/automaxprocs v1.6.0 // indirect
 	go.uber.org/multierr v1.9.0 // indirect
 	go.uber.org/zap v1.24.0 // indirect
-	golang.org/x/crypto v0.38.0 // indirect
 	golang.org/x/exp v0.0.0-20240909161429-701f63a606c0 // indirect
 	golang.org/x/exp/typeparams v0.0.0-20250210185358-939b2ce775ac // indirect
 	golang.org/x/mod v0.25.0 // indirect
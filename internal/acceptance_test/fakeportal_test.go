@@ -0,0 +1,122 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package acceptance_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	testutils "github.com/hewlettpackard/hpegl-metal-terraform-resources/internal/test-utils"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/internal/test-utils/fakeportal"
+)
+
+// fakePortalProviders returns provider factories whose Metal client talks to the fake portal.
+func fakePortalProviders(srv *fakeportal.Server) map[string]func() (*schema.Provider, error) {
+	return map[string]func() (*schema.Provider, error){
+		"hpegl": func() (*schema.Provider, error) {
+			return testutils.ProviderFuncWithOpts(srv.Options()...)(), nil
+		},
+	}
+}
+
+// skipWithoutTerraform skips unit tests that drive the terraform CLI when it isn't available.
+func skipWithoutTerraform(t *testing.T) {
+	t.Helper()
+
+	if os.Getenv("TF_ACC_TERRAFORM_PATH") != "" {
+		return
+	}
+
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("terraform CLI not found, set TF_ACC_TERRAFORM_PATH to run")
+	}
+}
+
+func TestUnitFakePortal_SSHKeyAndVolume(t *testing.T) {
+	skipWithoutTerraform(t)
+
+	srv := fakeportal.New()
+	defer srv.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: fakePortalProviders(srv),
+		CheckDestroy:      testFakePortalDestroyed(srv),
+		Steps: []resource.TestStep{
+			{
+				Config: testFakePortalConfig("key1"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hpegl_metal_ssh_key.test", "name", "key1"),
+					resource.TestCheckResourceAttr("hpegl_metal_volume.test", "state", "allocated"),
+					resource.TestCheckResourceAttr("hpegl_metal_volume.test", "flavor", fakeportal.VolumeFlavor),
+				),
+			},
+			{
+				Config: testFakePortalConfig("key2"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hpegl_metal_ssh_key.test", "name", "key2"),
+				),
+			},
+		},
+	})
+}
+
+func testFakePortalConfig(keyName string) string {
+	return fmt.Sprintf(`
+provider "hpegl" {
+	metal {
+		gl_token = false
+	}
+}
+
+resource "hpegl_metal_ssh_key" "test" {
+	name       = %q
+	public_key = "ssh-rsa AAAAB3NzaC1yc2E test@fakeportal"
+}
+
+resource "hpegl_metal_volume" "test" {
+	name     = "test.volume"
+	size     = 10
+	flavor   = %q
+	location = %q
+}
+`, keyName, fakeportal.VolumeFlavor, fakeportal.Location)
+}
+
+func testFakePortalDestroyed(srv *fakeportal.Server) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		cfg, err := srv.NewConfig()
+		if err != nil {
+			return err
+		}
+
+		ctx, err := cfg.GetContextWithError()
+		if err != nil {
+			return err
+		}
+
+		for _, rs := range s.RootModule().Resources {
+			switch rs.Type {
+			case "hpegl_metal_ssh_key":
+				//nolint:bodyclose // Response body is closed by metal client.
+				if _, _, err := cfg.Client.SshkeysApi.GetByID(ctx, rs.Primary.ID, nil); err == nil {
+					return fmt.Errorf("SSH key %s still exists", rs.Primary.ID)
+				}
+			case "hpegl_metal_volume":
+				//nolint:bodyclose // Response body is closed by metal client.
+				v, _, err := cfg.Client.VolumesApi.GetByID(ctx, rs.Primary.ID, nil)
+				if err == nil && v.State != rest.VOLUMESTATE_DELETED {
+					return fmt.Errorf("volume %s still exists", rs.Primary.ID)
+				}
+			}
+		}
+
+		return nil
+	}
+}
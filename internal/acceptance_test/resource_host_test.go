@@ -1,4 +1,4 @@
-// (C) Copyright 2020-2024 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2024, 2026 Hewlett Packard Enterprise Development LP
 
 package acceptance_test
 
@@ -164,7 +164,10 @@ func testWaitUntilHostReady(rsrc string) resource.TestCheckFunc {
 			return fmt.Errorf("Error retrieving Metal client: %v", err)
 		}
 
-		ctx := p.GetContext()
+		ctx, err := p.GetContextWithError()
+		if err != nil {
+			return err
+		}
 
 		hostState := rest.HOSTSTATE_NEW
 		for i := 0; i < hostStatePollCount && hostState != rest.HOSTSTATE_READY; i++ {
@@ -208,7 +211,10 @@ func testVerifyHostReady(resourceStateKey string) resource.TestCheckFunc {
 			return fmt.Errorf("Error retrieving Metal client: %v", err)
 		}
 
-		ctx := p.GetContext()
+		ctx, err := p.GetContextWithError()
+		if err != nil {
+			return err
+		}
 
 		host, resp, err := p.Client.HostsApi.GetByID(ctx, hostID, nil)
 		if err != nil {
@@ -244,7 +250,10 @@ func testAccCheckHostDestroy(t *testing.T, s *terraform.State) error {
 			return fmt.Errorf("Error retrieving Metal client: %v", err)
 		}
 
-		ctx := p.GetContext()
+		ctx, err := p.GetContextWithError()
+		if err != nil {
+			return err
+		}
 
 		_, resp, err := p.Client.HostsApi.GetByID(ctx, hostID, nil)
 		if err != nil {
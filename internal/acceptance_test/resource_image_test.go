@@ -1,4 +1,4 @@
-// (C) Copyright 2023 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2023, 2026 Hewlett Packard Enterprise Development LP
 
 package acceptance_test
 
@@ -93,7 +93,10 @@ func testAccCheckImageDestroy(t *testing.T, s *terraform.State) error {
 			return fmt.Errorf("Error retrieving Metal client: %v", err)
 		}
 
-		ctx := p.GetContext()
+		ctx, err := p.GetContextWithError()
+		if err != nil {
+			return err
+		}
 
 		_, res, err := p.Client.ServicesApi.GetByID(ctx, imageID, nil)
 		if err == nil {
@@ -124,7 +127,10 @@ func testAccCheckImageExists(resource string) resource.TestCheckFunc {
 			return fmt.Errorf("Error retrieving Metal client: %v", err)
 		}
 
-		ctx := p.GetContext()
+		ctx, err := p.GetContextWithError()
+		if err != nil {
+			return err
+		}
 
 		ret, res, err := p.Client.ServicesApi.GetByID(ctx, imageID, nil)
 		if err != nil {
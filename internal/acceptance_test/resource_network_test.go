@@ -1,4 +1,4 @@
-// (C) Copyright 2020-2023, 2025 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2023, 2025-2026 Hewlett Packard Enterprise Development LP
 
 package acceptance_test
 
@@ -94,10 +94,13 @@ func testAccCheckNetworkDestroy(t *testing.T, s *terraform.State) error {
 			continue
 		}
 
-		ctx := p.GetContext()
+		ctx, err := p.GetContextWithError()
+		if err != nil {
+			return err
+		}
 
 		//nolint:bodyclose // Response body is closed by metal client.
-		_, _, err := p.Client.NetworksApi.GetByID(ctx, rs.Primary.ID, nil)
+		_, _, err = p.Client.NetworksApi.GetByID(ctx, rs.Primary.ID, nil)
 		if err == nil {
 			return fmt.Errorf("Alert pnet still exists")
 		}
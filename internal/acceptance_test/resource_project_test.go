@@ -1,4 +1,4 @@
-// (C) Copyright 2022,2024-2025 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2022,2024-2026 Hewlett Packard Enterprise Development LP
 
 package acceptance_test
 
@@ -120,7 +120,10 @@ func testAccCheckProjectDestroy(t *testing.T, s *terraform.State) error {
 			return fmt.Errorf("Error retrieving Metal client: %v", err)
 		}
 
-		ctx := p.GetContext()
+		ctx, err := p.GetContextWithError()
+		if err != nil {
+			return err
+		}
 
 		_, res, err := p.Client.ProjectsApi.GetByID(ctx, ProjectID, nil)
 		if err == nil {
@@ -151,7 +154,10 @@ func testAccCheckProjectExists(resource string) resource.TestCheckFunc {
 			return fmt.Errorf("Error retrieving Metal client: %v", err)
 		}
 
-		ctx := p.GetContext()
+		ctx, err := p.GetContextWithError()
+		if err != nil {
+			return err
+		}
 
 		ret, res, err := p.Client.ProjectsApi.GetByID(ctx, ProjectID, nil)
 		if err != nil {
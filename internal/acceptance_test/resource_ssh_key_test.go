@@ -1,4 +1,4 @@
-// (C) Copyright 2020-2023 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2023, 2026 Hewlett Packard Enterprise Development LP
 
 package acceptance_test
 
@@ -73,7 +73,10 @@ func testAccCheckSSHKeyExists(n string, out *rest.SshKey) resource.TestCheckFunc
 			return fmt.Errorf("Error retrieving Metal client: %v", err)
 		}
 
-		ctx := p.GetContext()
+		ctx, err := p.GetContextWithError()
+		if err != nil {
+			return err
+		}
 
 		//nolint:bodyclose // Response body is closed by metal client.
 		key, _, err := p.Client.SshkeysApi.GetByID(ctx, rs.Primary.ID, nil)
@@ -99,7 +102,10 @@ func testAccCheckSSHKeyDestroy(s *terraform.State) error {
 			continue
 		}
 
-		ctx := p.GetContext()
+		ctx, err := p.GetContextWithError()
+		if err != nil {
+			return err
+		}
 
 		//nolint:bodyclose // Response body is closed by metal client.
 		if _, _, err := p.Client.SshkeysApi.GetByID(ctx, rs.Primary.ID, nil); err == nil {
@@ -0,0 +1,142 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package acceptance_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/client"
+)
+
+func TestAccResourceVolumeAttachment_Basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVolumeAttachmentDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCheckVolumeAttachmentBasic(),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVolumeAttachmentExists("hpegl_metal_volume_attachment.test_va"),
+					resource.TestCheckResourceAttrPair("hpegl_metal_volume_attachment.test_va", "volume_id",
+						"hpegl_metal_volume.test_va_vol", "id"),
+					resource.TestCheckResourceAttrPair("hpegl_metal_volume_attachment.test_va", "host_id",
+						"hpegl_metal_host.test_va_host", "id"),
+					resource.TestCheckResourceAttrSet("hpegl_metal_volume_attachment.test_va", "target_iqn"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckVolumeAttachmentBasic() string {
+	return `
+provider "hpegl" {
+	metal {
+	}
+	alias = "test"
+}
+
+variable "location" {
+	default = "USA:Central:AFCDCC1"
+}
+
+data "hpegl_metal_available_resources" "compute" {
+	provider = hpegl.test
+}
+
+resource "hpegl_metal_volume" "test_va_vol" {
+	provider    = hpegl.test
+	name        = "test.va.volume"
+	size        = 10
+	flavor      = "Fast"
+	description = "hello from Terraform"
+	location    = var.location
+}
+
+resource "hpegl_metal_host" "test_va_host" {
+	provider          = hpegl.test
+	name              = "testVAHost"
+	image             = join("@", [data.hpegl_metal_available_resources.compute.images.0.flavor,
+		data.hpegl_metal_available_resources.compute.images.0.version])
+	machine_size      = data.hpegl_metal_available_resources.compute.machine_sizes.0.name
+	ssh               = ["User1 - Linux"]
+	networks          = ["Public", "Storage"]
+	network_route     = "Public"
+	location          = var.location
+	host_action_async = false
+}
+
+resource "hpegl_metal_volume_attachment" "test_va" {
+	provider = hpegl.test
+	volume   = hpegl_metal_volume.test_va_vol.name
+	host     = hpegl_metal_host.test_va_host.id
+}
+`
+}
+
+func testAccCheckVolumeAttachmentExists(rsrc string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[rsrc]
+		if !ok {
+			return fmt.Errorf("Volume attachment not found: %q", rsrc)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No volume attachment primary ID set")
+		}
+
+		p, err := client.GetClientFromMetaMap(testAccProvider.Meta())
+		if err != nil {
+			return fmt.Errorf("Error retrieving Metal client: %v", err)
+		}
+
+		ctx, err := p.GetContextWithError()
+		if err != nil {
+			return err
+		}
+
+		//nolint:bodyclose // Response body is closed by metal client.
+		va, _, err := p.Client.VolumeAttachmentsApi.GetByID(ctx, rs.Primary.ID, nil)
+		if err != nil {
+			return fmt.Errorf("Volume attachment: %q not found: %s", rs.Primary.ID, err)
+		}
+
+		if va.State != rest.VASTATEENUM_READY {
+			return fmt.Errorf("Volume attachment %s state %v != %v", va.ID, va.State, rest.VASTATEENUM_READY)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckVolumeAttachmentDestroy(s *terraform.State) error {
+	p, err := client.GetClientFromMetaMap(testAccProvider.Meta())
+	if err != nil {
+		return fmt.Errorf("Error retrieving Metal client: %v", err)
+	}
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "hpegl_metal_volume_attachment" {
+			continue
+		}
+
+		ctx, err := p.GetContextWithError()
+		if err != nil {
+			return err
+		}
+
+		//nolint:bodyclose // Response body is closed by metal client.
+		va, _, err := p.Client.VolumeAttachmentsApi.GetByID(ctx, rs.Primary.ID, nil)
+		if err == nil && va.State != rest.VASTATEENUM_DELETED {
+			return fmt.Errorf("Volume attachment %s still exists", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
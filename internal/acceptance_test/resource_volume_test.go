@@ -1,4 +1,4 @@
-// (C) Copyright 2020-2022 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2022, 2026 Hewlett Packard Enterprise Development LP
 
 package acceptance_test
 
@@ -111,7 +111,10 @@ func testAccCheckVolumeDestroy(t *testing.T, s *terraform.State) error {
 			return fmt.Errorf("Error retrieving Metal client: %v", err)
 		}
 
-		ctx := p.GetContext()
+		ctx, err := p.GetContextWithError()
+		if err != nil {
+			return err
+		}
 
 		//nolint:bodyclose // Response body is closed by metal client.
 		volume, _, err := p.Client.VolumesApi.GetByID(ctx, volumeID, nil)
@@ -141,7 +144,10 @@ func testAccCheckVolumeExists(resource string, id *string) resource.TestCheckFun
 			return fmt.Errorf("Error retrieving Metal client: %v", err)
 		}
 
-		ctx := p.GetContext()
+		ctx, err := p.GetContextWithError()
+		if err != nil {
+			return err
+		}
 
 		//nolint:bodyclose // Response body is closed by metal client.
 		_, _, err = p.Client.VolumesApi.GetByID(ctx, volumeID, nil)
@@ -1,10 +1,12 @@
-// (C) Copyright 2020-2023, 2025 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2023, 2025-2026 Hewlett Packard Enterprise Development LP
 
 package resources
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
@@ -286,7 +288,7 @@ func storagePoolResource() *schema.Resource {
 
 func DataSourceAvailableResources() *schema.Resource {
 	return &schema.Resource{
-		Read:        dataSourceAvailableResourcesRead,
+		ReadContext: dataSourceAvailableResourcesRead,
 		Description: "Provides a list of available resources in a project for creating Hosts and Volumes.",
 		Schema: map[string]*schema.Schema{
 			dsFilter: dataSourceFiltersSchema(),
@@ -350,40 +352,49 @@ func DataSourceAvailableResources() *schema.Resource {
 	}
 }
 
-func dataSourceAvailableResourcesRead(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to read available resources")
+func dataSourceAvailableResourcesRead(_ context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to read available resources")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
+	}
+
+	available, err := p.GetAvailableResources()
+	if err != nil {
+		return diagFromErr(err)
 	}
-	available := p.AvailableResources
 
 	if err = addLocations(d, available); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 	if err = addImages(d, available); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 	if err = addSSHKeys(d, available); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 	if err = addNetworks(p, d, available); err != nil {
-		return err
+		return diagFromErr(err)
 	}
-	if err = addMachineSizes(p, d, available); err != nil {
-		return err
+	filters, err := getFilters(d)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	if err = addMachineSizes(p, d, available, filters); err != nil {
+		return diagFromErr(err)
 	}
 	if err = addVolmeFlavors(p, d, available); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	if err = addStoragePools(p, d, available); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	if err = addVolumeCollections(p, d, available); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	d.SetId("resources")
@@ -466,37 +477,38 @@ func addNetworks(p *configuration.Config, d *schema.ResourceData, available rest
 	return nil
 }
 
-func addMachineSizes(p *configuration.Config, d *schema.ResourceData, available rest.AvailableResources) error {
+// addMachineSizes sets machine_sizes to an entry for each machine size and
+// location that has machines in inventory. Filters on the name and location
+// select the entries.
+func addMachineSizes(p *configuration.Config, d *schema.ResourceData, available rest.AvailableResources,
+	filters []filter,
+) error {
 	sizes := make([]map[string]interface{}, 0, len(available.MachineSizes))
+
 	for _, size := range available.MachineSizes {
-		var (
-			total                int
-			locationID, location string
-		)
 		for _, machines := range available.MachineInventory {
-			if machines.SizeID == size.ID {
-				total = int(machines.Number)
-				locationID = machines.LocationID
-				location, _ = p.GetLocationName(locationID)
-				break
+			if machines.SizeID != size.ID || machines.Number <= 0 {
+				continue
 			}
-		}
-		if total > 0 {
-			iData := map[string]interface{}{
+
+			location, _ := p.GetLocationName(machines.LocationID)
+			if !matchFilters(filters, map[string]string{sName: size.Name, sLocation: location}) {
+				continue
+			}
+
+			sizes = append(sizes, map[string]interface{}{
 				"id":         size.ID,
 				sName:        size.Name,
 				sDescription: size.Details.Banner1,
-				sLocationID:  locationID,
+				sLocationID:  machines.LocationID,
 				sLocation:    location,
-				sQuantity:    total,
-			}
-			sizes = append(sizes, iData)
+				sQuantity:    int(machines.Number),
+			})
 		}
 	}
-	if err := d.Set(avMachinesSizes, sizes); err != nil {
-		return err
-	}
-	return nil
+
+	//nolint:wrapcheck // caller defer func is wrapping the error.
+	return d.Set(avMachinesSizes, sizes)
 }
 
 func addVolmeFlavors(p *configuration.Config, d *schema.ResourceData, available rest.AvailableResources) error {
@@ -528,7 +540,7 @@ func addVolmeFlavors(p *configuration.Config, d *schema.ResourceData, available
 		iData[sLocation], _ = p.GetLocationName(vol.LocationID)
 		iData[vFlavor], _ = p.GetVolumeFlavorName(vol.FlavorID)
 		iData[vStoragePool], _ = p.GetStoragePoolName(vol.StoragePoolID)
-		iData[vCollection], _ = p.GetVolumeCollectionID(vol.VolumeCollectionID)
+		iData[vCollection], _ = p.GetVolumeCollectionName(vol.VolumeCollectionID)
 		existingVols = append(existingVols, iData)
 	}
 	if err := d.Set(avVolumes, existingVols); err != nil {
@@ -1,14 +1,17 @@
-// (C) Copyright 2023, 2025 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2023, 2025-2026 Hewlett Packard Enterprise Development LP
 
 package resources
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/internal/test-utils/fakeportal"
 	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/configuration"
 )
 
@@ -36,9 +39,7 @@ func Test_addNetworks(t *testing.T) {
 		},
 	}
 
-	cfg := &configuration.Config{
-		AvailableResources: client.AvailableResources{},
-	}
+	cfg := &configuration.Config{}
 
 	d := schema.TestResourceDataRaw(t, DataSourceAvailableResources().Schema, map[string]interface{}{})
 
@@ -64,3 +65,68 @@ func Test_addNetworks(t *testing.T) {
 	assert.Equal(t, testVni2, net["vni"])
 	assert.Equal(t, true, net["no_ip_pool"])
 }
+
+func TestAvailableMachineSizes(t *testing.T) {
+	t.Parallel()
+
+	srv, cfg, meta := newFakePortalMeta(t)
+	seeded := availableResources(t, cfg)
+
+	const otherLocation = "USA:West:AFCDCW1"
+
+	otherLocationID := srv.AddLocation(otherLocation)
+	g3iID := srv.AddMachineSize("G3i", client.FlavorDesc{Banner1: "2 x AMD EPYC 7302 - 32 cores"})
+	srv.SetMachineInventory(otherLocationID, seeded.MachineSizes[0].ID, 3)
+	srv.SetMachineInventory(otherLocationID, g3iID, 2)
+	srv.SetMachineInventory(seeded.Locations[0].ID, g3iID, 0)
+	cfg.InvalidateAvailableResources()
+
+	filter := func(name string, values ...interface{}) map[string]interface{} {
+		return map[string]interface{}{"name": name, "values": values}
+	}
+
+	tests := []struct {
+		name    string
+		filters []interface{}
+		exp     []string
+	}{
+		{
+			name: "All locations",
+			exp: []string{
+				fakeportal.MachineSize + " " + fakeportal.Location + " 10",
+				fakeportal.MachineSize + " " + otherLocation + " 3",
+				"G3i " + otherLocation + " 2",
+			},
+		},
+		{
+			name:    "By location",
+			filters: []interface{}{filter(sLocation, "^USA:West:")},
+			exp:     []string{fakeportal.MachineSize + " " + otherLocation + " 3", "G3i " + otherLocation + " 2"},
+		},
+		{
+			name:    "By location and name",
+			filters: []interface{}{filter(sLocation, "West"), filter(sName, "G2")},
+			exp:     []string{fakeportal.MachineSize + " " + otherLocation + " 3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := map[string]interface{}{}
+			if tt.filters != nil {
+				raw[dsFilter] = tt.filters
+			}
+
+			d := schema.TestResourceDataRaw(t, DataSourceAvailableResources().Schema, raw)
+			assert.Nil(t, dataSourceAvailableResourcesRead(context.Background(), d, meta))
+
+			sizes := make([]string, 0, len(tt.exp))
+			for _, s := range d.Get(avMachinesSizes).([]interface{}) {
+				size, _ := s.(map[string]interface{})
+				sizes = append(sizes, fmt.Sprintf("%s %s %d", size[sName], size[sLocation], size[sQuantity]))
+			}
+
+			assert.Equal(t, tt.exp, sizes)
+		})
+	}
+}
@@ -0,0 +1,568 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"gopkg.in/yaml.v2"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/configuration"
+)
+
+const (
+	// Inputs of the hpegl_metal_cloud_init_preview data source. The host
+	// attributes use the keys of the host resource.
+	cpServiceYAML = "service_yaml"
+	cpInterfaces  = "interfaces"
+
+	// The rendered info blocks of the service image.
+	cpFiles    = "files"
+	cpPath     = "path"
+	cpTarget   = "target"
+	cpContent  = "content"
+	cpRendered = "rendered"
+
+	// cpDefaultInterface is the interface of a host when none are given.
+	cpDefaultInterface = "eth0"
+)
+
+// hostDef is the model that go-text-template info blocks with hostdef-v2
+// input are rendered against, with the fields that can be known before a
+// host is created.
+type hostDef struct {
+	Name                  string
+	SSHKeys               []string
+	Connections           []hostDefConnection
+	InitiatorName         string
+	CHAPUser              string
+	CHAPSecret            string
+	ISCSIDiscoveryAddress string
+	VolumeAttachments     []hostDefVolume
+}
+
+// hostDefConnection is a connection of a host, over one interface or a bond of
+// several, that carries its networks.
+type hostDefConnection struct {
+	Name        string
+	Interfaces  []hostDefInterface
+	UntaggedNet hostDefNetwork
+	Networks    []hostDefNetwork
+}
+
+type hostDefInterface struct {
+	Name   string
+	HWAddr string
+}
+
+type hostDefNetwork struct {
+	Name   string
+	VID    int
+	Ranges []hostDefRange
+}
+
+// hostDefRange is the addressing of a host on a network.
+type hostDefRange struct {
+	Base    string
+	CIDR    int
+	Gateway string
+	DNS     []string
+	Proxy   string
+	NoProxy string
+	NTP     []string
+}
+
+type hostDefVolume struct {
+	Name      string
+	TargetIQN string
+	IPAddress string
+}
+
+// hostDefFuncs are the functions that info block templates can use.
+//
+//nolint:gochecknoglobals // Used as a constant
+var hostDefFuncs = template.FuncMap{
+	"contains":  strings.Contains,
+	"hasPrefix": strings.HasPrefix,
+	"hasSuffix": strings.HasSuffix,
+	"join":      strings.Join,
+	"split":     strings.Split,
+	"lower":     strings.ToLower,
+	"upper":     strings.ToUpper,
+	"trim":      strings.TrimSpace,
+	"replace":   strings.ReplaceAll,
+}
+
+func cloudInitPreviewSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		cpServiceYAML: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ExactlyOneOf: []string{cpServiceYAML, hImage},
+			Description:  "The YAML definition of a service image, eg file(\"service.yml\").",
+		},
+		hImage: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "An image of the project in the form of flavor@version, in place of service_yaml.",
+		},
+		hName: {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "The name of the host.",
+		},
+		hSSHKeys: {
+			Type:     schema.TypeList,
+			Optional: true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+			Description: "A list of names or IDs of SSH keys of the project, or of public keys.",
+		},
+		hLocation: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The location of the host, which is required for networks and volumes.",
+		},
+		hNetworks: {
+			Type:     schema.TypeList,
+			Optional: true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+			Description: "List of network names or IDs e.g. ['Public', 'Private'].",
+		},
+		hNetUntagged: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The one of networks that is untagged.",
+		},
+		hPreAllocatedIPs: {
+			Type:     schema.TypeList,
+			Optional: true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+			Description: "The IP addresses of the host on each of networks. The default is the first address of each pool.",
+		},
+		hVolumeAttachments: {
+			Type:     schema.TypeList,
+			Optional: true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+			Description: "List of names or IDs of volumes that are attached to the host.",
+		},
+		hInitiatorName: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "The iSCSI initiator name of the host.",
+		},
+		cpInterfaces: {
+			Type:     schema.TypeList,
+			Optional: true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+			Description: "The names of the network interfaces of the host, which are bonded when there are several. " +
+				"The default is eth0.",
+		},
+		cpFiles: {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "The files of the info blocks of the service image.",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					cpPath: {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "The path of the file on the host.",
+					},
+					cpTarget: {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "Where the file is written, eg hdd.",
+					},
+					cpContent: {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "The content of the file.",
+					},
+					cpRendered: {
+						Type:        schema.TypeBool,
+						Computed:    true,
+						Description: "Whether the content was rendered from a template.",
+					},
+				},
+			},
+		},
+	}
+}
+
+func DataSourceCloudInitPreview() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceCloudInitPreviewRead,
+		Schema:      cloudInitPreviewSchema(),
+		Description: "Renders the info blocks of a service image, such as its cloud-init, for a planned host, " +
+			"so that the files that are written to the host can be reviewed before it is created.",
+	}
+}
+
+func dataSourceCloudInitPreviewRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to render cloud-init")
+
+	p, err := client.GetClientFromMetaMap(meta)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	infos, err := serviceInfos(ctx, p, d)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	def, err := planHostDef(ctx, p, d)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	files := make([]map[string]interface{}, 0, len(infos))
+	sum := sha256.New()
+
+	for _, info := range infos {
+		content, rendered, err := renderInfo(info, def)
+		if err != nil {
+			return diag.Errorf("info block %s: %v", info.Path, err)
+		}
+
+		if !rendered && info.Templating == rest.TEMPLATING_GO_TEXT_TEMPLATE {
+			diags = append(diags, warning("info block %s uses %s input, only %s is rendered",
+				info.Path, info.TemplatingInput, rest.TEMPLATINGINFO_V2))
+		}
+
+		files = append(files, map[string]interface{}{
+			cpPath:     info.Path,
+			cpTarget:   string(info.Target),
+			cpContent:  content,
+			cpRendered: rendered,
+		})
+
+		fmt.Fprintf(sum, "%s\x00%s\x00", info.Path, content)
+	}
+
+	if err = d.Set(cpFiles, files); err != nil {
+		return append(diags, diagFromErr(err)...)
+	}
+
+	d.SetId(hex.EncodeToString(sum.Sum(nil)))
+
+	return diags
+}
+
+// serviceInfos returns the info blocks of the service image given by its YAML
+// definition, or by the image of the project.
+func serviceInfos(ctx context.Context, p *configuration.Config, d *schema.ResourceData) ([]rest.PassedInfo, error) {
+	if data := safeString(d.Get(cpServiceYAML)); data != "" {
+		var svc struct {
+			Info []struct {
+				Contents        string `yaml:"contents"`
+				Encoding        string `yaml:"encoding"`
+				Templating      string `yaml:"templating"`
+				TemplatingInput string `yaml:"templating_input"`
+				Target          string `yaml:"target"`
+				Path            string `yaml:"path"`
+			} `yaml:"info"`
+		}
+
+		if err := yaml.Unmarshal([]byte(data), &svc); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", cpServiceYAML, err)
+		}
+
+		infos := make([]rest.PassedInfo, 0, len(svc.Info))
+		for _, info := range svc.Info {
+			infos = append(infos, rest.PassedInfo{
+				Contents:        info.Contents,
+				Encoding:        rest.Encoding(info.Encoding),
+				Templating:      rest.Templating(info.Templating),
+				TemplatingInput: rest.TemplatingInfo(info.TemplatingInput),
+				Target:          rest.Target(info.Target),
+				Path:            info.Path,
+			})
+		}
+
+		return infos, nil
+	}
+
+	resources, err := p.GetAvailableResources(configuration.KindImages)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := imageID(resources.Images, safeString(d.Get(hImage)))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, _, err := p.Client.ServicesApi.GetByID(ctx, id, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get image %s: %w", id, err)
+	}
+
+	return svc.Info, nil
+}
+
+// renderInfo returns the content of an info block, rendered against def if it
+// is a go-text-template with hostdef-v2 input.
+func renderInfo(info rest.PassedInfo, def hostDef) (string, bool, error) {
+	var (
+		raw []byte
+		err error
+	)
+
+	switch info.Encoding {
+	case rest.ENCODING_BASE64:
+		raw, err = base64.StdEncoding.DecodeString(strings.TrimSpace(info.Contents))
+	case rest.ENCODING_HEX:
+		raw, err = hex.DecodeString(strings.TrimSpace(info.Contents))
+	default:
+		raw = []byte(info.Contents)
+	}
+
+	if err != nil {
+		return "", false, fmt.Errorf("decode %s contents: %w", info.Encoding, err)
+	}
+
+	if info.Templating != rest.TEMPLATING_GO_TEXT_TEMPLATE || info.TemplatingInput != rest.TEMPLATINGINFO_V2 {
+		return string(raw), false, nil
+	}
+
+	tmpl, err := template.New("info").Funcs(hostDefFuncs).Parse(string(raw))
+	if err != nil {
+		return "", false, err
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, def); err != nil {
+		return "", false, err
+	}
+
+	return buf.String(), true, nil
+}
+
+// planHostDef returns the hostdef-v2 model of the planned host. Interfaces are
+// given made up MAC addresses, and hosts are given the first address of the
+// pool of each network unless allocated_ips is set.
+func planHostDef(ctx context.Context, p *configuration.Config, d *schema.ResourceData) (hostDef, error) {
+	def := hostDef{
+		Name:          safeString(d.Get(hName)),
+		InitiatorName: safeString(d.Get(hInitiatorName)),
+	}
+
+	resources, err := p.GetAvailableResources(configuration.KindSSHKeys, configuration.KindNetworks,
+		configuration.KindVolumes, configuration.KindLocations)
+	if err != nil {
+		return def, err
+	}
+
+	for _, key := range convertStringArr(d.Get(hSSHKeys).([]interface{})) {
+		public, err := sshKeyPublicKey(resources.SSHKeys, key)
+		if err != nil {
+			return def, err
+		}
+
+		def.SSHKeys = append(def.SSHKeys, public)
+	}
+
+	networks := convertStringArr(d.Get(hNetworks).([]interface{}))
+	volumes := convertStringArr(d.Get(hVolumeAttachments).([]interface{}))
+
+	if len(networks) == 0 && len(volumes) == 0 {
+		return def, nil
+	}
+
+	locationID, err := p.GetLocationID(safeString(d.Get(hLocation)))
+	if err != nil {
+		return def, fmt.Errorf("%s: %w", hLocation, err)
+	}
+
+	if len(networks) > 0 {
+		conn, err := planHostDefConnection(ctx, p, d, resources.Networks, locationID, networks)
+		if err != nil {
+			return def, err
+		}
+
+		def.Connections = []hostDefConnection{conn}
+	}
+
+	for _, vol := range volumes {
+		info, ok := findVolumeInfo(resources.Volumes, vol, locationID)
+		if !ok {
+			return def, fmt.Errorf("volume %q not found in location %q", vol, locationID)
+		}
+
+		def.VolumeAttachments = append(def.VolumeAttachments, hostDefVolume{
+			Name:      info.Name,
+			TargetIQN: info.TargetIQN,
+			IPAddress: info.DiscoveryIP,
+		})
+
+		if def.ISCSIDiscoveryAddress == "" {
+			def.ISCSIDiscoveryAddress = info.DiscoveryIP
+		}
+	}
+
+	return def, nil
+}
+
+// planHostDefConnection returns the connection of the planned host, with its
+// untagged network apart from the others.
+func planHostDefConnection(ctx context.Context, p *configuration.Config, d *schema.ResourceData,
+	available []rest.AvailableNetwork, locationID string, networks []string,
+) (hostDefConnection, error) {
+	var conn hostDefConnection
+
+	names := convertStringArr(d.Get(cpInterfaces).([]interface{}))
+	if len(names) == 0 {
+		names = []string{cpDefaultInterface}
+	}
+
+	for i, name := range names {
+		conn.Interfaces = append(conn.Interfaces, hostDefInterface{
+			Name:   name,
+			HWAddr: fmt.Sprintf("02:00:00:00:00:%02x", i),
+		})
+	}
+
+	conn.Name = names[0]
+	if len(names) > 1 {
+		conn.Name = "bond0"
+	}
+
+	ips := convertStringArr(d.Get(hPreAllocatedIPs).([]interface{}))
+	if len(ips) > 0 && len(ips) != len(networks) {
+		return conn, fmt.Errorf("%s: %d IP addresses are given for %d networks, there must be one for each network",
+			hPreAllocatedIPs, len(ips), len(networks))
+	}
+
+	ctx, err := p.ContextWithToken(ctx)
+	if err != nil {
+		return conn, err
+	}
+
+	untagged := safeString(d.Get(hNetUntagged))
+
+	for i, name := range networks {
+		net, err := findNetwork(available, name, locationID)
+		if err != nil {
+			return conn, err
+		}
+
+		hnet := hostDefNetwork{Name: net.Name, VID: int(net.VLAN)}
+
+		if net.IPPoolID != "" && !net.NoIPPool {
+			pool, _, err := p.Client.IppoolsApi.GetByID(ctx, net.IPPoolID, nil)
+			if err != nil {
+				return conn, fmt.Errorf("get IP pool of network %s: %w", net.Name, err)
+			}
+
+			ip := ""
+			if len(ips) > 0 {
+				ip = ips[i]
+			} else if len(pool.Sources) > 0 {
+				ip = pool.Sources[0].Base
+			}
+
+			cidr, _ := strconv.Atoi(strings.TrimPrefix(string(pool.Netmask), "/"))
+
+			hnet.Ranges = []hostDefRange{{
+				Base:    ip,
+				CIDR:    cidr,
+				Gateway: pool.DefaultRoute,
+				DNS:     pool.DNS,
+				Proxy:   pool.Proxy,
+				NoProxy: pool.NoProxy,
+				NTP:     pool.NTP,
+			}}
+		}
+
+		if untagged != "" && (untagged == net.ID || untagged == net.Name) {
+			conn.UntaggedNet = hnet
+		} else {
+			conn.Networks = append(conn.Networks, hnet)
+		}
+	}
+
+	return conn, nil
+}
+
+// sshKeyPublicKey returns the public key of an SSH key of the project given by
+// its name or ID, or key itself if it is a public key.
+func sshKeyPublicKey(keys []rest.SshKeyEntry, key string) (string, error) {
+	if strings.HasPrefix(key, "ssh-") || strings.HasPrefix(key, "ecdsa-") {
+		return key, nil
+	}
+
+	for _, k := range keys {
+		if k.ID == key || k.Name == key {
+			return k.Key, nil
+		}
+	}
+
+	return "", fmt.Errorf("SSH key %q not found", key)
+}
+
+// findNetwork returns the network of a location given by its name or ID.
+func findNetwork(available []rest.AvailableNetwork, name, locationID string) (rest.AvailableNetwork, error) {
+	var matches []rest.AvailableNetwork
+
+	for _, net := range available {
+		if net.LocationID != locationID {
+			continue
+		}
+
+		if net.ID == name {
+			return net, nil
+		}
+
+		if net.Name == name {
+			matches = append(matches, net)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return rest.AvailableNetwork{}, fmt.Errorf("network %q not found in location %q", name, locationID)
+	case 1:
+		return matches[0], nil
+	default:
+		return rest.AvailableNetwork{}, fmt.Errorf("network %q is ambiguous in location %q, use its ID", name, locationID)
+	}
+}
+
+// findVolumeInfo returns the volume of a location given by its name or ID.
+func findVolumeInfo(volumes []rest.VolumeInfo, name, locationID string) (rest.VolumeInfo, bool) {
+	for _, vol := range volumes {
+		if vol.LocationID == locationID && (vol.ID == name || vol.Name == name) {
+			return vol, true
+		}
+	}
+
+	return rest.VolumeInfo{}, false
+}
@@ -0,0 +1,154 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/internal/test-utils/fakeportal"
+)
+
+const exampleServiceFile = "../../examples/resources/hpegl_metal_image/service.yml"
+
+func TestRenderInfo(t *testing.T) {
+	def := hostDef{Name: "web1.example.com", SSHKeys: []string{"ssh-rsa AAAA"}}
+
+	tests := []struct {
+		name        string
+		info        rest.PassedInfo
+		expContent  string
+		expRendered bool
+		expErr      string
+	}{
+		{
+			name: "Template",
+			info: rest.PassedInfo{
+				Contents:        base64.StdEncoding.EncodeToString([]byte(`{{if contains .Name "."}}fqdn: {{.Name}}{{end}}`)),
+				Encoding:        rest.ENCODING_BASE64,
+				Templating:      rest.TEMPLATING_GO_TEXT_TEMPLATE,
+				TemplatingInput: rest.TEMPLATINGINFO_V2,
+			},
+			expContent:  "fqdn: web1.example.com",
+			expRendered: true,
+		},
+		{
+			name:       "Not a template",
+			info:       rest.PassedInfo{Contents: "{{.Name}}", Encoding: rest.ENCODING_NONE, Templating: rest.TEMPLATING_NONE},
+			expContent: "{{.Name}}",
+		},
+		{
+			name: "hostdef-v1",
+			info: rest.PassedInfo{
+				Contents:        "{{.Name}}",
+				Templating:      rest.TEMPLATING_GO_TEXT_TEMPLATE,
+				TemplatingInput: rest.TEMPLATINGINFO_V1,
+			},
+			expContent: "{{.Name}}",
+		},
+		{
+			name: "Unknown field",
+			info: rest.PassedInfo{
+				Contents:        "{{.Serial}}",
+				Templating:      rest.TEMPLATING_GO_TEXT_TEMPLATE,
+				TemplatingInput: rest.TEMPLATINGINFO_V2,
+			},
+			expErr: "can't evaluate field Serial",
+		},
+		{
+			name:   "Bad encoding",
+			info:   rest.PassedInfo{Contents: "not hex", Encoding: rest.ENCODING_HEX},
+			expErr: "decode hex contents",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, rendered, err := renderInfo(tt.info, def)
+			if tt.expErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.expErr)
+				}
+
+				return
+			}
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.expContent, content)
+				assert.Equal(t, tt.expRendered, rendered)
+			}
+		})
+	}
+}
+
+func TestDataSourceCloudInitPreview(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+
+	service, err := os.ReadFile(exampleServiceFile)
+	require.NoError(t, err)
+
+	file, err := os.Open(exampleServiceFile)
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	_, _, err = cfg.Client.ServicesApi.Add(testContext(t, cfg), file, nil)
+	require.NoError(t, err)
+	cfg.InvalidateAvailableResources()
+
+	sshKey := availableResources(t, cfg).SSHKeys[0].Key
+
+	for _, source := range []map[string]interface{}{
+		{cpServiceYAML: string(service)},
+		{hImage: "ubuntu@18.04-20201103"},
+	} {
+		raw := map[string]interface{}{
+			hName:        "web1",
+			hSSHKeys:     []interface{}{fakeportal.SSHKey},
+			hLocation:    fakeportal.Location,
+			hNetworks:    []interface{}{fakeportal.PublicNetwork, fakeportal.StorageNetwork},
+			hNetUntagged: fakeportal.PublicNetwork,
+			cpInterfaces: []interface{}{"ens1", "ens2"},
+		}
+
+		for k, v := range source {
+			raw[k] = v
+		}
+
+		d := schema.TestResourceDataRaw(t, DataSourceCloudInitPreview().Schema, raw)
+		require.Nil(t, dataSourceCloudInitPreviewRead(context.Background(), d, meta))
+
+		files, _ := d.Get(cpFiles).([]interface{})
+		require.Len(t, files, 1)
+
+		f, _ := files[0].(map[string]interface{})
+		content := safeString(f[cpContent])
+
+		assert.NotEmpty(t, d.Id())
+		assert.Equal(t, "/etc/cloud/cloud.cfg.d/95_datasource.cfg", f[cpPath])
+		assert.Equal(t, "hdd", f[cpTarget])
+		assert.Equal(t, true, f[cpRendered])
+		assert.NoError(t, checkCloudConfig(content))
+
+		for _, exp := range []string{
+			"hostname: web1\nfqdn:     web1.localdomain",
+			"ssh_authorized_keys:\n      - " + sshKey,
+			"- type: bond\n      name: bond0",
+			// The untagged network is on the bond, the other network on a VLAN of it.
+			"address: 10.0.0.10/24",
+			"- type: vlan\n      name: bond0.101",
+			"address: 10.0.1.10/24",
+		} {
+			assert.Contains(t, content, exp)
+		}
+	}
+}
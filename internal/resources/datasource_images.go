@@ -1,16 +1,20 @@
-// (C) Copyright 2020-2022 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2022, 2026 Hewlett Packard Enterprise Development LP
 
 package resources
 
 import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/configuration"
 )
 
 func DataSourceImage() *schema.Resource {
 	return &schema.Resource{
-		Read: dataSourceImageRead,
+		ReadContext: dataSourceImageRead,
 		Schema: map[string]*schema.Schema{
 			avImages: {
 				Type:     schema.TypeList,
@@ -24,20 +28,24 @@ func DataSourceImage() *schema.Resource {
 
 }
 
-func dataSourceImageRead(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to read images")
+func dataSourceImageRead(_ context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to read images")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
+	}
+
+	available, err := p.GetAvailableResources(configuration.KindImages)
+	if err != nil {
+		return diagFromErr(err)
 	}
-	available := p.AvailableResources
 
 	var images = make([]map[string]interface{}, 0, len(available.Images))
 	for _, image := range available.Images {
 		filters, err := getFilters(d)
 		if err != nil {
-			return err
+			return diagFromErr(err)
 		}
 		matched := (len(filters) == 0)
 		flavorMatch, categoryMatch, versionMatch := true, true, true
@@ -63,7 +71,7 @@ func dataSourceImageRead(d *schema.ResourceData, meta interface{}) (err error) {
 		}
 	}
 	if err := d.Set(avImages, images); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 	d.SetId("images")
 	return nil
@@ -0,0 +1,452 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/configuration"
+)
+
+const (
+	// Constraints of the hpegl_metal_machine_size data source.
+	msName        = "name"
+	msLocation    = "location"
+	msMinMemoryGB = "min_memory_gb"
+	msMinCores    = "min_cores"
+	msMinGPUs     = "min_gpus"
+
+	// Details of the selected machine size.
+	msLocationID  = "location_id"
+	msDescription = "description"
+	msCPU         = "cpu"
+	msCPUCount    = "cpu_count"
+	msCores       = "cores"
+	msMemoryGB    = "memory_gb"
+	msDisks       = "disks"
+	msNICs        = "nics"
+	msGPU         = "gpu"
+	msGPUCount    = "gpu_count"
+	msQuantity    = "quantity"
+
+	// For msDisks and msNICs each terraform block has these attributes.
+	msCount     = "count"
+	msSizeGB    = "size_gb"
+	msType      = "type"
+	msSpeedGbps = "speed_gbps"
+)
+
+// machineSizeSpec is the hardware of a machine size, as parsed from the
+// banners and bullets of its details.
+type machineSizeSpec struct {
+	cpu      string
+	cpuCount int
+	cores    int
+	memoryGB int
+	disks    []diskSpec
+	nics     []nicSpec
+	gpu      string
+	gpuCount int
+}
+
+type diskSpec struct {
+	count  int
+	sizeGB int
+	kind   string
+}
+
+type nicSpec struct {
+	count     int
+	speedGbps int
+}
+
+// Patterns of the hardware in the details of a machine size, e.g.
+// "2 x Intel Xeon Silver 4210 - 20 cores", "192GB RAM", "2 x 480GB SSD",
+// "2 x 25Gb NIC" and "1 x NVIDIA A100 GPU".
+//
+//nolint:gochecknoglobals // the patterns are compiled once
+var (
+	reCores  = regexp.MustCompile(`(?i)(\d+)\s*cores?\b`)
+	reMemory = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*([GT])i?B\s*(?:of\s+)?(?:RAM|memory|DDR\d?)`)
+	reDisk   = regexp.MustCompile(`(?i)^(?:(\d+)\s*x\s*)?(\d+(?:\.\d+)?)\s*([GT])i?B\s+` +
+		`(.*\b(?:SSD|HDD|NVMe|SAS|SATA|disk|drive)s?\b.*)$`)
+	reNIC   = regexp.MustCompile(`(?i)^(?:(\d+)\s*x\s*)?(\d+)\s*Gb(?:E|ps)?\b.*\bNICs?\b`)
+	reGPU   = regexp.MustCompile(`(?i)^(?:(\d+)\s*x\s*)?(.*?)\s*GPUs?\b`)
+	reCount = regexp.MustCompile(`(?i)^(\d+)\s*x\s*`)
+)
+
+// parseMachineSize parses the hardware of a machine size from its details.
+// Anything that isn't recognized is left out.
+func parseMachineSize(size rest.MachineSize) machineSizeSpec {
+	var spec machineSizeSpec
+
+	lines := append([]string{size.Details.Banner1, size.Details.Banner2}, size.Details.Bullets...)
+
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+
+		if m := reCores.FindStringSubmatch(line); m != nil && spec.cores == 0 {
+			spec.cores = atoi(m[1])
+		}
+
+		if m := reMemory.FindStringSubmatch(line); m != nil && spec.memoryGB == 0 {
+			spec.memoryGB = sizeGB(m[1], m[2])
+		}
+
+		if m := reDisk.FindStringSubmatch(line); m != nil {
+			spec.disks = append(spec.disks, diskSpec{count: countOf(m[1]), sizeGB: sizeGB(m[2], m[3]), kind: m[4]})
+
+			continue
+		}
+
+		if m := reNIC.FindStringSubmatch(line); m != nil {
+			spec.nics = append(spec.nics, nicSpec{count: countOf(m[1]), speedGbps: atoi(m[2])})
+
+			continue
+		}
+
+		if m := reGPU.FindStringSubmatch(line); m != nil && spec.gpu == "" {
+			spec.gpu, spec.gpuCount = m[2], countOf(m[1])
+
+			continue
+		}
+
+		// The CPU is in the first banner, before the number of cores.
+		if i == 0 && line != "" {
+			cpu, _, _ := strings.Cut(line, " - ")
+			if m := reCount.FindStringSubmatch(cpu); m != nil {
+				spec.cpuCount = atoi(m[1])
+				cpu = cpu[len(m[0]):]
+			} else {
+				spec.cpuCount = 1
+			}
+
+			spec.cpu = strings.TrimSpace(cpu)
+		}
+	}
+
+	return spec
+}
+
+// sized reports whether the cores and memory of the machine size were parsed
+// from its details. Those that weren't can't be ranked by size.
+func (s machineSizeSpec) sized() bool {
+	return s.cores > 0 && s.memoryGB > 0
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+
+	return n
+}
+
+// countOf returns the count of "N x" in front of an item, which is one if
+// there is none.
+func countOf(s string) int {
+	if s == "" {
+		return 1
+	}
+
+	return atoi(s)
+}
+
+// sizeGB returns the size in GB of a size in GB or TB.
+func sizeGB(size, unit string) int {
+	f, _ := strconv.ParseFloat(size, 64)
+	if strings.EqualFold(unit, "T") {
+		f *= 1024
+	}
+
+	return int(f)
+}
+
+func DataSourceMachineSize() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceMachineSizeRead,
+		Description: "Selects the smallest machine size, with machines in inventory, that meets the constraints. " +
+			"The portal has no prices for machine sizes, so the smallest is taken to be the cheapest. Machine sizes " +
+			"whose cores and memory aren't known from their details are selected last. Its id can be used as the " +
+			"machine_size of a host.",
+		Schema: map[string]*schema.Schema{
+			msName: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The name or ID of the machine size, to select a specific machine size.",
+			},
+			msLocation: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The location of the machines, of the form 'country:region:centre'. The default is any location.",
+			},
+			msMinMemoryGB: {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The least memory, in GB, of the machine size.",
+			},
+			msMinCores: {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The least number of CPU cores of the machine size.",
+			},
+			msMinGPUs: {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The least number of GPUs of the machine size.",
+			},
+			msLocationID: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The ID of the location of the machines.",
+			},
+			msDescription: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The first banner of the machine size details.",
+			},
+			msCPU: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The CPU model.",
+			},
+			msCPUCount: {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The number of CPUs.",
+			},
+			msCores: {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The total number of CPU cores.",
+			},
+			msMemoryGB: {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The memory in GB.",
+			},
+			msDisks: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The local disks.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						msCount: {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The number of disks.",
+						},
+						msSizeGB: {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The size of each disk in GB.",
+						},
+						msType: {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The type of the disks, e.g. 'SSD'.",
+						},
+					},
+				},
+			},
+			msNICs: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The network interfaces.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						msCount: {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The number of NICs.",
+						},
+						msSpeedGbps: {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The speed of each NIC in Gb/s.",
+						},
+					},
+				},
+			},
+			msGPU: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The GPU model, if the machine size has GPUs.",
+			},
+			msGPUCount: {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The number of GPUs.",
+			},
+			msQuantity: {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of available machines of this size in the location",
+			},
+		},
+	}
+}
+
+// machineSizeCandidate is a machine size with machines in inventory at a location.
+type machineSizeCandidate struct {
+	size       rest.MachineSize
+	spec       machineSizeSpec
+	locationID string
+	location   string
+	quantity   int
+}
+
+func dataSourceMachineSizeRead(_ context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to read machine size")
+
+	p, err := client.GetClientFromMetaMap(meta)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	available, err := p.GetAvailableResources(configuration.KindMachines, configuration.KindLocations)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	candidates, err := machineSizeCandidates(p, d, available)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	if len(candidates) == 0 {
+		return diag.Errorf("no machine size with machines in inventory meets the constraints")
+	}
+
+	// The smallest machine size is the one with the least cores, then memory,
+	// then GPUs, and those whose size isn't known come last. The portal has no
+	// prices for machine sizes, so the smallest is taken to be the cheapest. Of
+	// the same size, the location with the most machines wins.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+
+		switch {
+		case a.spec.sized() != b.spec.sized():
+			return a.spec.sized()
+		case a.spec.cores != b.spec.cores:
+			return a.spec.cores < b.spec.cores
+		case a.spec.memoryGB != b.spec.memoryGB:
+			return a.spec.memoryGB < b.spec.memoryGB
+		case a.spec.gpuCount != b.spec.gpuCount:
+			return a.spec.gpuCount < b.spec.gpuCount
+		case a.size.Name != b.size.Name:
+			return a.size.Name < b.size.Name
+		default:
+			return a.quantity > b.quantity
+		}
+	})
+
+	if err = setMachineSize(d, candidates[0]); err != nil {
+		return diagFromErr(err)
+	}
+
+	d.SetId(candidates[0].size.ID)
+
+	return nil
+}
+
+// machineSizeCandidates returns the machine sizes, with machines in inventory,
+// that meet the constraints, once for each of their locations.
+func machineSizeCandidates(p *configuration.Config, d *schema.ResourceData, available rest.AvailableResources,
+) ([]machineSizeCandidate, error) {
+	name := safeString(d.Get(msName))
+	minMemory, _ := d.Get(msMinMemoryGB).(int)
+	minCores, _ := d.Get(msMinCores).(int)
+	minGPUs, _ := d.Get(msMinGPUs).(int)
+
+	locationID := ""
+
+	if location := safeString(d.Get(msLocation)); location != "" {
+		id, err := p.GetLocationID(location)
+		if err != nil {
+			return nil, err
+		}
+
+		locationID = id
+	}
+
+	var candidates []machineSizeCandidate
+
+	for _, size := range available.MachineSizes {
+		if name != "" && name != size.Name && name != size.ID {
+			continue
+		}
+
+		spec := parseMachineSize(size)
+		if spec.memoryGB < minMemory || spec.cores < minCores || spec.gpuCount < minGPUs {
+			continue
+		}
+
+		for _, inv := range available.MachineInventory {
+			if inv.SizeID != size.ID || inv.Number <= 0 || (locationID != "" && inv.LocationID != locationID) {
+				continue
+			}
+
+			location, _ := p.GetLocationName(inv.LocationID)
+			candidates = append(candidates, machineSizeCandidate{
+				size:       size,
+				spec:       spec,
+				locationID: inv.LocationID,
+				location:   location,
+				quantity:   int(inv.Number),
+			})
+		}
+	}
+
+	return candidates, nil
+}
+
+func setMachineSize(d *schema.ResourceData, c machineSizeCandidate) error {
+	disks := make([]map[string]interface{}, 0, len(c.spec.disks))
+	for _, disk := range c.spec.disks {
+		disks = append(disks, map[string]interface{}{msCount: disk.count, msSizeGB: disk.sizeGB, msType: disk.kind})
+	}
+
+	nics := make([]map[string]interface{}, 0, len(c.spec.nics))
+	for _, nic := range c.spec.nics {
+		nics = append(nics, map[string]interface{}{msCount: nic.count, msSpeedGbps: nic.speedGbps})
+	}
+
+	values := map[string]interface{}{
+		msName:        c.size.Name,
+		msLocation:    c.location,
+		msLocationID:  c.locationID,
+		msDescription: c.size.Details.Banner1,
+		msCPU:         c.spec.cpu,
+		msCPUCount:    c.spec.cpuCount,
+		msCores:       c.spec.cores,
+		msMemoryGB:    c.spec.memoryGB,
+		msDisks:       disks,
+		msNICs:        nics,
+		msGPU:         c.spec.gpu,
+		msGPUCount:    c.spec.gpuCount,
+		msQuantity:    c.quantity,
+	}
+
+	for key, value := range values {
+		if err := d.Set(key, value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	return nil
+}
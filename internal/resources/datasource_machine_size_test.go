@@ -0,0 +1,126 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/internal/test-utils/fakeportal"
+)
+
+func TestParseMachineSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		details rest.FlavorDesc
+		exp     machineSizeSpec
+	}{
+		{
+			name: "General purpose",
+			details: rest.FlavorDesc{
+				Banner1: "2 x Intel Xeon Silver 4210 - 20 cores",
+				Banner2: "192GB RAM",
+				Bullets: []string{"2 x 480GB SSD", "2 x 25Gb NIC"},
+			},
+			exp: machineSizeSpec{
+				cpu: "Intel Xeon Silver 4210", cpuCount: 2, cores: 20, memoryGB: 192,
+				disks: []diskSpec{{count: 2, sizeGB: 480, kind: "SSD"}},
+				nics:  []nicSpec{{count: 2, speedGbps: 25}},
+			},
+		},
+		{
+			name: "GPU",
+			details: rest.FlavorDesc{
+				Banner1: "AMD EPYC 7543 - 32 cores",
+				Banner2: "1TB RAM",
+				Bullets: []string{"2 x 1.92TB NVMe SSD", "8TB HDD", "4 x 100GbE NIC", "4 x NVIDIA A100 GPU"},
+			},
+			exp: machineSizeSpec{
+				cpu: "AMD EPYC 7543", cpuCount: 1, cores: 32, memoryGB: 1024,
+				disks: []diskSpec{{count: 2, sizeGB: 1966, kind: "NVMe SSD"}, {count: 1, sizeGB: 8192, kind: "HDD"}},
+				nics:  []nicSpec{{count: 4, speedGbps: 100}},
+				gpu:   "NVIDIA A100", gpuCount: 4,
+			},
+		},
+		{
+			name:    "Unrecognized",
+			details: rest.FlavorDesc{Banner2: "Custom build"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.exp, parseMachineSize(rest.MachineSize{Details: tt.details}))
+		})
+	}
+}
+
+func TestDataSourceMachineSize(t *testing.T) {
+	t.Parallel()
+
+	srv, cfg, meta := newFakePortalMeta(t)
+
+	const otherLocation = "USA:West:AFCDCW1"
+
+	otherLocationID := srv.AddLocation(otherLocation)
+	srv.AddMachineSize("S1", rest.FlavorDesc{Banner1: "Intel Xeon E-2236 - 6 cores", Banner2: "32GB RAM"})
+
+	gpuID := srv.AddMachineSize("GPU1", rest.FlavorDesc{
+		Banner1: "2 x AMD EPYC 7543 - 64 cores",
+		Banner2: "1TB RAM",
+		Bullets: []string{"4 x NVIDIA A100 GPU"},
+	})
+	srv.SetMachineInventory(otherLocationID, gpuID, 2)
+
+	// A machine size whose details aren't parsed, which has no known size.
+	customID := srv.AddMachineSize("Custom", rest.FlavorDesc{Banner1: "Custom build"})
+	srv.SetMachineInventory(otherLocationID, customID, 5)
+	cfg.InvalidateAvailableResources()
+
+	seededID := availableResources(t, cfg).MachineSizes[0].ID
+
+	tests := []struct {
+		name        string
+		constraints map[string]interface{}
+		expID       string
+		expLocation string
+		expErr      string
+	}{
+		{"Smallest with inventory", map[string]interface{}{}, seededID, fakeportal.Location, ""},
+		{"Memory", map[string]interface{}{msMinMemoryGB: 256}, gpuID, otherLocation, ""},
+		{"Cores and GPUs", map[string]interface{}{msMinCores: 32, msMinGPUs: 1}, gpuID, otherLocation, ""},
+		{"By name", map[string]interface{}{msName: "GPU1"}, gpuID, otherLocation, ""},
+		{"Unknown size in location", map[string]interface{}{msLocation: otherLocation}, gpuID, otherLocation, ""},
+		{"Unknown size by name", map[string]interface{}{msName: "Custom"}, customID, otherLocation, ""},
+		{
+			"None in location", map[string]interface{}{msMinCores: 32, msLocation: fakeportal.Location}, "", "",
+			"no machine size with machines in inventory meets the constraints",
+		},
+		{"Unknown location", map[string]interface{}{msLocation: "USA:Central:Missing"}, "", "", "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, DataSourceMachineSize().Schema, tt.constraints)
+			diags := dataSourceMachineSizeRead(context.Background(), d, meta)
+
+			if tt.expErr != "" {
+				if assert.True(t, diags.HasError()) {
+					assert.Contains(t, diags[0].Summary, tt.expErr)
+				}
+
+				return
+			}
+
+			if assert.False(t, diags.HasError(), "unexpected errors %v", diags) {
+				assert.Equal(t, tt.expID, d.Id())
+				assert.Equal(t, tt.expLocation, d.Get(msLocation))
+				assert.Positive(t, d.Get(msQuantity))
+			}
+		})
+	}
+}
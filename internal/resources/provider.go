@@ -1,4 +1,4 @@
-// (C) Copyright 2020-2022 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2022, 2026 Hewlett Packard Enterprise Development LP
 
 package resources
 
@@ -17,6 +17,19 @@ var (
 	resourceDefaultTimeouts *schema.ResourceTimeout
 )
 
+// The waits for the portal to act on a request on a host or volume attachment
+// poll it after a delay, and then no more often than the poll interval. They
+// are variables so that tests against the fake portal, which acts at once,
+// need not wait.
+//
+//nolint:gochecknoglobals // set near zero by tests
+var (
+	hostWaitDelay          = mediumTimeout
+	hostPollInterval       = shortTimeout
+	attachmentWaitDelay    = shortTimeout
+	attachmentPollInterval = pollInterval
+)
+
 func init() {
 	d := time.Minute * 60
 	resourceDefaultTimeouts = &schema.ResourceTimeout{
@@ -0,0 +1,91 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/internal/test-utils/fakeportal"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/configuration"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/constants"
+)
+
+// testWaitInterval is how long the waits for the fake portal, which acts on
+// requests at once, wait between polls.
+const testWaitInterval = time.Millisecond
+
+func TestMain(m *testing.M) {
+	hostWaitDelay, hostPollInterval = testWaitInterval, testWaitInterval
+	attachmentWaitDelay, attachmentPollInterval = testWaitInterval, testWaitInterval
+
+	os.Exit(m.Run())
+}
+
+// newFakePortalMeta starts a fake Metal portal and returns it, along with the
+// client configuration and the provider meta to pass to resource functions.
+func newFakePortalMeta(t *testing.T) (*fakeportal.Server, *configuration.Config, map[string]interface{}) {
+	t.Helper()
+
+	srv := fakeportal.New()
+	t.Cleanup(srv.Close)
+
+	cfg, err := srv.NewConfig()
+	if err != nil {
+		t.Fatalf("failed to configure client for fake portal: %v", err)
+	}
+
+	return srv, cfg, map[string]interface{}{constants.MetalClientMapKey: cfg}
+}
+
+// availableResources returns the available resources of cfg, fetching any that are stale.
+func availableResources(t *testing.T, cfg *configuration.Config) rest.AvailableResources {
+	t.Helper()
+
+	ar, err := cfg.GetAvailableResources()
+	if err != nil {
+		t.Fatalf("failed to get available resources: %v", err)
+	}
+
+	return ar
+}
+
+func testContext(t *testing.T, cfg *configuration.Config) context.Context {
+	t.Helper()
+
+	ctx, err := cfg.GetContextWithError()
+	if err != nil {
+		t.Fatalf("failed to get context: %v", err)
+	}
+
+	return ctx
+}
+
+// hostRawConfig returns the raw configuration of a host on the fake portal
+// with the keys in overrides set in place of the defaults, or left out when
+// they are nil.
+func hostRawConfig(overrides map[string]interface{}) map[string]interface{} {
+	raw := map[string]interface{}{
+		hName:     "host1",
+		hImage:    fakeportal.ImageFlavor + "@" + fakeportal.ImageVersion,
+		hSize:     fakeportal.MachineSize,
+		hSSHKeys:  []interface{}{fakeportal.SSHKey},
+		hNetworks: []interface{}{fakeportal.PublicNetwork},
+		hLocation: fakeportal.Location,
+	}
+
+	for k, v := range overrides {
+		if v == nil {
+			delete(raw, k)
+
+			continue
+		}
+
+		raw[k] = v
+	}
+
+	return raw
+}
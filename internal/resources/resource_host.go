@@ -1,15 +1,18 @@
-// (C) Copyright 2020-2024 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2024, 2026 Hewlett Packard Enterprise Development LP
 
 package resources
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
 	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
 	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/client"
@@ -32,13 +35,17 @@ const (
 	hNetUntaggedID        = "network_untagged_id"
 	hSSHKeys              = "ssh"
 	hSSHKeyIDs            = "ssh_ids"
+	hSSHKeyPush           = "ssh_key_push"
 	hSize                 = "machine_size"
 	hSizeID               = "machine_size_id"
+	hSizeFallbacks        = "machine_size_fallbacks"
 	hConnections          = "connections"
 	hConnectionsSubnet    = "connections_subnet"
 	hConnectionsGateway   = "connections_gateway"
 	hConnectionsVLAN      = "connections_vlan"
 	hUserData             = "user_data"
+	hUserDataEncoding     = "user_data_encoding"
+	hCloudInit            = "cloud_init"
 	hCHAPUser             = "chap_user"
 	hCHAPSecret           = "chap_secret"
 	hInitiatorName        = "initiator_name"
@@ -51,8 +58,19 @@ const (
 	hLabels               = "labels"
 	hSummaryStatus        = "summary_status"
 	hHostActionAsync      = "host_action_async"
+	hOnCreateFailure      = "on_create_failure"
 	hWWPNS                = "wwpns"
 
+	// power_state values that power the host on or off, or reset it.
+	powerOn    = "on"
+	powerOff   = "off"
+	powerReset = "reset"
+
+	// on_create_failure values for a host that fails to provision.
+	onCreateFailureKeep   = "keep"
+	onCreateFailureDelete = "delete"
+	onCreateFailureRetry  = "retry"
+
 	// allowedImageLength is number of Image related attributes that can be provided in the from of 'image@version'.
 	allowedImageLength = 2
 )
@@ -67,10 +85,10 @@ const (
 func hostSchema() map[string]*schema.Schema {
 	return map[string]*schema.Schema{
 		hName: {
-			Type:        schema.TypeString,
-			Required:    true,
-			ForceNew:    true,
-			Description: "Any friendly name to identify the host that will become the OS hostname in lower case.",
+			Type:     schema.TypeString,
+			Required: true,
+			Description: "Any friendly name to identify the host that will become the OS hostname in lower case. " +
+				"Renaming the host doesn't change the OS hostname.",
 		},
 		hImage: {
 			Type:        schema.TypeString,
@@ -81,12 +99,14 @@ func hostSchema() map[string]*schema.Schema {
 		hSSHKeys: {
 			Type:     schema.TypeList,
 			Required: true,
-			ForceNew: true,
 			Elem: &schema.Schema{
 				Type: schema.TypeString,
 			},
-			Description: "A list of SSH keys that will be pushed to the host.",
+			DiffSuppressFunc: suppressListNameOrID(hSSHKeyIDs),
+			Description: "A list of names or IDs of SSH keys that will be pushed to the host. Changing them replaces " +
+				"the host unless ssh_key_push is set.",
 		},
+		hSSHKeyPush: sshKeyPushSchema(),
 		hSSHKeyIDs: {
 			Type:     schema.TypeList,
 			Computed: true,
@@ -95,28 +115,61 @@ func hostSchema() map[string]*schema.Schema {
 			},
 		},
 		hSize: {
-			Type:        schema.TypeString,
-			Required:    true,
-			ForceNew:    true,
-			Description: "Some generic sizing information for the machine like 'Small', 'Very Large'.",
+			Type:             schema.TypeString,
+			Required:         true,
+			ForceNew:         true,
+			DiffSuppressFunc: suppressMachineSize,
+			Description:      "Some generic sizing information for the machine like 'Small', 'Very Large', or its ID.",
 		},
 		hSizeID: {
 			Type:        schema.TypeString,
 			Computed:    true,
 			Description: "Machine size ID",
 		},
+		hSizeFallbacks: {
+			Type:     schema.TypeList,
+			Optional: true,
+			Description: "Machine sizes, and optionally other locations, to create the host with in order of preference " +
+				"when there are no machines of machine_size in inventory at location.",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					hSize: {
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "The name or ID of the machine size.",
+					},
+					hLocation: {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "The location to create the host in. The default is the location of the host.",
+					},
+				},
+			},
+		},
 		hLocation: {
-			Type:        schema.TypeString,
-			Required:    true,
-			ForceNew:    true,
-			Description: "The location of where the machine will be provisioned, of the form 'country:region:centre', eg 'USA:Texas:AUSL2'.",
+			Type:             schema.TypeString,
+			Required:         true,
+			ForceNew:         true,
+			DiffSuppressFunc: suppressPlacement,
+			Description:      "The location of where the machine will be provisioned, of the form 'country:region:centre', eg 'USA:Texas:AUSL2'.",
 		},
 		hUserData: {
-			Type:        schema.TypeString,
-			Optional:    true,
-			ForceNew:    true,
-			Description: "Any yaml compliant string that will be merged into cloud-init for this host.",
+			Type:          schema.TypeString,
+			Optional:      true,
+			ForceNew:      true,
+			ConflictsWith: []string{hCloudInit},
+			ValidateFunc:  validateUserData,
+			Description: "Any yaml compliant string that will be merged into cloud-init for this host. " +
+				"cloud-config is checked when it is planned.",
+		},
+		hUserDataEncoding: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ForceNew:     true,
+			ValidateFunc: validation.StringInSlice([]string{userDataGzipBase64}, false),
+			Description:  "Set to gzip+base64 to send the user data to the portal gzipped and base64 encoded.",
 		},
+		hCloudInit: cloudInitSchema(),
 		hLocationID: {
 			Type:        schema.TypeString,
 			Computed:    true,
@@ -128,7 +181,8 @@ func hostSchema() map[string]*schema.Schema {
 			Elem: &schema.Schema{
 				Type: schema.TypeString,
 			},
-			Description: "List of network name e.g. ['Public', 'Private'].",
+			DiffSuppressFunc: suppressListNameOrID(hNetworkIDs),
+			Description:      "List of network names or IDs e.g. ['Public', 'Private'].",
 		},
 		hNetworkIDs: {
 			Type:     schema.TypeList,
@@ -197,7 +251,8 @@ func hostSchema() map[string]*schema.Schema {
 			Elem: &schema.Schema{
 				Type: schema.TypeString,
 			},
-			Description: "List of existing volume IDs",
+			DiffSuppressFunc: suppressVolumeAttachment,
+			Description:      "List of names or IDs of existing volumes",
 		},
 		hState: {
 			Type:        schema.TypeString,
@@ -215,14 +270,20 @@ func hostSchema() map[string]*schema.Schema {
 			Description: "The current portal communication state of the host",
 		},
 		hPwrState: {
-			Type:        schema.TypeString,
-			Computed:    true,
-			Description: "The current power state of the host",
+			Type:         schema.TypeString,
+			Optional:     true,
+			Computed:     true,
+			ValidateFunc: validation.StringInSlice([]string{powerOn, powerOff, powerReset}, true),
+			DiffSuppressFunc: func(_, old, new string, _ *schema.ResourceData) bool {
+				return strings.EqualFold(old, new)
+			},
+			Description: "The power state of the host. Set to on or off to power the host on or off, or to reset to reset it",
 		},
 		hNetForDefaultRoute: {
-			Type:        schema.TypeString,
-			Description: "Network selected for the default route",
-			Optional:    true,
+			Type:             schema.TypeString,
+			Description:      "Network selected for the default route",
+			Optional:         true,
+			DiffSuppressFunc: suppressDefaultRoute,
 		},
 		hNetForDefaultRouteID: {
 			Type:        schema.TypeString,
@@ -230,9 +291,10 @@ func hostSchema() map[string]*schema.Schema {
 			Description: "Network ID of the default route",
 		},
 		hNetUntagged: {
-			Type:        schema.TypeString,
-			Description: "Untagged network",
-			Optional:    true,
+			Type:             schema.TypeString,
+			Description:      "Untagged network",
+			Optional:         true,
+			DiffSuppressFunc: suppressNameOrID(hNetUntaggedID),
 		},
 		hNetUntaggedID: {
 			Type:        schema.TypeString,
@@ -243,7 +305,7 @@ func hostSchema() map[string]*schema.Schema {
 			Type:        schema.TypeSet,
 			Optional:    true,
 			Computed:    true,
-			Description: "Information about volumes attached to this host.",
+			Description: "Information about every volume attached to this host, including those not in volume_attachments.",
 			Elem: &schema.Resource{
 				Schema: volumeInfoSchema(),
 			},
@@ -264,6 +326,14 @@ func hostSchema() map[string]*schema.Schema {
 			Default:     true,
 			Description: "set true to do host create, update, and delete asynchronously.  The default is true.",
 		},
+		hOnCreateFailure: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      onCreateFailureKeep,
+			ValidateFunc: validation.StringInSlice([]string{onCreateFailureKeep, onCreateFailureDelete, onCreateFailureRetry}, false),
+			Description: "What to do with a host that fails to provision when host_action_async is false. keep leaves the " +
+				"failed host for inspection, delete deletes it, and retry deletes it and creates it once more. The default is keep.",
+		},
 		hWWPNS: {
 			Type:     schema.TypeList,
 			Computed: true,
@@ -277,15 +347,14 @@ func hostSchema() map[string]*schema.Schema {
 
 func HostResource() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceMetalHostCreate,
-		Read:   resourceMetalHostRead,
-		Delete: resourceMetalHostDelete,
-		Update: resourceMetalHostUpdate,
-		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
-		},
-		Schema:      hostSchema(),
-		Description: "Provides Host resource. This allows Metal Host creation, deletion and update.",
+		CreateContext: resourceMetalHostCreate,
+		ReadContext:   resourceMetalHostRead,
+		DeleteContext: resourceMetalHostDelete,
+		UpdateContext: resourceMetalHostUpdate,
+		CustomizeDiff: resourceMetalHostCustomizeDiff,
+		Importer:      &schema.ResourceImporter{StateContext: importHost},
+		Schema:        hostSchema(),
+		Description:   "Provides Host resource. This allows Metal Host creation, deletion and update.",
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(longTimeout),
 			Update: schema.DefaultTimeout(longTimeout),
@@ -305,110 +374,69 @@ func isHostActionAsync(d *schema.ResourceData) (bool, error) {
 
 // updateResourceData will update the ResourceData by querying the latest host
 // if the action is async.  Returns true if the action is async, false otherwise.
-func updateResourceData(d *schema.ResourceData, meta interface{}) (bool, error) {
+func updateResourceData(ctx context.Context, d *schema.ResourceData, meta interface{}) (bool, diag.Diagnostics) {
 	isAsync, err := isHostActionAsync(d)
 	if err != nil {
-		return false, err
+		return false, diag.FromErr(err)
 	}
 
 	if isAsync {
-		return true, resourceMetalHostRead(d, meta)
+		return true, resourceMetalHostRead(ctx, d, meta)
 	}
 
 	return false, nil
 }
 
-//nolint:funlen // Ignoring function length check on existing function
-func resourceMetalHostCreate(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to create host")
+func resourceMetalHostCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to create host")
 
+	return createHost(ctx, d, meta, safeString(d.Get(hOnCreateFailure)))
+}
+
+// createHost creates a host and, unless host_action_async is set, waits for it
+// to be ready. onFailure is what to do with the host if it fails to provision.
+//
+//nolint:funlen // Ignoring function length check on existing function
+func createHost(ctx context.Context, d *schema.ResourceData, meta interface{}, onFailure string) diag.Diagnostics {
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
-	// get available resources
-	resources := p.AvailableResources
+	// get available resources, with the current machine inventory
+	p.InvalidateAvailableResources(configuration.KindMachines)
+
+	resources, err := p.GetAvailableResources()
+	if err != nil {
+		return diagFromErr(err)
+	}
+
 	host := rest.NewHost{
 		Name:        d.Get(hName).(string),
 		Description: d.Get(hDescription).(string),
-		UserData:    d.Get(hUserData).(string),
 	}
 
 	// 1) verify that flavor and version are sane
-	flavorFound := false
-	versionFound := false
-
-	var targetImageFlavor, targetImageVersion string
-
-	image, ok := d.Get(hImage).(string)
-	if ok {
-		fv := strings.Split(image, "@")
-		if len(fv) == allowedImageLength {
-			targetImageFlavor = fv[0]
-			targetImageVersion = fv[1]
-		} else {
-			return fmt.Errorf("image attribute %q must be in falvor@version format", image)
-		}
+	if host.ServiceID, err = imageID(resources.Images, safeString(d.Get(hImage))); err != nil {
+		return diagFromErr(err)
 	}
 
-	flavors := []string{}
-	for _, image := range resources.Images {
-		if image.Flavor == targetImageFlavor {
-			flavorFound = true
-			if image.Version == targetImageVersion {
-				versionFound = true
-				host.ServiceID = image.ID
-			}
-		}
-		flavors = append(flavors, fmt.Sprintf("%s@%s", image.Flavor, image.Version))
-	}
-	if !flavorFound {
-		return fmt.Errorf("image flavor %q not found in %q", targetImageFlavor, flavors)
-	}
-	if !versionFound {
-		return fmt.Errorf("image version %q of flavor %q not found in %q", targetImageVersion, targetImageFlavor, flavors)
-	}
+	// 2) choose the machine size and location, and get their ids
+	var skipped []string
 
-	// 2) verify that machine size exists and get id
-	sizes := []string{}
-	targetSize := d.Get(hSize).(string)
-	for _, mSize := range resources.MachineSizes {
-		// Name match or an ID was used.
-		if mSize.Name == targetSize || mSize.ID == targetSize {
-			host.MachineSizeID = mSize.ID
-			break
-		}
-		sizes = append(sizes, mSize.Name)
-	}
-	if host.MachineSizeID == "" {
-		return fmt.Errorf("machine size %q not found in %q", targetSize, sizes)
+	host.MachineSizeID, host.LocationID, skipped, err = choosePlacement(p, resources, hostPlacements(d.Get))
+	if err != nil {
+		return diagFromErr(err)
 	}
 
 	// 3) verify that all of the ssh keys exist and get ids
-	var keyIDs, allKeys []string
 	for _, name := range convertStringArr(d.Get(hSSHKeys).([]interface{})) {
-		found := false
-		for _, sshKey := range resources.SSHKeys {
-			// Name match or an ID was used
-			if sshKey.Name == name || sshKey.ID == name {
-				found = true
-				keyIDs = append(keyIDs, sshKey.ID)
-				break
-			}
-			allKeys = append(allKeys, sshKey.Name)
-		}
-		if !found {
-			return fmt.Errorf("SSH key %q not found in %q", name, allKeys)
+		keyID, err := sshKeyID(resources.SSHKeys, name)
+		if err != nil {
+			return diagFromErr(err)
 		}
-	}
-	host.SSHKeyIDs = keyIDs
 
-	// 4) parse location, verify it exists, and get id
-	locationID, err := p.GetLocationID(d.Get(hLocation).(string))
-	if err != nil {
-		return err
+		host.SSHKeyIDs = append(host.SSHKeyIDs, keyID)
 	}
-	host.LocationID = locationID
 
 	// Add networks
 	processedNetworks := []string{}
@@ -435,15 +463,15 @@ func resourceMetalHostCreate(d *schema.ResourceData, meta interface{}) (err erro
 		}
 		if id, ok := podNetMap[network]; ok {
 			if podNetMapCount[network] > 1 {
-				return fmt.Errorf("network %q is ambiguous in location %q %s", network, host.LocationID, availableNetworks)
+				return diag.Errorf("network %q is ambiguous in location %q %s", network, host.LocationID, availableNetworks)
 			}
 			processedNetworks = append(processedNetworks, id)
 			continue
 		}
-		return fmt.Errorf("network %q not found in location %q %s", network, host.LocationID, availableNetworks)
+		return diag.Errorf("network %q not found in location %q %s", network, host.LocationID, availableNetworks)
 	}
 	if len(processedNetworks) == 0 {
-		return fmt.Errorf("no networks in %q found in %q", d.Get(hNetworks), availableNetworks)
+		return diag.Errorf("no networks in %q found in %q", d.Get(hNetworks), availableNetworks)
 	}
 
 	host.NetworkIDs = processedNetworks
@@ -453,14 +481,14 @@ func resourceMetalHostCreate(d *schema.ResourceData, meta interface{}) (err erro
 		host.NetworkForDefaultRoute = processedNetworks[0]
 	} else {
 		if host.NetworkForDefaultRoute, err = getNetworkID(p, host.NetworkIDs, host.LocationID, netDefaultRoute); err != nil {
-			return err
+			return diagFromErr(err)
 		}
 	}
 
 	// Untagged network
 	if netUntagged := safeString(d.Get(hNetUntagged)); netUntagged != "" {
 		if host.NetworkUntagged, err = getNetworkID(p, host.NetworkIDs, host.LocationID, netUntagged); err != nil {
-			return err
+			return diagFromErr(err)
 		}
 	}
 
@@ -468,12 +496,17 @@ func resourceMetalHostCreate(d *schema.ResourceData, meta interface{}) (err erro
 	for _, vID := range convertStringArr(d.Get(hVolumeAttachments).([]interface{})) {
 		id, exists := isVolumeAvailable(vID, resources.Volumes)
 		if !exists {
-			return fmt.Errorf("volume attachment failed due to volume %q does not exist", vID)
+			return diag.Errorf("volume attachment failed due to volume %q does not exist", vID)
 		}
 
 		host.VolumeIDs = append(host.VolumeIDs, id)
 	}
 
+	// User data, assembled from cloud_init and encoded as asked
+	if host.UserData, err = hostUserData(d.Get); err != nil {
+		return diagFromErr(err)
+	}
+
 	// PreAllocatedIP addresses
 	if ips, ok := d.Get(hPreAllocatedIPs).([]interface{}); ok {
 		host.PreAllocatedIPs = convertStringArr(ips)
@@ -485,22 +518,26 @@ func resourceMetalHostCreate(d *schema.ResourceData, meta interface{}) (err erro
 	}
 
 	// Create it
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 
 	h, _, err := p.Client.HostsApi.Add(ctx, host, nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	d.SetId(h.ID)
+	p.InvalidateAvailableResources(configuration.KindMachines, configuration.KindVolumes)
 
-	isAsync, err := updateResourceData(d, meta)
-	if err != nil {
-		return err
+	isAsync, diags := updateResourceData(ctx, d, meta)
+	for _, reason := range skipped {
+		diags = append(diags, warning("%s", reason))
 	}
 
-	if isAsync {
-		return nil
+	if isAsync || diags.HasError() {
+		return diags
 	}
 
 	// host create is asynchronous in Metal svc. Wait until host state is Ready.
@@ -518,63 +555,207 @@ func resourceMetalHostCreate(d *schema.ResourceData, meta interface{}) (err erro
 		Target: []string{
 			string(rest.HOSTSTATE_READY),
 		},
-		Refresh: func() (interface{}, string, error) {
-			host, _, err := p.Client.HostsApi.GetByID(ctx, h.ID, nil)
-			if err != nil {
-				return nil, "", fmt.Errorf("get host %v", h.ID)
-			}
-
-			return host, string(host.State), nil
-		},
+		Refresh:    hostCreateRefresh(ctx, p.Client.HostsApi, h.ID),
 		Timeout:    d.Timeout(schema.TimeoutCreate),
-		Delay:      mediumTimeout,
-		MinTimeout: shortTimeout,
+		Delay:      hostWaitDelay,
+		MinTimeout: hostPollInterval,
 	}
 
 	if _, err = createStateConf.WaitForStateContext(ctx); err != nil {
-		return fmt.Errorf("waiting for host instance (%s) to be created: %s", d.Id(), err)
+		var failure *hostProvisioningError
+		if errors.As(err, &failure) {
+			return handleHostCreateFailure(ctx, d, meta, onFailure, failure)
+		}
+
+		return diag.Errorf("waiting for host instance (%s) to be created: %s", d.Id(), err)
 	}
 
-	return resourceMetalHostRead(d, meta)
+	if err = changePowerState(ctx, d, p.Client.HostsApi, d.Timeout(schema.TimeoutCreate)); err != nil {
+		return diagFromErr(err)
+	}
+
+	return append(diags, resourceMetalHostRead(ctx, d, meta)...)
+}
+
+// hostProvisioningError is the error of a host that has failed to provision.
+type hostProvisioningError struct {
+	host rest.Host
+}
+
+func (e *hostProvisioningError) Error() string {
+	msg := fmt.Sprintf("provisioning failed with state %q, sub_state %q, summary_status %q",
+		e.host.State, e.host.Substate, e.host.SummaryStatus)
+
+	var alerts []string
+
+	for _, alert := range e.host.AlertInfo {
+		if !alert.Ack && alert.Message != "" {
+			alerts = append(alerts, alert.Message)
+		}
+	}
+
+	if len(alerts) > 0 {
+		msg += ": " + strings.Join(alerts, "; ")
+	}
+
+	return msg
+}
+
+// hostProvisioningFailed reports whether a host that is being created has
+// failed. The state of a host may stay as it was when its deployment fails, so
+// the sub-state is checked as well.
+func hostProvisioningFailed(host rest.Host) bool {
+	if host.State == rest.HOSTSTATE_FAILED {
+		return true
+	}
+
+	switch host.Substate {
+	case rest.HOSTSUBSTATE_FAILED, rest.HOSTSUBSTATE_ABORT_DEPLOY, rest.HOSTSUBSTATE_SNAP_LOG_OF_FAILURE:
+		return true
+	default:
+		return false
+	}
+}
+
+// hostCreateRefresh returns the refresh function that waits for a new host,
+// which stops with a *hostProvisioningError as soon as the host has failed.
+func hostCreateRefresh(ctx context.Context, hostAPI rest.HostsAPI, hostID string) retry.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		host, _, err := hostAPI.GetByID(ctx, hostID, nil)
+		if err != nil {
+			return nil, "", fmt.Errorf("get host %v: %w", hostID, err)
+		}
+
+		if hostProvisioningFailed(host) {
+			return host, string(host.State), &hostProvisioningError{host: host}
+		}
+
+		return host, string(host.State), nil
+	}
+}
+
+// handleHostCreateFailure does what on_create_failure asks with a host that
+// has failed to provision. A host that is kept is left in the state, where
+// Terraform marks it as tainted. A host that is deleted is removed from the
+// state, and with retry the host is created once more.
+func handleHostCreateFailure(ctx context.Context, d *schema.ResourceData, meta interface{}, onFailure string,
+	failure *hostProvisioningError,
+) diag.Diagnostics {
+	diags := diag.Errorf("waiting for host instance (%s) to be created: %s", d.Id(), failure)
+
+	if onFailure != onCreateFailureDelete && onFailure != onCreateFailureRetry {
+		return diags
+	}
+
+	if delDiags := resourceMetalHostDelete(ctx, d, meta); delDiags.HasError() {
+		return append(diags, delDiags...)
+	}
+
+	failedID := d.Id()
+	d.SetId("")
+
+	if onFailure == onCreateFailureDelete {
+		return diags
+	}
+
+	retried := diag.Diagnostics{warning("host %s was deleted and is created again, %s", failedID, failure)}
+
+	return append(retried, createHost(ctx, d, meta, onCreateFailureKeep)...)
 }
 
 //nolint:funlen // Ignoring function length check on existing function
-func resourceMetalHostRead(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to query host")
+func resourceMetalHostRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to query host")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
-	ctx := p.GetContext()
-	host, _, err := p.Client.HostsApi.GetByID(ctx, d.Id(), nil)
+	ctx, err = p.ContextWithToken(ctx)
 	if err != nil {
-		return err
+		return diagFromErr(err)
+	}
+	host, resp, err := p.Client.HostsApi.GetByID(ctx, d.Id(), nil)
+	if isNotFound(resp) || (err == nil && (host.Deleted || host.State == rest.HOSTSTATE_DELETED)) {
+		return resourceGone(d, "host")
+	}
+
+	if err != nil {
+		return diagFromErr(err)
 	}
 
 	d.Set(hName, host.Name)
 	d.Set(hState, host.State)
 	d.Set(hSubState, host.Substate)
 	d.Set(hPortalCommOkay, host.PortalCommOkay)
-	d.Set(hPwrState, host.PowerStatus)
+	d.Set(hPwrState, powerStateValue(safeString(d.Get(hPwrState)), host.PowerStatus))
 	d.Set(hImage, fmt.Sprintf("%s@%s", host.ServiceFlavor, host.ServiceVersion)) //nolint:errcheck
-	d.Set(hSSHKeyIDs, host.SSHAuthorizedKeys)
+	// The portal keeps the SSH keys that a host was created with, so those
+	// pushed to it since are left as they are.
+	_, pushed := sshKeyPushSettings(d.Get)
+	pushed = pushed && len(d.Get(hSSHKeyIDs).([]interface{})) > 0
+
+	if !pushed {
+		d.Set(hSSHKeyIDs, host.SSHKeyIDs)
+	}
 	d.Set(hSizeID, host.MachineSizeID)
 	d.Set(hSize, host.MachineSizeName)
-	d.Set(hUserData, host.UserData)
-	loc, _ := p.GetLocationName(host.LocationID)
+	// The user data of a host created from cloud_init is left as it is, empty.
+	if cloudInit, _ := d.Get(hCloudInit).([]interface{}); len(cloudInit) == 0 {
+		userData := host.UserData
+		if safeString(d.Get(hUserDataEncoding)) == userDataGzipBase64 {
+			userData, _ = decodeUserData(userData)
+		}
+
+		d.Set(hUserData, userData)
+	}
+	loc, err := p.GetLocationName(host.LocationID)
+	if err != nil {
+		diags = append(diags, warning("location of host %s not resolved: %v", host.Name, err))
+	}
 	d.Set(hLocation, loc)
 	d.Set(hLocationID, host.LocationID)
 	d.Set(hNetworkIDs, host.NetworkIDs)
 
+	// networks and ssh are written in the form that they are in the state, so
+	// that changes made in the portal show as diffs of what the user wrote.
+	if available, err := p.GetAvailableResources(configuration.KindNetworks, configuration.KindSSHKeys); err != nil {
+		diags = append(diags, warning("networks and SSH keys of host %s not resolved: %v", host.Name, err))
+	} else {
+		netNames := make(map[string]string, len(available.Networks))
+		for _, net := range available.Networks {
+			netNames[net.ID] = net.Name
+		}
+
+		keyNames := make(map[string]string, len(available.SSHKeys))
+		for _, key := range available.SSHKeys {
+			keyNames[key.ID] = key.Name
+		}
+
+		d.Set(hNetworks, namesOrIDs(convertStringArr(d.Get(hNetworks).([]interface{})), host.NetworkIDs, netNames))
+		// network_route and network_untagged given by ID are written as the
+		// names of the networks, so that a switch to either shows no diff.
+		for key, id := range map[string]string{
+			hNetForDefaultRoute: host.NetworkForDefaultRoute,
+			hNetUntagged:        host.NetworkUntagged,
+		} {
+			if name := netNames[id]; name != "" && safeString(d.Get(key)) == id {
+				d.Set(key, name)
+			}
+		}
+		if !pushed {
+			d.Set(hSSHKeys, namesOrIDs(convertStringArr(d.Get(hSSHKeys).([]interface{})), host.SSHKeyIDs, keyNames))
+		}
+	}
+
 	if err = d.Set(hSummaryStatus, host.SummaryStatus); err != nil {
-		return fmt.Errorf("set summary status: %v", err)
+		return append(diags, diag.Errorf("set summary status: %v", err)...)
 	}
 
 	varesources, _, err := p.Client.VolumeAttachmentsApi.List(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("error reading volume attachment information %v", err)
+		return append(diags, diag.Errorf("error reading volume attachment information %v", err)...)
 	}
 
 	hostvas := getVAsForHost(host.ID, varesources)
@@ -591,13 +772,32 @@ func resourceMetalHostRead(d *schema.ResourceData, meta interface{}) (err error)
 	}
 
 	if err := d.Set(hVolumeInfos, volumeInfos); err != nil {
-		return err
+		return append(diags, diagFromErr(err)...)
+	}
+
+	// volume_attachments keeps only the attached volumes that it names, while
+	// volume_infos has every volume attached to the host.
+	attachments := convertStringArr(d.Get(hVolumeAttachments).([]interface{}))
+	volIDs := make([]string, 0, len(hostvas))
+	volNames := make(map[string]string, len(hostvas))
+
+	for _, vi := range hostvas {
+		for _, va := range attachments {
+			if va == vi.ID || va == vi.Name {
+				volIDs = append(volIDs, vi.ID)
+				volNames[vi.ID] = vi.Name
+
+				break
+			}
+		}
 	}
 
+	d.Set(hVolumeAttachments, namesOrIDs(attachments, volIDs, volNames))
+
 	d.Set(hDescription, host.Description)
 
 	if err = setConnectionsValues(d, host.Connections); err != nil {
-		return err
+		return append(diags, diagFromErr(err)...)
 	}
 
 	d.Set(hCHAPUser, host.ISCSIConfig.CHAPUser)
@@ -605,15 +805,15 @@ func resourceMetalHostRead(d *schema.ResourceData, meta interface{}) (err error)
 	d.Set(hInitiatorName, host.ISCSIConfig.InitiatorName)
 
 	if err := d.Set(hWWPNS, host.WWPNs); err != nil {
-		return fmt.Errorf("set WWPNs: %v", err)
+		return append(diags, diag.Errorf("set WWPNs: %v", err)...)
 	}
 
 	if err = d.Set(hNetForDefaultRouteID, host.NetworkForDefaultRoute); err != nil {
-		return err
+		return append(diags, diagFromErr(err)...)
 	}
 
 	if err = d.Set(hNetUntaggedID, host.NetworkUntagged); err != nil {
-		return fmt.Errorf("set untagged network: %v", err)
+		return append(diags, diag.Errorf("set untagged network: %v", err)...)
 	}
 
 	tags := make(map[string]string, len(host.Labels))
@@ -623,10 +823,10 @@ func resourceMetalHostRead(d *schema.ResourceData, meta interface{}) (err error)
 	}
 
 	if err := d.Set(hLabels, tags); err != nil {
-		return fmt.Errorf("set labels: %v", err)
+		return append(diags, diag.Errorf("set labels: %v", err)...)
 	}
 
-	return nil
+	return diags
 }
 
 // setConnectionsValues sets hConnections, hConnectionsSubnet, hConnectionsGateway
@@ -665,11 +865,60 @@ func setConnectionsValues(d *schema.ResourceData, hostConnections []rest.HostCon
 	return nil
 }
 
+// namesOrIDs returns the names or IDs of the resources with the given IDs in
+// the form of current, the list in the state, so that a resource referred to
+// by name stays a name and one referred to by ID stays an ID. Resources keep
+// their place in current, those that aren't in it are added by name, or by ID
+// if their name isn't known.
+func namesOrIDs(current, ids []string, names map[string]string) []string {
+	nameIDs := make(map[string]string, len(ids))
+	isID := make(map[string]bool, len(ids))
+
+	for _, id := range ids {
+		isID[id] = true
+
+		if name := names[id]; name != "" {
+			nameIDs[name] = id
+		}
+	}
+
+	listed := make(map[string]bool, len(ids))
+	ret := make([]string, 0, len(ids))
+
+	for _, c := range current {
+		id := c
+		if !isID[c] {
+			id = nameIDs[c]
+		}
+
+		if id == "" || listed[id] {
+			continue
+		}
+
+		listed[id] = true
+		ret = append(ret, c)
+	}
+
+	for _, id := range ids {
+		if listed[id] {
+			continue
+		}
+
+		if name := names[id]; name != "" {
+			ret = append(ret, name)
+		} else {
+			ret = append(ret, id)
+		}
+	}
+
+	return ret
+}
+
 func getVAsForHost(hostID string, vas []rest.VolumeAttachment) []rest.VolumeInfo {
 	hostvas := make([]rest.VolumeInfo, 0, len(vas))
 
 	for _, i := range vas {
-		if i.HostID == hostID {
+		if i.HostID == hostID && i.State != rest.VASTATEENUM_DELETED {
 			vi := rest.VolumeInfo{}
 			vi.ID = i.VolumeID
 			vi.Name = i.Name
@@ -683,29 +932,67 @@ func getVAsForHost(hostID string, vas []rest.VolumeAttachment) []rest.VolumeInfo
 }
 
 //nolint:funlen // Ignoring function length check on existing function
-func resourceMetalHostUpdate(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to update host")
+func resourceMetalHostUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to update host")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
+	}
+
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
 	}
 
-	ctx := p.GetContext()
+	isAsync, err := isHostActionAsync(d)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	powerTimeout := d.Timeout(schema.TimeoutUpdate)
+	if isAsync {
+		powerTimeout = 0
+	}
+
+	if d.HasChange(hSSHKeys) {
+		host, _, err := p.Client.HostsApi.GetByID(ctx, d.Id(), nil)
+		if err != nil {
+			return diagFromErr(err)
+		}
+
+		if diags = updateHostSSHKeys(ctx, d, p, host); diags.HasError() {
+			// Keep the keys that the host had in the state, so that they are
+			// pushed again on the next apply.
+			d.Partial(true)
+
+			return diags
+		}
+	}
+
+	// Only the power state, the SSH keys or how the provider handles the host
+	// has changed, so there is nothing else to update.
+	if !d.HasChangesExcept(hPwrState, hHostActionAsync, hOnCreateFailure, hSizeFallbacks, hSSHKeys, hSSHKeyPush) {
+		if err = changePowerState(ctx, d, p.Client.HostsApi, powerTimeout); err != nil {
+			return diagFromErr(err)
+		}
+
+		return append(diags, resourceMetalHostRead(ctx, d, meta)...)
+	}
 
 	host, _, err := p.Client.HostsApi.GetByID(ctx, d.Id(), nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	volumes, _, err := p.Client.VolumesApi.List(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("error reading volume information %v", err)
+		return diag.Errorf("error reading volume information %v", err)
 	}
 
 	varesources, _, err := p.Client.VolumeAttachmentsApi.List(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("error reading volume attachment information %v", err)
+		return diag.Errorf("error reading volume attachment information %v", err)
 	}
 
 	hostvas := getVAsForHost(host.ID, varesources)
@@ -715,12 +1002,24 @@ func resourceMetalHostUpdate(d *schema.ResourceData, meta interface{}) (err erro
 	for _, vID := range convertStringArr(d.Get(hVolumeAttachments).([]interface{})) {
 		volID, exists := volumeExists(vID, volumes)
 		if !exists {
-			return fmt.Errorf("volume attachment failed due to volume %q does not exist", vID)
+			return diag.Errorf("volume attachment failed due to volume %q does not exist", vID)
 		}
 
 		desired = append(desired, volID)
 	}
 
+	// previously managed volume IDs. Only volumes that were listed in the host's
+	// volume_attachments are detached here, so that attachments owned by a
+	// hpegl_metal_volume_attachment resource are left alone.
+	oldVAs, _ := d.GetChange(hVolumeAttachments)
+
+	previous := make([]string, 0, len(hostvas))
+	for _, vID := range convertStringArr(oldVAs.([]interface{})) {
+		if volID, exists := volumeExists(vID, volumes); exists {
+			previous = append(previous, volID)
+		}
+	}
+
 	// existing volume IDs
 	existing := make([]string, 0, len(hostvas))
 	for _, i := range hostvas {
@@ -729,7 +1028,7 @@ func resourceMetalHostUpdate(d *schema.ResourceData, meta interface{}) (err erro
 
 	// volume IDs to attach & detach
 	attachList := difference(desired, existing)
-	detachList := difference(existing, desired)
+	detachList := intersection(difference(previous, desired), existing)
 
 	// detach
 	vaHostID := rest.VolumeAttachHostUuid{HostID: host.ID}
@@ -737,7 +1036,7 @@ func resourceMetalHostUpdate(d *schema.ResourceData, meta interface{}) (err erro
 		_, err = p.Client.VolumesApi.Detach(ctx, dv, vaHostID, nil)
 
 		if err != nil {
-			return err
+			return diagFromErr(err)
 		}
 	}
 
@@ -746,14 +1045,14 @@ func resourceMetalHostUpdate(d *schema.ResourceData, meta interface{}) (err erro
 		_, _, err = p.Client.VolumesApi.Attach(ctx, av, vaHostID, nil)
 
 		if err != nil {
-			return err
+			return diagFromErr(err)
 		}
 	}
 
 	updateHost := rest.UpdateHost{
 		ID:   host.ID,
 		ETag: host.ETag,
-		Name: host.Name,
+		Name: safeString(d.Get(hName)),
 	}
 
 	// description
@@ -771,13 +1070,13 @@ func resourceMetalHostUpdate(d *schema.ResourceData, meta interface{}) (err erro
 
 	// set the network ids
 	if updateHost.NetworkIDs, err = getNetworkIDs(d, p, &host); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	// set the network for default route
 	if nDefRoute := safeString(d.Get(hNetForDefaultRoute)); nDefRoute != "" {
 		if updateHost.NetworkForDefaultRoute, err = getNetworkID(p, host.NetworkIDs, host.LocationID, nDefRoute); err != nil {
-			return err
+			return diagFromErr(err)
 		}
 	}
 
@@ -785,7 +1084,7 @@ func resourceMetalHostUpdate(d *schema.ResourceData, meta interface{}) (err erro
 	if nUntagged := safeString(d.Get(hNetUntagged)); nUntagged == "" {
 		updateHost.NetworkUntagged = ""
 	} else if updateHost.NetworkUntagged, err = getNetworkID(p, host.NetworkIDs, host.LocationID, nUntagged); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	// add tags
@@ -794,21 +1093,21 @@ func resourceMetalHostUpdate(d *schema.ResourceData, meta interface{}) (err erro
 	}
 
 	// Update.
-	ctx = p.GetContext()
-
-	_, _, err = p.Client.HostsApi.Update(ctx, updateHost.ID, updateHost, nil)
-	if err != nil {
-		//nolint:wrapcheck // defer func is wrapping the error.
-		return err
+	if ctx, err = p.ContextWithToken(ctx); err != nil {
+		return diagFromErr(err)
 	}
 
-	isAsync, err := updateResourceData(d, meta)
+	_, _, err = p.Client.HostsApi.Update(ctx, updateHost.ID, updateHost, nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	if isAsync {
-		return nil
+		if err = changePowerState(ctx, d, p.Client.HostsApi, powerTimeout); err != nil {
+			return diagFromErr(err)
+		}
+
+		return append(diags, resourceMetalHostRead(ctx, d, meta)...)
 	}
 
 	// host update is asynchronous in Metal svc. Wait until host state is Ready.
@@ -830,41 +1129,46 @@ func resourceMetalHostUpdate(d *schema.ResourceData, meta interface{}) (err erro
 			return h, string(h.State), nil
 		},
 		Timeout:    d.Timeout(schema.TimeoutUpdate),
-		Delay:      mediumTimeout,
-		MinTimeout: shortTimeout,
+		Delay:      hostWaitDelay,
+		MinTimeout: hostPollInterval,
 	}
 
 	if _, err := updateStateConf.WaitForStateContext(ctx); err != nil {
-		return fmt.Errorf("waiting for host instance (%s) to be updated: %s", d.Id(), err)
+		return diag.Errorf("waiting for host instance (%s) to be updated: %s", d.Id(), err)
+	}
+
+	if err = changePowerState(ctx, d, p.Client.HostsApi, powerTimeout); err != nil {
+		return diagFromErr(err)
 	}
 
-	return resourceMetalHostRead(d, meta)
+	return append(diags, resourceMetalHostRead(ctx, d, meta)...)
 }
 
 //nolint:funlen // Ignoring function length check on existing function
-func resourceMetalHostDelete(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to delete host")
+func resourceMetalHostDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to delete host")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	defer func() {
 		// This is the last in the deferred chain to fire. If there has been no
-		// preceding error we will refresh the available resources and return
-		// any possible error that may have caused.
-		if err == nil {
-			// Update resource pool and propagate any error
-			err = p.RefreshAvailableResources()
+		// preceding error the machine inventory and volumes have changed.
+		if !diags.HasError() {
+			p.InvalidateAvailableResources(configuration.KindMachines, configuration.KindVolumes)
 		}
 	}()
 
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 
 	host, _, err := p.Client.HostsApi.GetByID(ctx, d.Id(), nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	if host.State == rest.HOSTSTATE_DELETED {
@@ -874,14 +1178,13 @@ func resourceMetalHostDelete(d *schema.ResourceData, meta interface{}) (err erro
 	// Hosts that are in the Ready state and powered-on can not be deleted while the
 	// power is on, so turn off the power.
 	if host.State == rest.HOSTSTATE_READY && host.PowerStatus == rest.HOSTPOWERSTATE_ON {
-		if err := powerOffHost(ctx, p.Client.HostsApi, d.Id(), d.Timeout(schema.TimeoutDefault)); err != nil {
-			return err
+		if err := powerHost(ctx, p.Client.HostsApi, d.Id(), powerOff, d.Timeout(schema.TimeoutDefault)); err != nil {
+			return diagFromErr(err)
 		}
 	}
 
 	if _, err := p.Client.HostsApi.Delete(ctx, d.Id(), nil); err != nil {
-		//nolint:wrapcheck // defer func is wrapping the error.
-		return err
+		return diagFromErr(err)
 	}
 
 	// host deletes are asynchronous in Metal svc and we can not delete terraform's
@@ -898,7 +1201,12 @@ func resourceMetalHostDelete(d *schema.ResourceData, meta interface{}) (err erro
 			string(rest.HOSTSTATE_DELETED),
 		},
 		Refresh: func() (interface{}, string, error) {
-			host, _, err := p.Client.HostsApi.GetByID(ctx, d.Id(), nil)
+			host, resp, err := p.Client.HostsApi.GetByID(ctx, d.Id(), nil)
+			if isNotFound(resp) {
+				// The host has been purged.
+				return host, string(rest.HOSTSTATE_DELETED), nil
+			}
+
 			if err != nil {
 				return nil, "", fmt.Errorf("get host %v", d.Id())
 			}
@@ -906,31 +1214,84 @@ func resourceMetalHostDelete(d *schema.ResourceData, meta interface{}) (err erro
 			return host, string(host.State), nil
 		},
 		Timeout:    d.Timeout(schema.TimeoutDelete),
-		Delay:      mediumTimeout,
-		MinTimeout: shortTimeout,
+		Delay:      hostWaitDelay,
+		MinTimeout: hostPollInterval,
 	}
 
 	if _, err := deleteStateConf.WaitForStateContext(ctx); err != nil {
-		return fmt.Errorf("waiting for host instance (%s) to be deleted: %s", d.Id(), err)
+		return diag.Errorf("waiting for host instance (%s) to be deleted: %s", d.Id(), err)
 	}
 
 	return nil
 }
 
-func powerOffHost(ctx context.Context, hostAPI rest.HostsAPI, hostID string, timeout time.Duration) error {
-	_, _, err := hostAPI.PowerOff(ctx, hostID, nil)
+// powerStateValue returns the power_state of a host with the given power status.
+// A host that was reset and is on keeps reset, so that another reset isn't planned.
+func powerStateValue(powerState string, status rest.HostPowerState) string {
+	if strings.EqualFold(powerState, powerReset) && status == rest.HOSTPOWERSTATE_ON {
+		return powerState
+	}
+
+	return string(status)
+}
+
+// changePowerState powers the host on or off, or resets it, if power_state has
+// changed and the host isn't already in that power state.
+func changePowerState(ctx context.Context, d *schema.ResourceData, hostAPI rest.HostsAPI, timeout time.Duration) error {
+	action := strings.ToLower(safeString(d.Get(hPwrState)))
+	if action == "" || !d.HasChange(hPwrState) {
+		return nil
+	}
+
+	host, _, err := hostAPI.GetByID(ctx, d.Id(), nil)
+	if err != nil {
+		return fmt.Errorf("get host %v: %v", d.Id(), err)
+	}
+
+	switch {
+	case action == powerOn && host.PowerStatus == rest.HOSTPOWERSTATE_ON:
+		return nil
+	case action == powerOff && host.PowerStatus == rest.HOSTPOWERSTATE_OFF:
+		return nil
+	}
+
+	return powerHost(ctx, hostAPI, d.Id(), action, timeout)
+}
+
+// powerHost powers the host on or off, or resets it. Unless timeout is zero it
+// waits for Metal svc to report the resulting power state.
+func powerHost(ctx context.Context, hostAPI rest.HostsAPI, hostID, action string, timeout time.Duration) error {
+	var err error
+
+	target := rest.HOSTPOWERSTATE_ON
+	pending := []string{string(rest.HOSTPOWERSTATE_UNKNOWN), string(rest.HOSTPOWERSTATE_OFF)}
+
+	switch action {
+	case powerOn:
+		_, _, err = hostAPI.PowerOn(ctx, hostID, nil)
+	case powerOff:
+		_, _, err = hostAPI.PowerOff(ctx, hostID, nil)
+		target = rest.HOSTPOWERSTATE_OFF
+		pending = []string{string(rest.HOSTPOWERSTATE_UNKNOWN), string(rest.HOSTPOWERSTATE_ON)}
+	case powerReset:
+		_, _, err = hostAPI.PowerReset(ctx, hostID, nil)
+	default:
+		return fmt.Errorf("unsupported %s %q", hPwrState, action)
+	}
+
 	if err != nil {
-		return fmt.Errorf("power off host %v: %v", hostID, err)
+		return fmt.Errorf("power %s host %v: %v", action, hostID, err)
 	}
 
-	// The power-off call is asynchronous so wait for Metal svc to complete the request.
-	powerOffStateConf := &retry.StateChangeConf{
-		Pending: []string{
-			string(rest.HOSTPOWERSTATE_UNKNOWN),
-			string(rest.HOSTPOWERSTATE_ON),
-		},
+	if timeout == 0 {
+		return nil
+	}
+
+	// The power calls are asynchronous so wait for Metal svc to complete the request.
+	powerStateConf := &retry.StateChangeConf{
+		Pending: pending,
 		Target: []string{
-			string(rest.HOSTPOWERSTATE_OFF),
+			string(target),
 		},
 		Refresh: func() (interface{}, string, error) {
 			host, _, err := hostAPI.GetByID(ctx, hostID, nil)
@@ -941,17 +1302,161 @@ func powerOffHost(ctx context.Context, hostAPI rest.HostsAPI, hostID string, tim
 			return host, string(host.PowerStatus), nil
 		},
 		Timeout:    timeout,
-		Delay:      mediumTimeout,
-		MinTimeout: shortTimeout,
+		Delay:      hostWaitDelay,
+		MinTimeout: hostPollInterval,
 	}
 
-	if _, err := powerOffStateConf.WaitForStateContext(ctx); err != nil {
-		return fmt.Errorf("waiting for host instance (%v) to be powered off: %v", hostID, err)
+	if _, err := powerStateConf.WaitForStateContext(ctx); err != nil {
+		return fmt.Errorf("waiting for power %s of host instance (%v): %v", action, hostID, err)
 	}
 
 	return nil
 }
 
+// imageID returns the ID of the image in flavor@version form.
+func imageID(images []rest.AvailableImage, image string) (string, error) {
+	fv := strings.Split(image, "@")
+	if len(fv) != allowedImageLength {
+		return "", fmt.Errorf("image attribute %q must be in flavor@version format", image)
+	}
+
+	flavorFound := false
+	flavors := []string{}
+
+	for _, img := range images {
+		if img.Flavor == fv[0] {
+			flavorFound = true
+
+			if img.Version == fv[1] {
+				return img.ID, nil
+			}
+		}
+
+		flavors = append(flavors, fmt.Sprintf("%s@%s", img.Flavor, img.Version))
+	}
+
+	if !flavorFound {
+		return "", fmt.Errorf("image flavor %q not found in %q", fv[0], flavors)
+	}
+
+	return "", fmt.Errorf("image version %q of flavor %q not found in %q", fv[1], fv[0], flavors)
+}
+
+// machineSizeID returns the ID of the machine size with the given name or ID.
+func machineSizeID(sizes []rest.MachineSize, size string) (string, error) {
+	names := []string{}
+
+	for _, mSize := range sizes {
+		if mSize.Name == size || mSize.ID == size {
+			return mSize.ID, nil
+		}
+
+		names = append(names, mSize.Name)
+	}
+
+	return "", fmt.Errorf("machine size %q not found in %q", size, names)
+}
+
+// hostPlacement is a machine size and location that a host may be created with.
+type hostPlacement struct {
+	size     string
+	location string
+}
+
+func (pl hostPlacement) String() string {
+	return fmt.Sprintf("%q in %q", pl.size, pl.location)
+}
+
+// hostPlacements returns the machine size and location of a host followed by
+// its fallbacks, in order of preference. get is the Get of the host's
+// ResourceData or ResourceDiff.
+func hostPlacements(get func(string) interface{}) []hostPlacement {
+	location := safeString(get(hLocation))
+	placements := []hostPlacement{{size: safeString(get(hSize)), location: location}}
+
+	fallbacks, _ := get(hSizeFallbacks).([]interface{})
+	for _, fallback := range fallbacks {
+		m, _ := fallback.(map[string]interface{})
+
+		pl := hostPlacement{size: safeString(m[hSize]), location: safeString(m[hLocation])}
+		if pl.location == "" {
+			pl.location = location
+		}
+
+		placements = append(placements, pl)
+	}
+
+	return placements
+}
+
+// choosePlacement returns the IDs of the machine size and location of the
+// first placement that has machines in inventory. A host without fallbacks is
+// created with its machine size and location whatever the inventory, and the
+// portal says why when there are no machines. Placements whose machine size
+// or location isn't found are skipped, and why is returned in skipped.
+func choosePlacement(p *configuration.Config, resources rest.AvailableResources, placements []hostPlacement,
+) (sizeID, locationID string, skipped []string, err error) {
+	if len(placements) == 1 {
+		if sizeID, err = machineSizeID(resources.MachineSizes, placements[0].size); err != nil {
+			return "", "", nil, err
+		}
+
+		if locationID, err = p.GetLocationID(placements[0].location); err != nil {
+			return "", "", nil, err
+		}
+
+		return sizeID, locationID, nil, nil
+	}
+
+	tried := make([]string, 0, len(placements))
+
+	for _, pl := range placements {
+		if sizeID, err = machineSizeID(resources.MachineSizes, pl.size); err == nil {
+			locationID, err = p.GetLocationID(pl.location)
+		}
+
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("machine size %s skipped: %v", pl, err))
+
+			continue
+		}
+
+		for _, inv := range resources.MachineInventory {
+			if inv.SizeID == sizeID && inv.LocationID == locationID && inv.Number > 0 {
+				return sizeID, locationID, skipped, nil
+			}
+		}
+
+		tried = append(tried, pl.String())
+	}
+
+	err = fmt.Errorf("no machines available of machine size %s", strings.Join(tried, ", "))
+	if len(tried) == 0 {
+		err = errors.New("no machine size found")
+	}
+
+	if len(skipped) > 0 {
+		err = fmt.Errorf("%w; %s", err, strings.Join(skipped, "; "))
+	}
+
+	return "", "", skipped, err
+}
+
+// sshKeyID returns the ID of the SSH key with the given name or ID.
+func sshKeyID(keys []rest.SshKeyEntry, key string) (string, error) {
+	names := []string{}
+
+	for _, sshKey := range keys {
+		if sshKey.Name == key || sshKey.ID == key {
+			return sshKey.ID, nil
+		}
+
+		names = append(names, sshKey.Name)
+	}
+
+	return "", fmt.Errorf("SSH key %q not found in %q", key, names)
+}
+
 // volumeExists returns true & the volume ID, if the input matches
 // either the ID or the name from existing volumes.
 func volumeExists(vID string, volumes []rest.Volume) (string, bool) {
@@ -980,7 +1485,11 @@ func isVolumeAvailable(vID string, availVols []rest.VolumeInfo) (string, bool) {
 func getNetworkIDs(d *schema.ResourceData, p *configuration.Config, host *rest.Host) (netIds []string, err error) {
 	netIds = []string{}
 
-	nIDMap, nNameMap := getAvailableNetworkMaps(p, host.LocationID)
+	nIDMap, nNameMap, err := getAvailableNetworkMaps(p, host.LocationID)
+	if err != nil {
+		return netIds, err
+	}
+
 	if len(nIDMap) == 0 {
 		return netIds, fmt.Errorf("no available networks for location %s", host.LocationID)
 	}
@@ -1019,7 +1528,11 @@ func getNetworkID(p *configuration.Config, hostNets []string, locationID, net st
 		return "", fmt.Errorf("no network provided")
 	}
 
-	nIDMap, nNameMap := getAvailableNetworkMaps(p, locationID)
+	nIDMap, nNameMap, err := getAvailableNetworkMaps(p, locationID)
+	if err != nil {
+		return "", err
+	}
+
 	if len(nIDMap) == 0 {
 		return "", fmt.Errorf("no available networks for location %s", locationID)
 	}
@@ -1059,16 +1572,22 @@ func getNetworkID(p *configuration.Config, hostNets []string, locationID, net st
 }
 
 // getAvailableNetworkMaps returns available network name and ID maps based on location.
-func getAvailableNetworkMaps(p *configuration.Config, loc string) (nIDMap map[string]string, nNameMap map[string]string) {
+func getAvailableNetworkMaps(p *configuration.Config, loc string,
+) (nIDMap map[string]string, nNameMap map[string]string, err error) {
 	nIDMap = make(map[string]string)
 	nNameMap = make(map[string]string)
 
-	for _, net := range p.AvailableResources.Networks {
+	available, err := p.GetAvailableResources(configuration.KindNetworks)
+	if err != nil {
+		return nIDMap, nNameMap, err
+	}
+
+	for _, net := range available.Networks {
 		if net.LocationID == loc {
 			nNameMap[net.Name] = net.ID
 			nIDMap[net.ID] = net.Name
 		}
 	}
 
-	return nIDMap, nNameMap
+	return nIDMap, nNameMap, nil
 }
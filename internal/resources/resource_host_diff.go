@@ -0,0 +1,295 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/client"
+)
+
+// resourceMetalHostCustomizeDiff checks the planned host against the resources
+// available in the project, so that a wrong image, machine size, SSH key,
+// location or network fails the plan rather than the apply. Only the attributes
+// of a new host, or those that change, are checked. Values that are not known
+// until apply are left to Create, as are network names that aren't found since
+// the network may be created in the same apply.
+func resourceMetalHostCustomizeDiff(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	p, err := client.GetClientFromMetaMap(meta)
+	if err != nil {
+		return err
+	}
+
+	resources, err := p.GetAvailableResources()
+	if err != nil {
+		return err
+	}
+
+	planned := func(key string) bool {
+		return (d.Id() == "" || d.HasChange(key)) && d.NewValueKnown(key)
+	}
+
+	var errs []error
+
+	if planned(hImage) {
+		if _, err = imageID(resources.Images, safeString(d.Get(hImage))); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hImage, err))
+		}
+	}
+
+	if planned(hSize) {
+		if _, err = machineSizeID(resources.MachineSizes, safeString(d.Get(hSize))); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hSize, err))
+		}
+	}
+
+	if planned(hSizeFallbacks) {
+		for i, pl := range hostPlacements(d.Get)[1:] {
+			key := fmt.Sprintf("%s.%d.", hSizeFallbacks, i)
+
+			if d.NewValueKnown(key + hSize) {
+				if _, err = machineSizeID(resources.MachineSizes, pl.size); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", hSizeFallbacks, err))
+				}
+			}
+
+			if d.NewValueKnown(key+hLocation) && d.NewValueKnown(hLocation) {
+				if _, err = p.GetLocationID(pl.location); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", hSizeFallbacks, err))
+				}
+			}
+		}
+	}
+
+	if planned(hCloudInit) {
+		for i, part := range cloudInitParts(d.Get) {
+			key := fmt.Sprintf("%s.0.%s.%d.", hCloudInit, ciPart, i)
+
+			if !d.NewValueKnown(key+ciContentType) || !d.NewValueKnown(key+ciContent) {
+				continue
+			}
+
+			if err = checkCloudInitPart(part.contentType, part.content); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s %d: %w", hCloudInit, ciPart, i, err))
+			}
+		}
+	}
+
+	if planned(hSSHKeys) {
+		for i, key := range convertStringArr(d.Get(hSSHKeys).([]interface{})) {
+			if !d.NewValueKnown(fmt.Sprintf("%s.%d", hSSHKeys, i)) {
+				continue
+			}
+
+			if _, err = sshKeyID(resources.SSHKeys, key); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", hSSHKeys, err))
+			}
+		}
+	}
+
+	// The portal only takes the SSH keys of a host when it is created, so they
+	// change in place only when they can be pushed to the host.
+	if d.Id() != "" && d.HasChange(hSSHKeys) && sshKeysChanged(d, resources.SSHKeys) {
+		if _, ok := sshKeyPushSettings(d.Get); !ok {
+			if err = d.ForceNew(hSSHKeys); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	// A host key that isn't known yet is left to Update.
+	push, ok := sshKeyPushSettings(d.Get)
+	if ok && push.hostKey == "" && !push.insecure && d.NewValueKnown(hSSHKeyPush+".0."+skpHostKey) {
+		errs = append(errs, fmt.Errorf("%s: %s is needed, or %s to accept any host key", hSSHKeyPush, skpHostKey,
+			skpInsecure))
+	}
+
+	if d.NewValueKnown(hLocation) {
+		locationID, err := p.GetLocationID(safeString(d.Get(hLocation)))
+		if err != nil && planned(hLocation) {
+			errs = append(errs, fmt.Errorf("%s: %w", hLocation, err))
+		}
+
+		if err == nil {
+			errs = append(errs, checkHostNetworks(d, resources.Networks, locationID, planned)...)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// sshKeysChanged returns whether the planned SSH keys of a host are other keys
+// than those it has, rather than the same keys given by name in place of ID or
+// the other way round. Keys that aren't known are taken to have changed.
+func sshKeysChanged(d *schema.ResourceDiff, keys []rest.SshKeyEntry) bool {
+	if !d.NewValueKnown(hSSHKeys) {
+		return true
+	}
+
+	old, planned := d.GetChange(hSSHKeys)
+	oldIDs, _, oldMissing := hostSSHKeys(keys, convertStringArr(old.([]interface{})))
+	newIDs, _, newMissing := hostSSHKeys(keys, convertStringArr(planned.([]interface{})))
+
+	return len(oldMissing) > 0 || len(newMissing) > 0 || !sameSSHKeys(oldIDs, newIDs)
+}
+
+// checkHostNetworks checks that the networks of the planned host aren't
+// ambiguous in its location, that the networks for the default route and
+// untagged traffic are among them and that there is an allocated IP for
+// each of them.
+func checkHostNetworks(d *schema.ResourceDiff, available []rest.AvailableNetwork, locationID string,
+	planned func(key string) bool,
+) []error {
+	if !d.NewValueKnown(hNetworks) {
+		return nil
+	}
+
+	nameCount := make(map[string]int)
+	nameIDs := make(map[string]string)
+	ids := make(map[string]bool)
+
+	for _, net := range available {
+		if net.LocationID == locationID {
+			nameCount[net.Name]++
+			nameIDs[net.Name] = net.ID
+			ids[net.ID] = true
+		}
+	}
+
+	var errs []error
+
+	// networkIDs holds the ID of each of the host's networks, or the name of
+	// those that aren't known to the portal yet.
+	networks := convertStringArr(d.Get(hNetworks).([]interface{}))
+	networkIDs := make(map[string]bool)
+	allKnown := true
+
+	for i, net := range networks {
+		if !d.NewValueKnown(fmt.Sprintf("%s.%d", hNetworks, i)) {
+			allKnown = false
+
+			continue
+		}
+
+		switch {
+		case ids[net]:
+			networkIDs[net] = true
+		case nameCount[net] > 1:
+			if planned(hNetworks) || planned(hLocation) {
+				errs = append(errs, fmt.Errorf("%s: network %q is ambiguous in location %q, use its ID", hNetworks, net, locationID))
+			}
+
+			networkIDs[net] = true
+		case nameCount[net] == 1:
+			networkIDs[nameIDs[net]] = true
+		default:
+			networkIDs[net] = true
+		}
+	}
+
+	if d.NewValueKnown(hPreAllocatedIPs) && (planned(hPreAllocatedIPs) || planned(hNetworks)) {
+		ips, _ := d.Get(hPreAllocatedIPs).([]interface{})
+		if len(ips) > 0 && len(ips) != len(networks) {
+			errs = append(errs, fmt.Errorf("%s: %d IP addresses are given for %d networks, there must be one for each network",
+				hPreAllocatedIPs, len(ips), len(networks)))
+		}
+	}
+
+	if !allKnown {
+		return errs
+	}
+
+	for _, key := range []string{hNetForDefaultRoute, hNetUntagged} {
+		net := safeString(d.Get(key))
+		if net == "" || !(planned(key) || planned(hNetworks)) {
+			continue
+		}
+
+		id := net
+		if !ids[net] && nameCount[net] == 1 {
+			id = nameIDs[net]
+		}
+
+		if !networkIDs[id] {
+			errs = append(errs, fmt.Errorf("%s: network %q must be one of the host's networks %q", key, net, networks))
+		}
+	}
+
+	return errs
+}
+
+// suppressVolumeAttachment suppresses the diff of an element of
+// volume_attachments that is set to the ID of the volume named in the state,
+// or to the name of the volume whose ID is in the state.
+func suppressVolumeAttachment(k, old, new string, d *schema.ResourceData) bool {
+	if strings.HasSuffix(k, ".#") || old == "" {
+		return false
+	}
+
+	infos, ok := d.Get(hVolumeInfos).(*schema.Set)
+	if !ok {
+		return false
+	}
+
+	for _, info := range infos.List() {
+		vi, _ := info.(map[string]interface{})
+		id, name := safeString(vi[vID]), safeString(vi[vName])
+
+		if (old == name && new == id) || (old == id && new == name) {
+			return true
+		}
+	}
+
+	return new == old
+}
+
+// suppressDefaultRoute is suppressNameOrID for network_route, which also
+// suppresses the diff of an unset network_route when the default route is on
+// the first of the host's networks, as it is for hosts created without one.
+func suppressDefaultRoute(k, old, new string, d *schema.ResourceData) bool {
+	if new == "" {
+		ids, _ := d.Get(hNetworkIDs).([]interface{})
+
+		return len(ids) > 0 && safeString(ids[0]) == safeString(d.Get(hNetForDefaultRouteID))
+	}
+
+	return suppressNameOrID(hNetForDefaultRouteID)(k, old, new, d)
+}
+
+// suppressPlacement suppresses the diff of the machine_size and location of a
+// host that was created with one of its machine_size_fallbacks, for as long as
+// that fallback is configured.
+func suppressPlacement(_, old, new string, d *schema.ResourceData) bool {
+	if old == "" {
+		return false
+	}
+
+	if old == new {
+		return true
+	}
+
+	oldSize, _ := d.GetChange(hSize)
+	oldLocation, _ := d.GetChange(hLocation)
+	sizeID, locationID := safeString(d.Get(hSizeID)), safeString(d.Get(hLocationID))
+
+	for _, pl := range hostPlacements(d.Get) {
+		if (pl.size == safeString(oldSize) || pl.size == sizeID) &&
+			(pl.location == safeString(oldLocation) || pl.location == locationID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// suppressMachineSize is suppressNameOrID for machine_size, which also
+// suppresses the diff of a host created with one of its fallbacks.
+func suppressMachineSize(k, old, new string, d *schema.ResourceData) bool {
+	return suppressNameOrID(hSizeID)(k, old, new, d) || suppressPlacement(k, old, new, d)
+}
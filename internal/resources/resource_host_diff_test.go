@@ -0,0 +1,335 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/internal/test-utils/fakeportal"
+)
+
+// unknownValue is how Terraform passes values that are not known until apply.
+const unknownValue = "74D93920-ED26-11E3-AC10-0800200C9A66"
+
+func TestHostCustomizeDiff(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+
+	publicID := ""
+
+	for _, net := range availableResources(t, cfg).Networks {
+		if net.Name == fakeportal.PublicNetwork {
+			publicID = net.ID
+		}
+	}
+
+	hostConfig := func(changes map[string]interface{}) map[string]interface{} {
+		raw := hostRawConfig(map[string]interface{}{
+			hNetworks: []interface{}{fakeportal.PublicNetwork, fakeportal.StorageNetwork},
+		})
+
+		for k, v := range changes {
+			raw[k] = v
+		}
+
+		return raw
+	}
+
+	tCases := []struct {
+		name    string
+		changes map[string]interface{}
+		expErrs []string
+	}{
+		{
+			name: "Valid",
+			changes: map[string]interface{}{
+				hNetForDefaultRoute: publicID,
+				hNetUntagged:        fakeportal.StorageNetwork,
+				hPreAllocatedIPs:    []interface{}{"10.0.0.20", "10.0.1.20"},
+			},
+		},
+		{
+			name:    "Network created in the same apply",
+			changes: map[string]interface{}{hNetworks: []interface{}{fakeportal.PublicNetwork, "New"}, hNetUntagged: "New"},
+		},
+		{
+			name: "Unknown values",
+			changes: map[string]interface{}{
+				hImage:              unknownValue,
+				hNetworks:           []interface{}{fakeportal.PublicNetwork, unknownValue},
+				hNetForDefaultRoute: "Other",
+			},
+		},
+		{
+			name:    "Image not in flavor@version form",
+			changes: map[string]interface{}{hImage: fakeportal.ImageFlavor},
+			expErrs: []string{`image: image attribute "ubuntu" must be in flavor@version format`},
+		},
+		{
+			name:    "Image version not found",
+			changes: map[string]interface{}{hImage: fakeportal.ImageFlavor + "@1.0"},
+			expErrs: []string{`image: image version "1.0" of flavor "ubuntu" not found`},
+		},
+		{
+			name: "Unknown machine size, SSH key and location",
+			changes: map[string]interface{}{
+				hSize:     "Huge",
+				hSSHKeys:  []interface{}{fakeportal.SSHKey, "Missing"},
+				hLocation: "USA:Central:Missing",
+			},
+			expErrs: []string{
+				`machine_size: machine size "Huge" not found`,
+				`ssh: SSH key "Missing" not found`,
+				`location: location "USA:Central:Missing" not found`,
+			},
+		},
+		{
+			name: "Unknown fallback machine size and location",
+			changes: map[string]interface{}{
+				hSizeFallbacks: []interface{}{
+					map[string]interface{}{hSize: "Huge"},
+					map[string]interface{}{hSize: fakeportal.MachineSize, hLocation: "USA:Central:Missing"},
+				},
+			},
+			expErrs: []string{
+				`machine_size_fallbacks: machine size "Huge" not found`,
+				`machine_size_fallbacks: location "USA:Central:Missing" not found`,
+			},
+		},
+		{
+			name:    "Default route not one of the networks",
+			changes: map[string]interface{}{hNetworks: []interface{}{fakeportal.StorageNetwork}, hNetForDefaultRoute: publicID},
+			expErrs: []string{`network_route: network "` + publicID + `" must be one of the host's networks`},
+		},
+		{
+			name:    "Untagged network not one of the networks",
+			changes: map[string]interface{}{hNetworks: []interface{}{fakeportal.PublicNetwork}, hNetUntagged: fakeportal.StorageNetwork},
+			expErrs: []string{`network_untagged: network "Storage" must be one of the host's networks`},
+		},
+		{
+			name: "Invalid cloud_init part",
+			changes: map[string]interface{}{
+				hCloudInit: []interface{}{map[string]interface{}{
+					ciPart: []interface{}{
+						map[string]interface{}{ciContentType: ctCloudConfig, ciContent: "#cloud-config\n- nginx\n"},
+						map[string]interface{}{ciContentType: ctShellScript, ciContent: unknownValue},
+					},
+				}},
+			},
+			expErrs: []string{"cloud_init: part 0: invalid cloud-config"},
+		},
+		{
+			name:    "Allocated IPs not one for each network",
+			changes: map[string]interface{}{hPreAllocatedIPs: []interface{}{"10.0.0.20"}},
+			expErrs: []string{"allocated_ips: 1 IP addresses are given for 2 networks"},
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := HostResource().Diff(context.Background(), nil,
+				terraform.NewResourceConfigRaw(hostConfig(tc.changes)), meta)
+
+			if len(tc.expErrs) == 0 {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			if assert.Error(t, err) {
+				for _, expErr := range tc.expErrs {
+					assert.Contains(t, err.Error(), expErr)
+				}
+			}
+		})
+	}
+}
+
+func TestHostDiffSuppressIDs(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+	ar := availableResources(t, cfg)
+
+	netIDs := make(map[string]string)
+	for _, net := range ar.Networks {
+		netIDs[net.Name] = net.ID
+	}
+
+	raw := hostRawConfig(map[string]interface{}{
+		hNetworks: []interface{}{fakeportal.PublicNetwork, fakeportal.StorageNetwork},
+	})
+
+	// The state of a host read back from the portal, which has the names.
+	d := schema.TestResourceDataRaw(t, hostSchema(), raw)
+	d.SetId("host1-id")
+	assert.Nil(t, d.Set(hSizeID, ar.MachineSizes[0].ID))
+	assert.Nil(t, d.Set(hSSHKeyIDs, []string{ar.SSHKeys[0].ID}))
+	assert.Nil(t, d.Set(hNetworkIDs, []string{netIDs[fakeportal.PublicNetwork], netIDs[fakeportal.StorageNetwork]}))
+
+	raw[hSize] = ar.MachineSizes[0].ID
+	raw[hSSHKeys] = []interface{}{ar.SSHKeys[0].ID}
+	raw[hNetworks] = []interface{}{fakeportal.PublicNetwork, netIDs[fakeportal.StorageNetwork]}
+
+	diff, err := HostResource().Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(raw), meta)
+	assert.NoError(t, err)
+
+	if assert.NotNil(t, diff) {
+		assert.False(t, diff.RequiresNew())
+
+		for _, key := range []string{hSize, hSSHKeys + ".0", hNetworks + ".1"} {
+			assert.NotContains(t, diff.Attributes, key)
+		}
+	}
+
+	// A different network is still a change.
+	raw[hNetworks] = []interface{}{fakeportal.PublicNetwork, netIDs[fakeportal.PublicNetwork]}
+
+	diff, err = HostResource().Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(raw), meta)
+	assert.NoError(t, err)
+
+	if assert.NotNil(t, diff) {
+		assert.Contains(t, diff.Attributes, hNetworks+".1")
+	}
+}
+
+func TestHostDiffInPlace(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+
+	_, _, err := cfg.Client.SshkeysApi.Add(testContext(t, cfg), rest.NewSshKey{Name: "New", Key: "ssh-ed25519 AAAA"}, nil)
+	assert.NoError(t, err)
+	cfg.InvalidateAvailableResources()
+
+	raw := hostRawConfig(nil)
+
+	d := schema.TestResourceDataRaw(t, hostSchema(), raw)
+	d.SetId("host1-id")
+
+	tCases := []struct {
+		name           string
+		changes        map[string]interface{}
+		expRequiresNew bool
+		expErr         string
+	}{
+		{name: "Rename", changes: map[string]interface{}{hName: "host2"}},
+		{name: "SSH keys", changes: map[string]interface{}{hSSHKeys: []interface{}{"New"}}, expRequiresNew: true},
+		{
+			name: "SSH keys pushed to the host",
+			changes: map[string]interface{}{
+				hSSHKeys:    []interface{}{"New"},
+				hSSHKeyPush: []interface{}{map[string]interface{}{skpPrivateKey: "key", skpHostKey: "ssh-ed25519 AAAA"}},
+			},
+		},
+		{
+			name: "SSH keys pushed to any host",
+			changes: map[string]interface{}{
+				hSSHKeys:    []interface{}{"New"},
+				hSSHKeyPush: []interface{}{map[string]interface{}{skpPrivateKey: "key", skpInsecure: true}},
+			},
+		},
+		{
+			name: "SSH keys pushed without a host key",
+			changes: map[string]interface{}{
+				hSSHKeys:    []interface{}{"New"},
+				hSSHKeyPush: []interface{}{map[string]interface{}{skpPrivateKey: "key"}},
+			},
+			expErr: "ssh_key_push: host_key is needed, or insecure_ignore_host_key to accept any host key",
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := make(map[string]interface{}, len(raw))
+			for k, v := range raw {
+				config[k] = v
+			}
+
+			for k, v := range tc.changes {
+				config[k] = v
+			}
+
+			diff, err := HostResource().Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(config), meta)
+			if tc.expErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.expErr)
+				}
+
+				return
+			}
+
+			if assert.NoError(t, err) && assert.NotNil(t, diff) {
+				assert.Equal(t, tc.expRequiresNew, diff.RequiresNew())
+			}
+		})
+	}
+
+	// The same keys in another order don't replace the host.
+	raw[hSSHKeys] = []interface{}{fakeportal.SSHKey, "New"}
+	d = schema.TestResourceDataRaw(t, hostSchema(), raw)
+	d.SetId("host1-id")
+
+	raw[hSSHKeys] = []interface{}{"New", fakeportal.SSHKey}
+
+	diff, err := HostResource().Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(raw), meta)
+	if assert.NoError(t, err) && diff != nil {
+		assert.False(t, diff.RequiresNew())
+	}
+}
+
+func TestSuppressVolumeAttachment(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, hostSchema(), map[string]interface{}{})
+	assert.Nil(t, d.Set(hVolumeInfos, []interface{}{map[string]interface{}{vID: "vol1-id", vName: "vol1"}}))
+
+	assert.True(t, suppressVolumeAttachment(hVolumeAttachments+".0", "vol1", "vol1-id", d))
+	assert.True(t, suppressVolumeAttachment(hVolumeAttachments+".0", "vol1-id", "vol1", d))
+	assert.False(t, suppressVolumeAttachment(hVolumeAttachments+".0", "vol1", "vol2-id", d))
+	assert.False(t, suppressVolumeAttachment(hVolumeAttachments+".#", "1", "2", d))
+}
+
+func TestHostDiffSuppressNetworkIDs(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+
+	netIDs := make(map[string]string)
+	for _, net := range availableResources(t, cfg).Networks {
+		netIDs[net.Name] = net.ID
+	}
+
+	raw := hostRawConfig(map[string]interface{}{
+		hNetworks:           []interface{}{fakeportal.PublicNetwork, fakeportal.StorageNetwork},
+		hNetForDefaultRoute: netIDs[fakeportal.PublicNetwork],
+		hNetUntagged:        netIDs[fakeportal.StorageNetwork],
+	})
+
+	// Networks given by ID are read back as their names.
+	d := schema.TestResourceDataRaw(t, hostSchema(), raw)
+	assert.Nil(t, resourceMetalHostCreate(context.Background(), d, meta))
+	assert.Equal(t, fakeportal.PublicNetwork, d.Get(hNetForDefaultRoute))
+	assert.Equal(t, fakeportal.StorageNetwork, d.Get(hNetUntagged))
+
+	// Neither the IDs nor a switch to the names is a diff.
+	for _, names := range []bool{false, true} {
+		if names {
+			raw[hNetForDefaultRoute] = fakeportal.PublicNetwork
+			raw[hNetUntagged] = fakeportal.StorageNetwork
+		}
+
+		diff, err := HostResource().Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(raw), meta)
+		assert.NoError(t, err)
+
+		if diff != nil {
+			assert.NotContains(t, diff.Attributes, hNetForDefaultRoute)
+			assert.NotContains(t, diff.Attributes, hNetUntagged)
+		}
+	}
+}
@@ -0,0 +1,624 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/client"
+)
+
+const (
+	hgNameTemplate = "name_template"
+	hgSize         = "size"
+	hgParallelism  = "parallelism"
+	hgHosts        = "hosts"
+	hgHostIDs      = "host_ids"
+	hgIndex        = "index"
+	hgID           = "id"
+
+	// hgIndexPlaceholder is replaced by the index of a member in name_template.
+	hgIndexPlaceholder = "{index}"
+
+	defaultGroupParallelism = 5
+)
+
+// hostGroupMemberKeys are the host attributes that a group passes to each of
+// its members.
+//
+//nolint:gochecknoglobals // Used as a constant
+var hostGroupMemberKeys = []string{
+	hImage, hSize, hSSHKeys, hNetworks, hNetForDefaultRoute, hNetUntagged,
+	hLocation, hUserData, hDescription, hLabels, hHostActionAsync,
+}
+
+func hostGroupSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		hgNameTemplate: {
+			Type:         schema.TypeString,
+			Required:     true,
+			ForceNew:     true,
+			ValidateFunc: validateNameTemplate,
+			Description: "The name of each host, in which " + hgIndexPlaceholder + " is replaced by the index of the " +
+				"host in the group, starting at 0, eg 'web-{index}'.",
+		},
+		hgSize: {
+			Type:         schema.TypeInt,
+			Required:     true,
+			ValidateFunc: validation.IntAtLeast(0),
+			Description: "The number of hosts in the group. Hosts are added to the group, or the hosts with the " +
+				"highest indices are deleted, when it changes.",
+		},
+		hgParallelism: {
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      defaultGroupParallelism,
+			ValidateFunc: validation.IntAtLeast(1),
+			Description:  "The most hosts that are created or deleted at the same time. The default is 5.",
+		},
+		hImage: {
+			Type:        schema.TypeString,
+			Required:    true,
+			ForceNew:    true,
+			Description: "A specific flavor and version in the form of flavor@version, eg 'ubuntu@18.04'.",
+		},
+		hSize: {
+			Type:        schema.TypeString,
+			Required:    true,
+			ForceNew:    true,
+			Description: "Some generic sizing information for the machines like 'Small', 'Very Large', or its ID.",
+		},
+		hSSHKeys: {
+			Type:     schema.TypeList,
+			Required: true,
+			ForceNew: true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+			Description: "A list of names or IDs of SSH keys that will be pushed to the hosts.",
+		},
+		hNetworks: {
+			Type:     schema.TypeList,
+			Required: true,
+			ForceNew: true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+			Description: "List of network names or IDs e.g. ['Public', 'Private'].",
+		},
+		hNetForDefaultRoute: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Description: "Network selected for the default route",
+		},
+		hNetUntagged: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Description: "Untagged network",
+		},
+		hLocation: {
+			Type:        schema.TypeString,
+			Required:    true,
+			ForceNew:    true,
+			Description: "The location of where the machines will be provisioned, of the form 'country:region:centre'.",
+		},
+		hUserData: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ForceNew:     true,
+			ValidateFunc: validateUserData,
+			Description:  "Any yaml compliant string that will be merged into cloud-init for each host.",
+		},
+		hDescription: {
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Description: "A wordy description of the machines and purpose.",
+		},
+		hLabels: {
+			Type:        schema.TypeMap,
+			Optional:    true,
+			ForceNew:    true,
+			Description: "map of label name to label value for each host",
+		},
+		hHostActionAsync: {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  true,
+			Description: "set true to create and delete hosts without waiting for them to be ready.  The default is true. " +
+				"Deletes always wait for the hosts to be deleted.",
+		},
+		hOnCreateFailure: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      onCreateFailureKeep,
+			ValidateFunc: validation.StringInSlice([]string{onCreateFailureKeep, onCreateFailureDelete, onCreateFailureRetry}, false),
+			Description:  "What to do with a host that fails to provision when host_action_async is false, as for a host.",
+		},
+		hgHosts: {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "The hosts of the group, in order of their index.",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					hgIndex: {
+						Type:        schema.TypeInt,
+						Computed:    true,
+						Description: "The index of the host in the group.",
+					},
+					hgID: {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "The ID of the host.",
+					},
+					hName: {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "The name of the host.",
+					},
+					hState: {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "The current state of the host.",
+					},
+					hConnections: {
+						Type:        schema.TypeMap,
+						Computed:    true,
+						Description: "A map of network connection name to assigned IP address, eg {'Private':'10.83.0.17'}.",
+					},
+				},
+			},
+		},
+		hgHostIDs: {
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+			Description: "The IDs of the hosts of the group, in order of their index.",
+		},
+	}
+}
+
+func HostGroupResource() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceMetalHostGroupCreate,
+		ReadContext:   resourceMetalHostGroupRead,
+		UpdateContext: resourceMetalHostGroupUpdate,
+		DeleteContext: resourceMetalHostGroupDelete,
+		CustomizeDiff: resourceMetalHostGroupCustomizeDiff,
+		Schema:        hostGroupSchema(),
+		Description: "Provides Host group resource. This allows a number of identical Metal Hosts to be created, " +
+			"scaled and deleted together.",
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(longTimeout),
+			Update: schema.DefaultTimeout(longTimeout),
+			Delete: schema.DefaultTimeout(longTimeout),
+		},
+	}
+}
+
+// hostGroupMember is a host of a group.
+type hostGroupMember struct {
+	index       int
+	id          string
+	name        string
+	state       string
+	connections map[string]string
+}
+
+func (m hostGroupMember) flatten() map[string]interface{} {
+	return map[string]interface{}{
+		hgIndex:      m.index,
+		hgID:         m.id,
+		hName:        m.name,
+		hState:       m.state,
+		hConnections: m.connections,
+	}
+}
+
+func resourceMetalHostGroupCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to create host group")
+
+	d.SetId(id.UniqueId())
+
+	diags = scaleHostGroup(ctx, d, meta, nil)
+
+	// A group that has no hosts isn't kept in the state if it failed, since
+	// there is nothing in it to clean up.
+	if diags.HasError() && len(hostGroupMembers(d)) == 0 {
+		d.SetId("")
+	}
+
+	return diags
+}
+
+func resourceMetalHostGroupRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to query host group")
+
+	p, err := client.GetClientFromMetaMap(meta)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	// The hosts of the group are listed at once, rather than read one at a time.
+	hosts, _, err := p.Client.HostsApi.List(ctx, nil)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	byID := make(map[string]rest.Host, len(hosts))
+	for _, h := range hosts {
+		byID[h.ID] = h
+	}
+
+	members := hostGroupMembers(d)
+	current := make([]hostGroupMember, 0, len(members))
+
+	for _, m := range members {
+		h, ok := byID[m.id]
+		if !ok || h.Deleted || h.State == rest.HOSTSTATE_DELETED {
+			diags = append(diags, warning("host %s (%s) of the group no longer exists, removing it from the group",
+				m.name, m.id))
+
+			continue
+		}
+
+		current = append(current, hostGroupMemberOf(m.index, h))
+	}
+
+	return append(diags, setHostGroupMembers(d, current)...)
+}
+
+func resourceMetalHostGroupUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to update host group")
+
+	return scaleHostGroup(ctx, d, meta, hostGroupMembers(d))
+}
+
+func resourceMetalHostGroupDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to delete host group")
+
+	remaining, diags := deleteHostGroupMembers(ctx, d, meta, hostGroupMembers(d))
+
+	return append(diags, setHostGroupMembers(d, remaining)...)
+}
+
+// resourceMetalHostGroupCustomizeDiff plans the hosts of a group to change when
+// its size changes, or when hosts have gone from the group since it was last
+// applied.
+func resourceMetalHostGroupCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+
+	hosts, _ := d.Get(hgHosts).([]interface{})
+	if !d.HasChange(hgSize) && d.NewValueKnown(hgSize) && len(hosts) == safeInt(d.Get(hgSize)) {
+		return nil
+	}
+
+	if err := d.SetNewComputed(hgHosts); err != nil {
+		return err
+	}
+
+	return d.SetNewComputed(hgHostIDs)
+}
+
+// scaleHostGroup deletes the hosts of a group with an index beyond its size,
+// the highest first, then creates the hosts that it is missing. The group is
+// left with the hosts that exist, whether or not that fails.
+func scaleHostGroup(ctx context.Context, d *schema.ResourceData, meta interface{},
+	members []hostGroupMember,
+) diag.Diagnostics {
+	extra, kept, missing := planHostGroupScale(members, safeInt(d.Get(hgSize)))
+
+	remaining, diags := deleteHostGroupMembers(ctx, d, meta, extra)
+	kept = append(kept, remaining...)
+
+	if diags.HasError() {
+		return append(diags, setHostGroupMembers(d, kept)...)
+	}
+
+	created, createDiags := createHostGroupMembers(ctx, d, meta, missing)
+	diags = append(diags, createDiags...)
+
+	return append(diags, setHostGroupMembers(d, append(kept, created...))...)
+}
+
+// planHostGroupScale returns the hosts of a group that are beyond size, with
+// the highest index first, the hosts that are kept, and the indices of the
+// hosts that are missing.
+func planHostGroupScale(members []hostGroupMember, size int) (extra, kept []hostGroupMember, missing []int) {
+	have := make(map[int]bool, len(members))
+
+	for _, m := range members {
+		if m.index >= size {
+			extra = append(extra, m)
+		} else {
+			kept = append(kept, m)
+			have[m.index] = true
+		}
+	}
+
+	sort.Slice(extra, func(i, j int) bool { return extra[i].index > extra[j].index })
+
+	for i := 0; i < size; i++ {
+		if !have[i] {
+			missing = append(missing, i)
+		}
+	}
+
+	return extra, kept, missing
+}
+
+// createHostGroupMembers creates the hosts of a group with the given indices,
+// no more than parallelism at a time, and returns those that were created.
+func createHostGroupMembers(ctx context.Context, d *schema.ResourceData, meta interface{},
+	indices []int,
+) ([]hostGroupMember, diag.Diagnostics) {
+	onFailure := safeString(d.Get(hOnCreateFailure))
+	created := make([]*hostGroupMember, len(indices))
+
+	hds, err := hostGroupMembersData(d, indices)
+	if err != nil {
+		return nil, diagFromErr(err)
+	}
+
+	diags := forEachBounded(len(indices), safeInt(d.Get(hgParallelism)), func(i int) diag.Diagnostics {
+		hd := hds[i]
+		name := safeString(hd.Get(hName))
+
+		diags := createHost(ctx, hd, meta, onFailure)
+		if hd.Id() != "" {
+			created[i] = &hostGroupMember{
+				index:       indices[i],
+				id:          hd.Id(),
+				name:        name,
+				state:       safeString(hd.Get(hState)),
+				connections: convertMap(mapOrEmpty(hd.Get(hConnections))),
+			}
+		}
+
+		return memberDiags(name, diags)
+	})
+
+	members := make([]hostGroupMember, 0, len(indices))
+
+	for _, m := range created {
+		if m != nil {
+			members = append(members, *m)
+		}
+	}
+
+	return members, diags
+}
+
+// deleteHostGroupMembers deletes the given hosts of a group, no more than
+// parallelism at a time, and returns those that are left.
+func deleteHostGroupMembers(ctx context.Context, d *schema.ResourceData, meta interface{},
+	members []hostGroupMember,
+) ([]hostGroupMember, diag.Diagnostics) {
+	deleted := make([]bool, len(members))
+	indices := make([]int, 0, len(members))
+
+	for _, m := range members {
+		indices = append(indices, m.index)
+	}
+
+	hds, err := hostGroupMembersData(d, indices)
+	if err != nil {
+		return members, diagFromErr(err)
+	}
+
+	for i, hd := range hds {
+		hd.SetId(members[i].id)
+	}
+
+	diags := forEachBounded(len(members), safeInt(d.Get(hgParallelism)), func(i int) diag.Diagnostics {
+		diags := resourceMetalHostDelete(ctx, hds[i], meta)
+		deleted[i] = !diags.HasError()
+
+		return memberDiags(members[i].name, diags)
+	})
+
+	remaining := make([]hostGroupMember, 0, len(members))
+
+	for i, m := range members {
+		if !deleted[i] {
+			remaining = append(remaining, m)
+		}
+	}
+
+	return remaining, diags
+}
+
+// hostGroupMembersData returns the data of the hosts of a group with the given
+// indices, for use with the functions of the host resource. The hosts have the
+// timeouts of the group. The data of the group is read here, as the hosts are
+// then created or deleted in parallel and ResourceData isn't safe for
+// concurrent use.
+func hostGroupMembersData(d *schema.ResourceData, indices []int) ([]*schema.ResourceData, error) {
+	create, update, del := d.Timeout(schema.TimeoutCreate), d.Timeout(schema.TimeoutUpdate), d.Timeout(schema.TimeoutDelete)
+
+	values := make(map[string]interface{}, len(hostGroupMemberKeys)+1)
+	for _, key := range hostGroupMemberKeys {
+		values[key] = d.Get(key)
+	}
+
+	values[hOnCreateFailure] = d.Get(hOnCreateFailure)
+	template := safeString(d.Get(hgNameTemplate))
+
+	hds := make([]*schema.ResourceData, 0, len(indices))
+
+	for _, index := range indices {
+		host := HostResource()
+		host.Timeouts = &schema.ResourceTimeout{Create: &create, Update: &update, Delete: &del}
+
+		hd := host.Data(nil)
+		name := hostGroupMemberName(template, index)
+
+		if err := hd.Set(hName, name); err != nil {
+			return nil, fmt.Errorf("host %s: set %s: %w", name, hName, err)
+		}
+
+		for key, value := range values {
+			if err := hd.Set(key, value); err != nil {
+				return nil, fmt.Errorf("host %s: set %s: %w", name, key, err)
+			}
+		}
+
+		hds = append(hds, hd)
+	}
+
+	return hds, nil
+}
+
+// validateNameTemplate checks that a name_template has the index placeholder,
+// so that the hosts of a group have different names.
+func validateNameTemplate(v interface{}, k string) ([]string, []error) {
+	if !strings.Contains(safeString(v), hgIndexPlaceholder) {
+		return nil, []error{fmt.Errorf("%s must contain %s", k, hgIndexPlaceholder)}
+	}
+
+	return nil, nil
+}
+
+// hostGroupMemberName returns the name of the host of a group with the given index.
+func hostGroupMemberName(template string, index int) string {
+	return strings.ReplaceAll(template, hgIndexPlaceholder, strconv.Itoa(index))
+}
+
+// hostGroupMemberOf returns the member of a group with the given index for a host.
+func hostGroupMemberOf(index int, h rest.Host) hostGroupMember {
+	connections := make(map[string]string)
+
+	for _, conn := range h.Connections {
+		for _, net := range conn.Networks {
+			connections[net.Name] = net.IP
+		}
+	}
+
+	return hostGroupMember{
+		index:       index,
+		id:          h.ID,
+		name:        h.Name,
+		state:       string(h.State),
+		connections: connections,
+	}
+}
+
+// hostGroupMembers returns the hosts of a group that are in the state.
+func hostGroupMembers(d *schema.ResourceData) []hostGroupMember {
+	hosts, _ := d.Get(hgHosts).([]interface{})
+	members := make([]hostGroupMember, 0, len(hosts))
+
+	for _, h := range hosts {
+		m, _ := h.(map[string]interface{})
+		members = append(members, hostGroupMember{
+			index:       safeInt(m[hgIndex]),
+			id:          safeString(m[hgID]),
+			name:        safeString(m[hName]),
+			state:       safeString(m[hState]),
+			connections: convertMap(mapOrEmpty(m[hConnections])),
+		})
+	}
+
+	return members
+}
+
+// setHostGroupMembers sets the hosts of a group, in order of their index.
+func setHostGroupMembers(d *schema.ResourceData, members []hostGroupMember) diag.Diagnostics {
+	sort.Slice(members, func(i, j int) bool { return members[i].index < members[j].index })
+
+	hosts := make([]interface{}, 0, len(members))
+	ids := make([]string, 0, len(members))
+
+	for _, m := range members {
+		hosts = append(hosts, m.flatten())
+		ids = append(ids, m.id)
+	}
+
+	if err := d.Set(hgHosts, hosts); err != nil {
+		return diag.Errorf("set hosts: %v", err)
+	}
+
+	if err := d.Set(hgHostIDs, ids); err != nil {
+		return diag.Errorf("set host IDs: %v", err)
+	}
+
+	return nil
+}
+
+// forEachBounded calls fn for each of 0 to n-1, in that order, with no more
+// than limit calls running at a time, and returns all their diagnostics.
+func forEachBounded(n, limit int, fn func(i int) diag.Diagnostics) diag.Diagnostics {
+	if limit < 1 {
+		limit = 1
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		diags diag.Diagnostics
+	)
+
+	sem := make(chan struct{}, limit)
+
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			d := fn(i)
+
+			mu.Lock()
+			diags = append(diags, d...)
+			mu.Unlock()
+		}(i)
+	}
+
+	wg.Wait()
+
+	return diags
+}
+
+// memberDiags prefixes the summaries of the diagnostics of a host of a group
+// with its name.
+func memberDiags(name string, diags diag.Diagnostics) diag.Diagnostics {
+	for i := range diags {
+		diags[i].Summary = fmt.Sprintf("host %s: %s", name, diags[i].Summary)
+	}
+
+	return diags
+}
+
+// mapOrEmpty returns v if it is a map, or nil.
+func mapOrEmpty(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+
+	return m
+}
@@ -0,0 +1,173 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/internal/test-utils/fakeportal"
+)
+
+func TestForEachBounded(t *testing.T) {
+	var (
+		mu             sync.Mutex
+		running, most  int
+		calls          []int
+		expectedCalled = []int{0, 1, 2, 3, 4, 5, 6}
+	)
+
+	diags := forEachBounded(len(expectedCalled), 3, func(i int) diag.Diagnostics {
+		mu.Lock()
+		running++
+		most = max(most, running)
+		calls = append(calls, i)
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if i == 4 {
+			return diag.Errorf("failed %d", i)
+		}
+
+		return nil
+	})
+
+	assert.LessOrEqual(t, most, 3)
+	assert.ElementsMatch(t, expectedCalled, calls)
+	if assert.Len(t, diags, 1) {
+		assert.Equal(t, "failed 4", diags[0].Summary)
+	}
+}
+
+func TestHostGroupCRUD(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+
+	raw := hostRawConfig(map[string]interface{}{
+		hName:          nil,
+		hgNameTemplate: "web-{index}",
+		hgSize:         3,
+		hgParallelism:  2,
+		hNetworks:      []interface{}{fakeportal.PublicNetwork, fakeportal.StorageNetwork},
+		hLabels:        map[string]interface{}{"role": "web"},
+	})
+
+	d := schema.TestResourceDataRaw(t, hostGroupSchema(), raw)
+
+	assert.Nil(t, resourceMetalHostGroupCreate(context.Background(), d, meta))
+	assert.NotEmpty(t, d.Id())
+	assert.Equal(t, int32(7), availableResources(t, cfg).MachineInventory[0].Number)
+
+	assert.Nil(t, resourceMetalHostGroupRead(context.Background(), d, meta))
+
+	members := hostGroupMembers(d)
+	if assert.Len(t, members, 3) {
+		for i, m := range members {
+			assert.Equal(t, i, m.index)
+			assert.Equal(t, hostGroupMemberName("web-{index}", i), m.name)
+			assert.NotEmpty(t, m.id)
+			assert.NotEmpty(t, m.connections[fakeportal.PublicNetwork])
+		}
+	}
+
+	assert.Len(t, d.Get(hgHostIDs), 3)
+
+	// A group that has lost a host plans to replace it.
+	assert.Nil(t, setHostGroupMembers(d, members[:2]))
+
+	diff, err := HostGroupResource().Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(raw), meta)
+	assert.NoError(t, err)
+	assert.True(t, diff != nil && !diff.Empty() && !diff.RequiresNew(), "unexpected diff %v", diff)
+
+	assert.Nil(t, setHostGroupMembers(d, members))
+
+	// Scale up.
+	assert.Nil(t, d.Set(hgSize, 4))
+	assert.Nil(t, resourceMetalHostGroupUpdate(context.Background(), d, meta))
+
+	scaled := hostGroupMembers(d)
+	if assert.Len(t, scaled, 4) {
+		assert.Equal(t, members, scaled[:3])
+		assert.Equal(t, "web-3", scaled[3].name)
+	}
+
+	assert.Nil(t, d.Set(hgParallelism, 4))
+	assert.Nil(t, resourceMetalHostGroupDelete(context.Background(), d, meta))
+	assert.Empty(t, d.Get(hgHostIDs))
+	assert.Equal(t, int32(10), availableResources(t, cfg).MachineInventory[0].Number)
+}
+
+func TestPlanHostGroupScale(t *testing.T) {
+	members := []hostGroupMember{{index: 0, id: "a"}, {index: 2, id: "c"}, {index: 3, id: "d"}, {index: 4, id: "e"}}
+
+	tests := []struct {
+		name       string
+		size       int
+		expExtra   []string
+		expKept    []string
+		expMissing []int
+	}{
+		{"Scale up and fill gaps", 6, nil, []string{"a", "c", "d", "e"}, []int{1, 5}},
+		{"Scale down highest first", 2, []string{"e", "d", "c"}, []string{"a"}, []int{1}},
+		{"Empty", 0, []string{"e", "d", "c", "a"}, nil, nil},
+	}
+
+	ids := func(members []hostGroupMember) []string {
+		var ids []string
+		for _, m := range members {
+			ids = append(ids, m.id)
+		}
+
+		return ids
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extra, kept, missing := planHostGroupScale(members, tt.size)
+			assert.Equal(t, tt.expExtra, ids(extra))
+			assert.Equal(t, tt.expKept, ids(kept))
+			assert.Equal(t, tt.expMissing, missing)
+		})
+	}
+}
+
+func TestValidateNameTemplate(t *testing.T) {
+	_, errs := validateNameTemplate("web-{index}", hgNameTemplate)
+	assert.Empty(t, errs)
+
+	_, errs = validateNameTemplate("web", hgNameTemplate)
+	assert.Len(t, errs, 1)
+}
+
+func TestHostGroupMembersData(t *testing.T) {
+	create, del := 90*time.Minute, 45*time.Minute
+
+	group := HostGroupResource()
+	group.Timeouts = &schema.ResourceTimeout{Create: &create, Delete: &del}
+
+	d := group.Data(nil)
+	assert.Nil(t, d.Set(hgNameTemplate, "web-{index}"))
+	assert.Nil(t, d.Set(hSize, fakeportal.MachineSize))
+
+	hds, err := hostGroupMembersData(d, []int{2})
+	if assert.NoError(t, err) && assert.Len(t, hds, 1) {
+		hd := hds[0]
+		assert.Equal(t, "web-2", hd.Get(hName))
+		assert.Equal(t, fakeportal.MachineSize, hd.Get(hSize))
+		assert.Equal(t, create, hd.Timeout(schema.TimeoutCreate))
+		assert.Equal(t, del, hd.Timeout(schema.TimeoutDelete))
+	}
+}
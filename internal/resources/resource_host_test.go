@@ -1,14 +1,17 @@
-// (C) Copyright 2022 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2022, 2026 Hewlett Packard Enterprise Development LP
 
 package resources
 
 import (
+	"context"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/internal/test-utils/fakeportal"
 )
 
 func Test_setConnectionsValues(t *testing.T) {
@@ -53,3 +56,368 @@ func Test_setConnectionsValues(t *testing.T) {
 	assert.Equal(t, 1, len(connGateways))
 	assert.Equal(t, someGateway, connGateways[someName])
 }
+
+func TestHostCRUD(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+
+	d := schema.TestResourceDataRaw(t, hostSchema(), hostRawConfig(map[string]interface{}{
+		hNetworks:           []interface{}{fakeportal.PublicNetwork, fakeportal.StorageNetwork},
+		hNetForDefaultRoute: fakeportal.PublicNetwork,
+	}))
+
+	assert.Nil(t, resourceMetalHostCreate(context.Background(), d, meta))
+	assert.NotEmpty(t, d.Id())
+	// The fake portal advances the host state each time it is read.
+	assert.Equal(t, string(client.HOSTSTATE_IMAGING), d.Get(hState))
+	assert.Equal(t, fakeportal.Location, d.Get(hLocation))
+	assert.Len(t, d.Get(hNetworkIDs), 2)
+
+	assert.Nil(t, resourceMetalHostRead(context.Background(), d, meta))
+
+	assert.Equal(t, string(client.HOSTSTATE_READY), d.Get(hState))
+	assert.Equal(t, string(client.HOSTPOWERSTATE_ON), d.Get(hPwrState))
+
+	connIPs, ok := d.Get(hConnections).(map[string]interface{})
+	assert.True(t, ok, "type assertion failed")
+	assert.Len(t, connIPs, 2)
+	assert.NotEmpty(t, connIPs[fakeportal.PublicNetwork])
+
+	assert.Nil(t, d.Set(hNetworks, []interface{}{fakeportal.PublicNetwork}))
+	assert.Nil(t, resourceMetalHostUpdate(context.Background(), d, meta))
+	assert.Equal(t, string(client.HOSTSTATE_READY), d.Get(hState))
+	assert.Len(t, d.Get(hNetworkIDs), 1)
+
+	connIPs, ok = d.Get(hConnections).(map[string]interface{})
+	assert.True(t, ok, "type assertion failed")
+	assert.Len(t, connIPs, 1)
+
+	assert.Nil(t, resourceMetalHostDelete(context.Background(), d, meta))
+	assert.Equal(t, int32(10), availableResources(t, cfg).MachineInventory[0].Number)
+}
+
+func TestHostPowerState(t *testing.T) {
+	t.Parallel()
+
+	_, _, meta := newFakePortalMeta(t)
+
+	hostConfig := func(powerState string) *schema.ResourceData {
+		raw := hostRawConfig(nil)
+		if powerState != "" {
+			raw[hPwrState] = powerState
+		}
+
+		return schema.TestResourceDataRaw(t, hostSchema(), raw)
+	}
+
+	d := hostConfig("")
+	assert.Nil(t, resourceMetalHostCreate(context.Background(), d, meta))
+	assert.Nil(t, resourceMetalHostRead(context.Background(), d, meta))
+	assert.Equal(t, string(client.HOSTPOWERSTATE_ON), d.Get(hPwrState))
+
+	tCases := []struct {
+		powerState string
+		expect     string
+	}{
+		{powerState: "off", expect: string(client.HOSTPOWERSTATE_OFF)},
+		{powerState: "reset", expect: "reset"},
+		{powerState: "on", expect: string(client.HOSTPOWERSTATE_ON)},
+	}
+
+	for _, tc := range tCases {
+		u := hostConfig(tc.powerState)
+		u.SetId(d.Id())
+
+		assert.Nil(t, resourceMetalHostUpdate(context.Background(), u, meta))
+		assert.Equal(t, tc.expect, u.Get(hPwrState), tc.powerState)
+	}
+}
+
+func TestPowerStateValue(t *testing.T) {
+	assert.Equal(t, "reset", powerStateValue("reset", client.HOSTPOWERSTATE_ON))
+	assert.Equal(t, "OFF", powerStateValue("reset", client.HOSTPOWERSTATE_OFF))
+	assert.Equal(t, "ON", powerStateValue("off", client.HOSTPOWERSTATE_ON))
+	assert.Equal(t, "OFF", powerStateValue("", client.HOSTPOWERSTATE_OFF))
+}
+
+func TestHostReadDrift(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+	ar := availableResources(t, cfg)
+
+	netIDs := make(map[string]string)
+	for _, net := range ar.Networks {
+		netIDs[net.Name] = net.ID
+	}
+
+	d := schema.TestResourceDataRaw(t, hostSchema(), hostRawConfig(map[string]interface{}{
+		hSSHKeys:  []interface{}{ar.SSHKeys[0].ID},
+		hNetworks: []interface{}{netIDs[fakeportal.StorageNetwork], fakeportal.PublicNetwork},
+	}))
+
+	assert.Nil(t, resourceMetalHostCreate(context.Background(), d, meta))
+	assert.Equal(t, []interface{}{ar.SSHKeys[0].ID}, d.Get(hSSHKeys))
+	assert.Equal(t, []interface{}{ar.SSHKeys[0].ID}, d.Get(hSSHKeyIDs))
+	assert.Equal(t, []interface{}{netIDs[fakeportal.StorageNetwork], fakeportal.PublicNetwork}, d.Get(hNetworks))
+
+	// Networks changed in the portal.
+	update := func(networkIDs ...string) {
+		host, _, err := cfg.Client.HostsApi.GetByID(testContext(t, cfg), d.Id(), nil)
+		assert.Nil(t, err)
+
+		_, _, err = cfg.Client.HostsApi.Update(testContext(t, cfg), d.Id(), client.UpdateHost{
+			ID:                     host.ID,
+			ETag:                   host.ETag,
+			Name:                   host.Name,
+			NetworkIDs:             networkIDs,
+			NetworkForDefaultRoute: networkIDs[0],
+		}, nil)
+		assert.Nil(t, err)
+	}
+
+	update(netIDs[fakeportal.PublicNetwork])
+	assert.Nil(t, resourceMetalHostRead(context.Background(), d, meta))
+	assert.Equal(t, []interface{}{fakeportal.PublicNetwork}, d.Get(hNetworks))
+
+	update(netIDs[fakeportal.PublicNetwork], netIDs[fakeportal.StorageNetwork])
+	assert.Nil(t, resourceMetalHostRead(context.Background(), d, meta))
+	assert.Equal(t, []interface{}{fakeportal.PublicNetwork, fakeportal.StorageNetwork}, d.Get(hNetworks))
+}
+
+func TestHostReadVolumeAttachments(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+	ar := availableResources(t, cfg)
+
+	volIDs := make(map[string]string)
+
+	for _, name := range []string{"vol1", "vol2"} {
+		vol, _, err := cfg.Client.VolumesApi.Add(testContext(t, cfg), client.NewVolume{
+			Name:       name,
+			FlavorID:   ar.VolumeFlavors[0].ID,
+			LocationID: ar.Locations[0].ID,
+			Capacity:   10,
+		}, nil)
+		assert.Nil(t, err)
+
+		volIDs[name] = vol.ID
+	}
+
+	cfg.InvalidateAvailableResources()
+
+	d := schema.TestResourceDataRaw(t, hostSchema(), hostRawConfig(map[string]interface{}{
+		hVolumeAttachments: []interface{}{"vol1"},
+	}))
+	assert.Nil(t, resourceMetalHostCreate(context.Background(), d, meta))
+
+	// A volume attached outside of Terraform is in volume_infos, but not in
+	// volume_attachments.
+	_, _, err := cfg.Client.VolumesApi.Attach(testContext(t, cfg), volIDs["vol2"],
+		client.VolumeAttachHostUuid{HostID: d.Id()}, nil)
+	assert.Nil(t, err)
+
+	assert.Nil(t, resourceMetalHostRead(context.Background(), d, meta))
+	assert.Equal(t, []interface{}{"vol1"}, d.Get(hVolumeAttachments))
+
+	infos := make(map[string]string)
+	for _, info := range d.Get(hVolumeInfos).(*schema.Set).List() {
+		vi, _ := info.(map[string]interface{})
+		infos[safeString(vi[vName])] = safeString(vi[vID])
+	}
+
+	assert.Equal(t, volIDs, infos)
+}
+
+func TestNamesOrIDs(t *testing.T) {
+	names := map[string]string{"id1": "one", "id2": "two", "id3": "three"}
+
+	tests := []struct {
+		name     string
+		current  []string
+		ids      []string
+		expected []string
+	}{
+		{"Unchanged", []string{"two", "id1"}, []string{"id1", "id2"}, []string{"two", "id1"}},
+		{"Removed", []string{"two", "id1"}, []string{"id1"}, []string{"id1"}},
+		{"Added", []string{"id1"}, []string{"id1", "id3"}, []string{"id1", "three"}},
+		{"Added without name", []string{}, []string{"id4"}, []string{"id4"}},
+		{"Imported", nil, []string{"id2", "id1"}, []string{"two", "one"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, namesOrIDs(tt.current, tt.ids, names))
+		})
+	}
+}
+
+func TestHostReadDeleted(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+
+	d := schema.TestResourceDataRaw(t, hostSchema(), hostRawConfig(nil))
+
+	assert.Nil(t, resourceMetalHostCreate(context.Background(), d, meta))
+
+	// Deleted in the portal, the host is in the Deleted state before it is purged.
+	_, err := cfg.Client.HostsApi.Delete(testContext(t, cfg), d.Id(), nil)
+	assert.Nil(t, err)
+
+	diags := resourceMetalHostRead(context.Background(), d, meta)
+	assert.False(t, diags.HasError(), "unexpected error %v", diags)
+	assert.Empty(t, d.Id())
+}
+
+func TestHostCreateFailure(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		onFailure string
+		expDelete bool
+		expError  bool
+	}{
+		{onCreateFailureKeep, false, true},
+		{onCreateFailureDelete, true, true},
+		{onCreateFailureRetry, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.onFailure, func(t *testing.T) {
+			t.Parallel()
+
+			srv, cfg, meta := newFakePortalMeta(t)
+			srv.FailProvisioning("PXE boot timed out")
+
+			d := schema.TestResourceDataRaw(t, hostSchema(), hostRawConfig(nil))
+
+			assert.Nil(t, resourceMetalHostCreate(context.Background(), d, meta))
+			id := d.Id()
+
+			// The create waiter stops as soon as the host has failed.
+			_, state, err := hostCreateRefresh(testContext(t, cfg), cfg.Client.HostsApi, id)()
+			assert.Equal(t, string(client.HOSTSTATE_IMAGING), state)
+
+			var failure *hostProvisioningError
+			if !assert.ErrorAs(t, err, &failure) {
+				return
+			}
+
+			assert.EqualError(t, failure, `provisioning failed with state "Imaging", sub_state "Failed", `+
+				`summary_status "Critical": PXE boot timed out`)
+
+			diags := handleHostCreateFailure(context.Background(), d, meta, tt.onFailure, failure)
+			assert.Equal(t, tt.expError, diags.HasError(), "unexpected diagnostics %v", diags)
+
+			if !tt.expDelete {
+				assert.Equal(t, id, d.Id())
+
+				return
+			}
+
+			_, resp, err := cfg.Client.HostsApi.GetByID(testContext(t, cfg), id, nil)
+			if assert.Error(t, err) {
+				assert.True(t, isNotFound(resp))
+			}
+
+			if tt.expError {
+				assert.Empty(t, d.Id())
+			} else {
+				assert.NotEmpty(t, d.Id())
+				assert.NotEqual(t, id, d.Id())
+			}
+		})
+	}
+}
+
+func TestHostMachineSizeFallbacks(t *testing.T) {
+	t.Parallel()
+
+	srv, cfg, meta := newFakePortalMeta(t)
+	ar := availableResources(t, cfg)
+
+	// G2i is out of stock, and G3i is only in stock in the other location.
+	const otherLocation = "USA:West:AFCDCW1"
+
+	otherLocationID := srv.AddLocation(otherLocation)
+	g3iID := srv.AddMachineSize("G3i", client.FlavorDesc{Banner1: "2 x AMD EPYC 7302 - 32 cores"})
+	srv.SetMachineInventory(ar.Locations[0].ID, ar.MachineSizes[0].ID, 0)
+	srv.SetMachineInventory(otherLocationID, g3iID, 1)
+	cfg.InvalidateAvailableResources()
+
+	raw := hostRawConfig(map[string]interface{}{
+		hSizeFallbacks: []interface{}{
+			map[string]interface{}{hSize: "G3i"},
+			map[string]interface{}{hSize: "G3i", hLocation: otherLocation},
+		},
+	})
+
+	d := schema.TestResourceDataRaw(t, hostSchema(), raw)
+	assert.Nil(t, resourceMetalHostCreate(context.Background(), d, meta))
+	assert.Equal(t, g3iID, d.Get(hSizeID))
+	assert.Equal(t, otherLocationID, d.Get(hLocationID))
+	assert.Equal(t, "G3i", d.Get(hSize))
+	assert.Equal(t, otherLocation, d.Get(hLocation))
+
+	// The host isn't replaced while the fallback it was created with is configured.
+	diff, err := HostResource().Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(raw), meta)
+	assert.NoError(t, err)
+
+	if diff != nil {
+		assert.False(t, diff.RequiresNew())
+		assert.NotContains(t, diff.Attributes, hSize)
+		assert.NotContains(t, diff.Attributes, hLocation)
+	}
+
+	raw[hSizeFallbacks] = []interface{}{map[string]interface{}{hSize: "G3i"}}
+
+	diff, err = HostResource().Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(raw), meta)
+	assert.NoError(t, err)
+
+	if assert.NotNil(t, diff) {
+		assert.True(t, diff.RequiresNew())
+	}
+
+	// There are no machines left of any of the machine sizes.
+	d = schema.TestResourceDataRaw(t, hostSchema(), raw)
+	diags := resourceMetalHostCreate(context.Background(), d, meta)
+
+	if assert.True(t, diags.HasError()) {
+		assert.Contains(t, diags[0].Summary, `no machines available of machine size "G2i" in "USA:Central:AFCDCC1", `+
+			`"G3i" in "USA:Central:AFCDCC1"`)
+	}
+
+	// A host without fallbacks is added whatever the inventory, and the portal
+	// says why it can't be.
+	delete(raw, hSizeFallbacks)
+
+	d = schema.TestResourceDataRaw(t, hostSchema(), raw)
+	diags = resourceMetalHostCreate(context.Background(), d, meta)
+
+	if assert.True(t, diags.HasError()) {
+		assert.Contains(t, diags[0].Summary, `no machines of size "G2i" available`)
+	}
+
+	// Fallbacks that aren't found are skipped with a warning.
+	srv.SetMachineInventory(otherLocationID, g3iID, 2)
+	cfg.InvalidateAvailableResources()
+
+	raw[hSizeFallbacks] = []interface{}{
+		map[string]interface{}{hSize: "G9z"},
+		map[string]interface{}{hSize: "G3i", hLocation: "Nowhere"},
+		map[string]interface{}{hSize: "G3i", hLocation: otherLocation},
+	}
+
+	d = schema.TestResourceDataRaw(t, hostSchema(), raw)
+	diags = resourceMetalHostCreate(context.Background(), d, meta)
+	assert.False(t, diags.HasError(), "unexpected error %v", diags)
+	assert.Equal(t, g3iID, d.Get(hSizeID))
+
+	if assert.Len(t, diags, 2) {
+		assert.Contains(t, diags[0].Summary, `machine size "G9z" in "USA:Central:AFCDCC1" skipped`)
+		assert.Contains(t, diags[1].Summary, `machine size "G3i" in "Nowhere" skipped`)
+	}
+}
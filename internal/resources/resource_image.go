@@ -1,14 +1,16 @@
-// (C) Copyright 2023 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2023, 2026 Hewlett Packard Enterprise Development LP
 
 package resources
 
 import (
-	"fmt"
+	"context"
 	"os"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/configuration"
 )
 
 const iServiceImageFile = "os_service_image_file"
@@ -25,10 +27,10 @@ func serviceSchema() map[string]*schema.Schema {
 
 func ServiceImageResource() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceMetalImageCreate,
-		Read:   resourceMetalImageRead,
-		Delete: resourceMetalImageDelete,
-		Update: resourceMetalImageUpdate,
+		CreateContext: resourceMetalImageCreate,
+		ReadContext:   resourceMetalImageRead,
+		DeleteContext: resourceMetalImageDelete,
+		UpdateContext: resourceMetalImageUpdate,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -37,76 +39,89 @@ func ServiceImageResource() *schema.Resource {
 	}
 }
 
-func resourceMetalImageCreate(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "create OS service image")
+func resourceMetalImageCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "create OS service image")
 
 	filePath := safeString(d.Get(iServiceImageFile))
 
 	file, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("open file %s, %v", filePath, err)
+		return diag.Errorf("open file %s, %v", filePath, err)
 	}
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return fmt.Errorf("get client, %v", err)
+		return diag.Errorf("get client, %v", err)
 	}
 
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 
 	svc, _, err := p.Client.ServicesApi.Add(ctx, file, nil)
 	if err != nil {
-		return err //nolint:wrapcheck // defer func is wrapping the error.
+		return diagFromErr(err)
 	}
 
 	d.SetId(svc.ID)
+	p.InvalidateAvailableResources(configuration.KindImages)
 
 	return nil
 }
 
-func resourceMetalImageRead(_ *schema.ResourceData, _ interface{}) (err error) {
+func resourceMetalImageRead(_ context.Context, _ *schema.ResourceData, _ interface{}) (diags diag.Diagnostics) {
 	return nil
 }
 
-func resourceMetalImageDelete(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "delete OS service image")
+func resourceMetalImageDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "delete OS service image")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return fmt.Errorf("get client, %v", err)
+		return diag.Errorf("get client, %v", err)
 	}
 
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 
 	if _, err = p.Client.ServicesApi.Delete(ctx, d.Id(), nil); err != nil {
-		return err //nolint:wrapcheck // defer func is wrapping the error.
+		return diagFromErr(err)
 	}
 
 	d.SetId("")
+	p.InvalidateAvailableResources(configuration.KindImages)
 
 	return nil
 }
 
-func resourceMetalImageUpdate(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "replace OS service image")
+func resourceMetalImageUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "replace OS service image")
 
 	filePath := safeString(d.Get(iServiceImageFile))
 
 	file, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("open file %s, %v", filePath, err)
+		return diag.Errorf("open file %s, %v", filePath, err)
 	}
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return fmt.Errorf("get client, %v", err)
+		return diag.Errorf("get client, %v", err)
 	}
 
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 
 	if _, _, err := p.Client.ServicesApi.Update(ctx, d.Id(), file, nil); err != nil {
-		return err //nolint:wrapcheck // defer func is wrapping the error.
+		return diagFromErr(err)
 	}
 
+	p.InvalidateAvailableResources(configuration.KindImages)
+
 	return nil
 }
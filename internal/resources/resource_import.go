@@ -0,0 +1,257 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/configuration"
+)
+
+// importCandidate is an existing resource that an import ID may refer to.
+type importCandidate struct {
+	id         string
+	name       string
+	locationID string
+}
+
+// importLister lists the resources of a kind that can be imported.
+type importLister func(ctx context.Context, p *configuration.Config) ([]importCandidate, error)
+
+// importByName returns an importer that accepts the ID of a resource, its name
+// if that is unique, or location/name for resources whose names are unique in
+// a location.
+func importByName(kind string, list importLister) *schema.ResourceImporter {
+	return &schema.ResourceImporter{
+		StateContext: func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+			p, err := client.GetClientFromMetaMap(meta)
+			if err != nil {
+				return nil, err
+			}
+
+			ctx, err = p.ContextWithToken(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			candidates, err := list(ctx, p)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %ss: %w", kind, err)
+			}
+
+			id, err := resolveImportID(p, kind, d.Id(), candidates)
+			if err != nil {
+				return nil, err
+			}
+
+			d.SetId(id)
+
+			return []*schema.ResourceData{d}, nil
+		},
+	}
+}
+
+// importHost imports a host by ID or name, and sets the attributes that Read
+// leaves as they are in the state to their values in the portal, by name, so
+// that an imported host plans clean against a configuration that matches it.
+// networks and ssh are set by Read.
+func importHost(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	rds, err := importByName("host", listHostsForImport).StateContext(ctx, d, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := client.GetClientFromMetaMap(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	host, _, err := p.Client.HostsApi.GetByID(ctx, d.Id(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get host %s: %w", d.Id(), err)
+	}
+
+	available, err := p.GetAvailableResources(configuration.KindNetworks)
+	if err != nil {
+		return nil, err
+	}
+
+	netName := func(id string) string {
+		for _, net := range available.Networks {
+			if net.ID == id {
+				return net.Name
+			}
+		}
+
+		return id
+	}
+
+	if host.NetworkForDefaultRoute != "" {
+		d.Set(hNetForDefaultRoute, netName(host.NetworkForDefaultRoute))
+	}
+
+	if host.NetworkUntagged != "" {
+		d.Set(hNetUntagged, netName(host.NetworkUntagged))
+	}
+
+	vas, _, err := p.Client.VolumeAttachmentsApi.List(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volume attachments: %w", err)
+	}
+
+	volumes := make([]string, 0, len(vas))
+	for _, vi := range getVAsForHost(host.ID, vas) {
+		volumes = append(volumes, vi.Name)
+	}
+
+	d.Set(hVolumeAttachments, volumes)
+
+	// User data that is gzipped and base64 encoded was created with user_data_encoding.
+	if _, ok := decodeUserData(host.UserData); ok {
+		d.Set(hUserDataEncoding, userDataGzipBase64)
+	}
+
+	d.Set(hHostActionAsync, true)
+	d.Set(hOnCreateFailure, onCreateFailureKeep)
+
+	return rds, nil
+}
+
+// resolveImportID returns the ID of the candidate that importID refers to.
+func resolveImportID(p *configuration.Config, kind, importID string, candidates []importCandidate) (string, error) {
+	for _, c := range candidates {
+		if c.id == importID {
+			return c.id, nil
+		}
+	}
+
+	name := importID
+	matches := matchImportName(candidates, name, "")
+
+	// Names that aren't found may be prefixed with their location.
+	if loc, n, ok := strings.Cut(importID, "/"); ok && len(matches) == 0 {
+		locationID, err := p.GetLocationID(loc)
+		if err != nil {
+			return "", fmt.Errorf("%s %q: %w", kind, importID, err)
+		}
+
+		name = n
+		matches = matchImportName(candidates, name, locationID)
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%s %q not found", kind, importID)
+	case 1:
+		return matches[0].id, nil
+	}
+
+	names := make([]string, 0, len(matches))
+
+	for _, c := range matches {
+		if loc, err := p.GetLocationName(c.locationID); err == nil && c.locationID != "" {
+			names = append(names, fmt.Sprintf("%s/%s (%s)", loc, c.name, c.id))
+		} else {
+			names = append(names, fmt.Sprintf("%s (%s)", c.name, c.id))
+		}
+	}
+
+	sort.Strings(names)
+
+	return "", fmt.Errorf("%s name %q is ambiguous, import one of these by ID or location/name: %s",
+		kind, name, strings.Join(names, ", "))
+}
+
+// matchImportName returns the candidates with the given name, in the given
+// location if it isn't empty.
+func matchImportName(candidates []importCandidate, name, locationID string) []importCandidate {
+	var matches []importCandidate
+
+	for _, c := range candidates {
+		if c.name == name && (locationID == "" || c.locationID == locationID) {
+			matches = append(matches, c)
+		}
+	}
+
+	return matches
+}
+
+func listHostsForImport(ctx context.Context, p *configuration.Config) ([]importCandidate, error) {
+	hosts, _, err := p.Client.HostsApi.List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]importCandidate, 0, len(hosts))
+
+	for _, h := range hosts {
+		if h.Deleted || h.State == rest.HOSTSTATE_DELETED {
+			continue
+		}
+
+		candidates = append(candidates, importCandidate{id: h.ID, name: h.Name, locationID: h.LocationID})
+	}
+
+	return candidates, nil
+}
+
+func listVolumesForImport(ctx context.Context, p *configuration.Config) ([]importCandidate, error) {
+	volumes, _, err := p.Client.VolumesApi.List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]importCandidate, 0, len(volumes))
+
+	for _, v := range volumes {
+		if v.State == rest.VOLUMESTATE_DELETED {
+			continue
+		}
+
+		candidates = append(candidates, importCandidate{id: v.ID, name: v.Name, locationID: v.LocationID})
+	}
+
+	return candidates, nil
+}
+
+func listNetworksForImport(ctx context.Context, p *configuration.Config) ([]importCandidate, error) {
+	networks, _, err := p.Client.NetworksApi.List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]importCandidate, 0, len(networks))
+
+	for _, n := range networks {
+		candidates = append(candidates, importCandidate{id: n.ID, name: n.Name, locationID: n.LocationID})
+	}
+
+	return candidates, nil
+}
+
+func listSSHKeysForImport(ctx context.Context, p *configuration.Config) ([]importCandidate, error) {
+	keys, _, err := p.Client.SshkeysApi.List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]importCandidate, 0, len(keys))
+
+	for _, k := range keys {
+		candidates = append(candidates, importCandidate{id: k.ID, name: k.Name})
+	}
+
+	return candidates, nil
+}
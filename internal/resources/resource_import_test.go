@@ -0,0 +1,138 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/internal/test-utils/fakeportal"
+)
+
+func TestImportByName(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+	ar := availableResources(t, cfg)
+
+	netIDs := make(map[string]string)
+	for _, net := range ar.Networks {
+		netIDs[net.Name] = net.ID
+	}
+
+	// Two hosts with the same name.
+	hostIDs := make([]string, 0, 2)
+
+	for i := 0; i < 2; i++ {
+		d := schema.TestResourceDataRaw(t, hostSchema(), hostRawConfig(map[string]interface{}{
+			hName: "dup",
+		}))
+
+		assert.Nil(t, resourceMetalHostCreate(context.Background(), d, meta))
+		hostIDs = append(hostIDs, d.Id())
+	}
+
+	tests := []struct {
+		name     string
+		resource *schema.Resource
+		importID string
+		expID    string
+		expErr   string
+	}{
+		{"Network by ID", ProjectNetworkResource(), netIDs[fakeportal.PublicNetwork], netIDs[fakeportal.PublicNetwork], ""},
+		{"Network by name", ProjectNetworkResource(), fakeportal.StorageNetwork, netIDs[fakeportal.StorageNetwork], ""},
+		{
+			"Network by location and name", ProjectNetworkResource(),
+			fakeportal.Location + "/" + fakeportal.PublicNetwork, netIDs[fakeportal.PublicNetwork], "",
+		},
+		{"Network in unknown location", ProjectNetworkResource(), "USA:Central:Missing/Public", "", `location "USA:Central:Missing" not found`},
+		{"SSH key by name", SshKeyResource(), fakeportal.SSHKey, ar.SSHKeys[0].ID, ""},
+		{"Host by ID", HostResource(), hostIDs[1], hostIDs[1], ""},
+		{"Host not found", HostResource(), "missing", "", `host "missing" not found`},
+		{
+			"Host name ambiguous", HostResource(), "dup", "",
+			`host name "dup" is ambiguous, import one of these by ID or location/name: ` +
+				fakeportal.Location + "/dup (" + hostIDs[0] + ")",
+		},
+		{"Volume not found", VolumeResource(), "missing", "", `volume "missing" not found`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, tt.resource.Schema, map[string]interface{}{})
+			d.SetId(tt.importID)
+
+			rds, err := tt.resource.Importer.StateContext(context.Background(), d, meta)
+			if tt.expErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.expErr)
+				}
+
+				return
+			}
+
+			if assert.NoError(t, err) && assert.Len(t, rds, 1) {
+				assert.Equal(t, tt.expID, rds[0].Id())
+			}
+		})
+	}
+}
+
+func TestHostImport(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+	ar := availableResources(t, cfg)
+
+	vol, _, err := cfg.Client.VolumesApi.Add(testContext(t, cfg), rest.NewVolume{
+		Name:       "vol1",
+		FlavorID:   ar.VolumeFlavors[0].ID,
+		LocationID: ar.Locations[0].ID,
+		Capacity:   10,
+	}, nil)
+	assert.Nil(t, err)
+	cfg.InvalidateAvailableResources()
+
+	raw := hostRawConfig(map[string]interface{}{
+		hNetworks:           []interface{}{fakeportal.PublicNetwork, fakeportal.StorageNetwork},
+		hNetForDefaultRoute: fakeportal.StorageNetwork,
+		hNetUntagged:        fakeportal.PublicNetwork,
+		hVolumeAttachments:  []interface{}{vol.Name},
+		hUserData:           "#cloud-config\n",
+		hDescription:        "imported",
+		hLabels:             map[string]interface{}{"team": "metal"},
+	})
+
+	d := schema.TestResourceDataRaw(t, hostSchema(), raw)
+	assert.Nil(t, resourceMetalHostCreate(context.Background(), d, meta))
+
+	imported := schema.TestResourceDataRaw(t, hostSchema(), map[string]interface{}{})
+	imported.SetId("host1")
+
+	rds, err := importHost(context.Background(), imported, meta)
+	if !assert.NoError(t, err) || !assert.Len(t, rds, 1) {
+		return
+	}
+
+	assert.Equal(t, d.Id(), rds[0].Id())
+	assert.Nil(t, resourceMetalHostRead(context.Background(), rds[0], meta))
+
+	diff, err := HostResource().Diff(context.Background(), rds[0].State(), terraform.NewResourceConfigRaw(raw), meta)
+	assert.NoError(t, err)
+	assert.True(t, diff == nil || diff.Empty(), "unexpected diff %v", diff)
+
+	// A host created without network_route has the default route on its first network.
+	delete(raw, hNetForDefaultRoute)
+	raw[hNetworks] = []interface{}{fakeportal.StorageNetwork, fakeportal.PublicNetwork}
+	assert.Nil(t, rds[0].Set(hNetworks, raw[hNetworks]))
+	assert.Nil(t, rds[0].Set(hNetworkIDs, []string{rds[0].Get(hNetForDefaultRouteID).(string), "other"}))
+
+	diff, err = HostResource().Diff(context.Background(), rds[0].State(), terraform.NewResourceConfigRaw(raw), meta)
+	assert.NoError(t, err)
+	assert.True(t, diff == nil || diff.Empty(), "unexpected diff %v", diff)
+}
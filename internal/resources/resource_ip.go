@@ -1,10 +1,12 @@
-// (C) Copyright 2021-2022 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2021-2022, 2026 Hewlett Packard Enterprise Development LP
 
 package resources
 
 import (
+	"context"
 	"strings"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
@@ -42,9 +44,9 @@ func ipSchema() map[string]*schema.Schema {
 
 func IPResource() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceIPCreate,
-		Read:   resourceIPRead,
-		Delete: resourceIPDelete,
+		CreateContext: resourceIPCreate,
+		ReadContext:   resourceIPRead,
+		DeleteContext: resourceIPDelete,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -53,12 +55,12 @@ func IPResource() *schema.Resource {
 	}
 }
 
-func resourceIPCreate(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to create IP resources")
+func resourceIPCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to create IP resources")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	poolID := d.Get(ipPoolID).(string)
@@ -69,11 +71,14 @@ func resourceIPCreate(d *schema.ResourceData, meta interface{}) (err error) {
 		Usage: safeString(d.Get(ipUsage)),
 	}
 
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 
 	ipPools, _, err := p.Client.IppoolsApi.List(ctx, nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	for _, ipPool := range ipPools {
@@ -84,29 +89,36 @@ func resourceIPCreate(d *schema.ResourceData, meta interface{}) (err error) {
 	}
 
 	if _, _, err := p.Client.IppoolsApi.AllocateIPs(ctx, poolID, []rest.IpAllocation{allocation}, nil); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	d.SetId(createIPResourceID(poolID, ip))
 
-	return resourceIPRead(d, meta)
+	return resourceIPRead(ctx, d, meta)
 }
 
-func resourceIPRead(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to read IP resources")
+func resourceIPRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to read IP resources")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 	poolID := extractIPPoolID(d.Id())
 	allocIP := extractIP(d.Id())
 
-	ippool, _, err := p.Client.IppoolsApi.GetByID(ctx, poolID, nil)
+	ippool, resp, err := p.Client.IppoolsApi.GetByID(ctx, poolID, nil)
+	if isNotFound(resp) {
+		return resourceGone(d, "IP allocation")
+	}
+
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	var usage, ip string
@@ -118,35 +130,43 @@ func resourceIPRead(d *schema.ResourceData, meta interface{}) (err error) {
 		}
 	}
 
+	// The IP address has been returned to the pool.
+	if ip == "" {
+		return resourceGone(d, "IP allocation")
+	}
+
 	if err = d.Set(ipPoolID, ippool.ID); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	if err = d.Set(address, ip); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	if err = d.Set(ipUsage, usage); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	return nil
 }
 
-func resourceIPDelete(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to delete IP resources")
+func resourceIPDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to delete IP resources")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 	poolID := extractIPPoolID(d.Id())
 	ip := extractIP(d.Id())
 
 	if _, _, err = p.Client.IppoolsApi.ReturnIPs(ctx, poolID, []string{ip}, nil); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	return nil
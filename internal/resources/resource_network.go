@@ -1,15 +1,18 @@
-// (C) Copyright 2020-2023, 2025 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2023, 2025-2026 Hewlett Packard Enterprise Development LP
 
 package resources
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
 	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/configuration"
 )
 
 const (
@@ -201,41 +204,39 @@ func networkSchema() map[string]*schema.Schema {
 
 func ProjectNetworkResource() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceMetalNetworkCreate,
-		Read:   resourceMetalNetworkRead,
-		Delete: resourceMetalNetworkDelete,
-		Update: resourceMetalNetworkUpdate,
-		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
-		},
-		Schema:      networkSchema(),
-		Description: "Provides Network resource. This allows creation, deletion and update of Metal networks.",
+		CreateContext: resourceMetalNetworkCreate,
+		ReadContext:   resourceMetalNetworkRead,
+		DeleteContext: resourceMetalNetworkDelete,
+		UpdateContext: resourceMetalNetworkUpdate,
+		Importer:      importByName("network", listNetworksForImport),
+		Schema:        networkSchema(),
+		Description:   "Provides Network resource. This allows creation, deletion and update of Metal networks.",
 	}
 }
 
-func resourceMetalNetworkCreate(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to create network resources")
+func resourceMetalNetworkCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to create network resources")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	locationID, err := p.GetLocationID(safeString(d.Get(nLocation)))
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	var ippool *rest.NewIpPool
 
 	noIPPool, ok := d.Get(nNoIPPool).(bool)
 	if !ok {
-		return fmt.Errorf("%v is expected to be a bool", nNoIPPool)
+		return diag.Errorf("%v is expected to be a bool", nNoIPPool)
 	}
 
 	if set, ok := d.Get(nIPPool).(*schema.Set); ok && len(set.List()) != 0 {
 		if noIPPool {
-			return fmt.Errorf("IPPool should be blank if NoIPPool is true")
+			return diag.Errorf("IPPool should be blank if NoIPPool is true")
 		}
 
 		ippool = getIPPool(set)
@@ -259,23 +260,24 @@ func resourceMetalNetworkCreate(d *schema.ResourceData, meta interface{}) (err e
 		newNetwork.Purpose = rest.NetworkPurpose(purpose)
 	}
 
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 	n, _, err := p.Client.NetworksApi.Add(ctx, newNetwork, nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	d.SetId(n.ID)
 
 	if err = d.Set(nIPPoolID, n.IPPoolID); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
-	if err = p.RefreshAvailableResources(); err != nil {
-		return err
-	}
+	p.InvalidateAvailableResources(configuration.KindNetworks)
 
-	return resourceMetalNetworkRead(d, meta)
+	return resourceMetalNetworkRead(ctx, d, meta)
 }
 
 func getIPPool(set *schema.Set) (ipPool *rest.NewIpPool) {
@@ -332,81 +334,92 @@ func getIPPool(set *schema.Set) (ipPool *rest.NewIpPool) {
 	return
 }
 
-func resourceMetalNetworkRead(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to read network")
+func resourceMetalNetworkRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to read network")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
+	}
+
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
+	n, resp, err := p.Client.NetworksApi.GetByID(ctx, d.Id(), nil)
+	if isNotFound(resp) {
+		return resourceGone(d, "network")
 	}
 
-	ctx := p.GetContext()
-	n, _, err := p.Client.NetworksApi.GetByID(ctx, d.Id(), nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	if err = d.Set(nName, n.Name); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	if err = d.Set(nDescription, n.Description); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	if err = d.Set(nLocationID, n.LocationID); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 	// Attempt best-effort to convert the locationID into huma readbale form. Not fatal
 	// if we can't
-	l, _ := p.GetLocationName(n.LocationID)
+	l, err := p.GetLocationName(n.LocationID)
+	if err != nil {
+		diags = append(diags, warning("location of network %s not resolved: %v", n.Name, err))
+	}
 
 	if err = d.Set(nLocation, l); err != nil {
-		return err
+		return append(diags, diagFromErr(err)...)
 	}
 
 	if err = d.Set(nHostUse, n.HostUse); err != nil {
-		return err
+		return append(diags, diagFromErr(err)...)
 	}
 
 	if err = d.Set(nPurpose, n.Purpose); err != nil {
-		//nolint:wrapcheck // defer func is wrapping the error.
-		return err
+		return append(diags, diagFromErr(err)...)
 	}
 
 	if err = d.Set(nIPPoolID, n.IPPoolID); err != nil {
-		return err
+		return append(diags, diagFromErr(err)...)
 	}
 
 	if err = d.Set(nNoIPPool, n.NoIPPool); err != nil {
-		//nolint:wrapcheck // defer func is wrapping the error.
-		return err
+		return append(diags, diagFromErr(err)...)
 	}
 
 	if err = d.Set(nVLAN, n.VLAN); err != nil {
-		return err
+		return append(diags, diagFromErr(err)...)
 	}
 
 	if err = d.Set(nVNI, n.VNI); err != nil {
-		return err
+		return append(diags, diagFromErr(err)...)
 	}
 
-	return nil
+	return diags
 }
 
-func resourceMetalNetworkUpdate(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to update network")
+func resourceMetalNetworkUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to update network")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 
 	n, _, err := p.Client.NetworksApi.GetByID(ctx, d.Id(), nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	updateNetwork := rest.UpdateNetwork{
@@ -427,29 +440,33 @@ func resourceMetalNetworkUpdate(d *schema.ResourceData, meta interface{}) (err e
 
 	_, _, err = p.Client.NetworksApi.Update(ctx, updateNetwork.ID, updateNetwork, nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
-	return resourceMetalNetworkRead(d, meta)
+	return resourceMetalNetworkRead(ctx, d, meta)
 }
 
 //nolint: dupl   // Ignoring issues in the existing code
-func resourceMetalNetworkDelete(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to delete network")
+func resourceMetalNetworkDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to delete network")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 	_, err = p.Client.NetworksApi.Delete(ctx, d.Id(), nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 	d.SetId("")
+	p.InvalidateAvailableResources(configuration.KindNetworks)
 
-	return p.RefreshAvailableResources()
+	return nil
 }
 
 // getSupportedNetworkPurpose returns a string containing supported network purpose values.
@@ -1,4 +1,4 @@
-// (C) Copyright 2020-2025 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2026 Hewlett Packard Enterprise Development LP
 
 package resources
 
@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
@@ -186,24 +187,24 @@ func projectSchema() map[string]*schema.Schema {
 
 func ProjectResource() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceMetalProjectCreate,
-		Read:   resourceMetalProjectRead,
-		Delete: resourceMetalProjectDelete,
-		Update: resourceMetalProjectUpdate,
+		CreateContext: resourceMetalProjectCreate,
+		ReadContext:   resourceMetalProjectRead,
+		DeleteContext: resourceMetalProjectDelete,
+		UpdateContext: resourceMetalProjectUpdate,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: schema.ImportStatePassthroughContext,
 		},
 		Schema:      projectSchema(),
 		Description: "Provides Project resource. This allows creation, deletion and update of Metal projects.",
 	}
 }
 
-func resourceMetalProjectCreate(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to create project")
+func resourceMetalProjectCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to create project")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	np := rest.NewProject{
@@ -215,18 +216,18 @@ func resourceMetalProjectCreate(d *schema.ResourceData, meta interface{}) (err e
 
 	if list, ok := d.Get(pProfile).([]interface{}); ok && len(list) == 1 {
 		if np.Profile, err = getProfile(list[0]); err != nil {
-			return fmt.Errorf("failed to create project %s: %v", np.Name, err)
+			return diag.Errorf("failed to create project %s: %v", np.Name, err)
 		}
 	} else {
-		return fmt.Errorf("failed to create project %s: only 1 profile block is allowed", np.Name)
+		return diag.Errorf("failed to create project %s: only 1 profile block is allowed", np.Name)
 	}
 
 	if list, ok := d.Get(pLimits).([]interface{}); ok && len(list) == 1 {
 		if np.Limits, err = getLimits(list[0]); err != nil {
-			return fmt.Errorf("failed to create project %s: %v", np.Name, err)
+			return diag.Errorf("failed to create project %s: %v", np.Name, err)
 		}
 	} else {
-		return fmt.Errorf("failed to create project %s: only 1 limit block is allowed", np.Name)
+		return diag.Errorf("failed to create project %s: only 1 limit block is allowed", np.Name)
 	}
 
 	if f, ok := d.GetOk(pSites); ok {
@@ -234,7 +235,7 @@ func resourceMetalProjectCreate(d *schema.ResourceData, meta interface{}) (err e
 		if !ok {
 			err = fmt.Errorf("sites list is not in the expected format")
 
-			return err
+			return diagFromErr(err)
 		}
 
 		np.PermittedSites = expandStringList(s.List())
@@ -245,13 +246,16 @@ func resourceMetalProjectCreate(d *schema.ResourceData, meta interface{}) (err e
 		if !ok {
 			err = fmt.Errorf("permitted images list is not in the expected format")
 
-			return err
+			return diagFromErr(err)
 		}
 
 		np.PermittedOSImages = expandStringList(s.List())
 	}
 
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 
 	// TO DO:
 	//  1. Remove 'Space' from the default header list with REST client.
@@ -260,11 +264,11 @@ func resourceMetalProjectCreate(d *schema.ResourceData, meta interface{}) (err e
 	//     configured to be sent as one of the default header with REST client.
 	project, _, err := p.Client.ProjectsApi.Add(ctx, np, nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 	d.SetId(project.ID)
 
-	return resourceMetalProjectRead(d, meta)
+	return resourceMetalProjectRead(ctx, d, meta)
 }
 
 func getUpdateProfile(profile interface{}) (p rest.UpdateProfile, err error) {
@@ -335,19 +339,26 @@ func getLimits(limits interface{}) (p rest.Limits, err error) {
 	}, nil
 }
 
-func resourceMetalProjectRead(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to read project")
+func resourceMetalProjectRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to read project")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 	ctx = context.WithValue(ctx, rest.ContextAPIKey, rest.APIKey{Key: d.Id()})
-	project, _, err := p.Client.ProjectsApi.GetByID(ctx, d.Id(), nil)
+	project, resp, err := p.Client.ProjectsApi.GetByID(ctx, d.Id(), nil)
+	if isNotFound(resp) {
+		return resourceGone(d, "project")
+	}
+
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 	d.Set(pName, project.Name)
 
@@ -364,7 +375,7 @@ func resourceMetalProjectRead(d *schema.ResourceData, meta interface{}) (err err
 	}
 
 	if err = d.Set(pProfile, []interface{}{pData}); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	lim := project.Limits
@@ -377,52 +388,55 @@ func resourceMetalProjectRead(d *schema.ResourceData, meta interface{}) (err err
 	}
 
 	if err = d.Set(pLimits, []interface{}{lData}); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	if len(project.PermittedSites) > 0 {
 		sites := flattenStringList(project.PermittedSites)
 		if err = d.Set(pSites, schema.NewSet(schema.HashString, sites)); err != nil {
-			return err // nolint:wrapcheck // defer func is wrapping the error.
+			return diagFromErr(err)
 		}
 	}
 
 	if len(project.PermittedOSImages) > 0 {
 		images := flattenStringList(project.PermittedOSImages)
 		if err = d.Set(pPermittedImages, schema.NewSet(schema.HashString, images)); err != nil {
-			return err //nolint:wrapcheck // defer func is wrapping the error.
+			return diagFromErr(err)
 		}
 	}
 
 	if err = d.Set(pVolumeReplicationEnabled, project.VolumeReplicationEnabled); err != nil {
-		return err //nolint:wrapcheck // defer func is wrapping the error.
+		return diagFromErr(err)
 	}
 
 	if err = d.Set(pBootFromSANSupport, project.BootFromSANSupport); err != nil {
-		return err //nolint:wrapcheck // defer func is wrapping the error.
+		return diagFromErr(err)
 	}
 
 	return nil
 }
 
-func resourceMetalProjectUpdate(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to update project")
+func resourceMetalProjectUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to update project")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return
+		return diagFromErr(err)
 	}
 
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 	ctx = context.WithValue(ctx, rest.ContextAPIKey, rest.APIKey{Key: d.Id()})
 	project, _, err := p.Client.ProjectsApi.GetByID(ctx, d.Id(), nil)
 	if err != nil {
-		return
+		return diagFromErr(err)
 	}
 
 	name, ok := d.Get(pName).(string)
 	if !ok {
-		return fmt.Errorf("name is not in the expected format")
+		return diag.Errorf("name is not in the expected format")
 	}
 
 	updateProject := rest.UpdateProject{
@@ -434,18 +448,18 @@ func resourceMetalProjectUpdate(d *schema.ResourceData, meta interface{}) (err e
 
 	if list, ok := d.Get(pProfile).([]interface{}); ok && len(list) == 1 {
 		if updateProject.Profile, err = getUpdateProfile(list[0]); err != nil {
-			return
+			return diagFromErr(err)
 		}
 	} else {
-		return fmt.Errorf("only 1 profile block is allowed")
+		return diag.Errorf("only 1 profile block is allowed")
 	}
 
 	if list, ok := d.Get(pLimits).([]interface{}); ok && len(list) == 1 {
 		if updateProject.Limits, err = getUpdateLimits(list[0]); err != nil {
-			return
+			return diagFromErr(err)
 		}
 	} else {
-		return fmt.Errorf("only 1 limit block is allowed")
+		return diag.Errorf("only 1 limit block is allowed")
 	}
 
 	if f, ok := d.GetOk(pSites); ok {
@@ -453,7 +467,7 @@ func resourceMetalProjectUpdate(d *schema.ResourceData, meta interface{}) (err e
 		if !ok {
 			err = fmt.Errorf("sites list is not in the expected format")
 
-			return err
+			return diagFromErr(err)
 		}
 
 		updateProject.PermittedSites = expandStringList(s.List())
@@ -464,7 +478,7 @@ func resourceMetalProjectUpdate(d *schema.ResourceData, meta interface{}) (err e
 		if !ok {
 			err = fmt.Errorf("permitted images list is not in the expected format")
 
-			return err
+			return diagFromErr(err)
 		}
 
 		updateProject.PermittedOSImages = expandStringList(s.List())
@@ -472,25 +486,28 @@ func resourceMetalProjectUpdate(d *schema.ResourceData, meta interface{}) (err e
 
 	_, _, err = p.Client.ProjectsApi.Update(ctx, updateProject.ID, updateProject, nil)
 	if err != nil {
-		return
+		return diagFromErr(err)
 	}
 
-	return resourceMetalProjectRead(d, meta)
+	return resourceMetalProjectRead(ctx, d, meta)
 }
 
-func resourceMetalProjectDelete(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to delete project")
+func resourceMetalProjectDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to delete project")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 	ctx = context.WithValue(ctx, rest.ContextAPIKey, rest.APIKey{Key: d.Id()})
 	_, err = p.Client.ProjectsApi.Delete(ctx, d.Id(), nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 	d.SetId("")
 	return nil
@@ -1,12 +1,15 @@
-// (C) Copyright 2020-2023 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2023, 2026 Hewlett Packard Enterprise Development LP
 
 package resources
 
 import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
 	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/configuration"
 )
 
 const (
@@ -30,74 +33,83 @@ func sshKeySchema() map[string]*schema.Schema {
 
 func SshKeyResource() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceMetalSSHKeyCreate,
-		Read:   resourceMetalSSHKeyRead,
-		Update: resourceMetalSSHKeyUpdate,
-		Delete: resourceMetalSSHKeyDelete,
-		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
-		},
-		Schema:      sshKeySchema(),
-		Description: "Provides SSH resource. This allows creation, deletion and update of Metal SSHKeys",
+		CreateContext: resourceMetalSSHKeyCreate,
+		ReadContext:   resourceMetalSSHKeyRead,
+		UpdateContext: resourceMetalSSHKeyUpdate,
+		DeleteContext: resourceMetalSSHKeyDelete,
+		Importer:      importByName("ssh_key", listSSHKeysForImport),
+		Schema:        sshKeySchema(),
+		Description:   "Provides SSH resource. This allows creation, deletion and update of Metal SSHKeys",
 	}
 }
 
-func resourceMetalSSHKeyCreate(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to create ssh_key")
+func resourceMetalSSHKeyCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to create ssh_key")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 	r := rest.NewSshKey{
 		Name: d.Get(sshKeyName).(string),
 		Key:  d.Get(sshPublicKey).(string),
 	}
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 	key, _, err := p.Client.SshkeysApi.Add(ctx, r, nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 	d.SetId(key.ID)
 
-	if err = p.RefreshAvailableResources(); err != nil {
-		return err
-	}
+	p.InvalidateAvailableResources(configuration.KindSSHKeys)
 
-	return resourceMetalSSHKeyRead(d, meta)
+	return resourceMetalSSHKeyRead(ctx, d, meta)
 }
 
-func resourceMetalSSHKeyRead(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to read ssh_key")
+func resourceMetalSSHKeyRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to read ssh_key")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
+	}
+
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
+	ssh, resp, err := p.Client.SshkeysApi.GetByID(ctx, d.Id(), nil)
+	if isNotFound(resp) {
+		return resourceGone(d, "ssh_key")
 	}
 
-	ctx := p.GetContext()
-	ssh, _, err := p.Client.SshkeysApi.GetByID(ctx, d.Id(), nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 	d.Set(sshKeyName, ssh.Name)
 	d.Set(sshPublicKey, ssh.Key)
 	return nil
 }
 
-func resourceMetalSSHKeyUpdate(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to update ssh_key")
+func resourceMetalSSHKeyUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to update ssh_key")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	// Read existing
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 	ssh, _, err := p.Client.SshkeysApi.GetByID(ctx, d.Id(), nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
 	updateSSH := rest.UpdateSshKey{
@@ -115,28 +127,37 @@ func resourceMetalSSHKeyUpdate(d *schema.ResourceData, meta interface{}) (err er
 	}
 
 	// Update
-	ctx = p.GetContext()
+	if ctx, err = p.ContextWithToken(ctx); err != nil {
+		return diagFromErr(err)
+	}
 	if _, _, err = p.Client.SshkeysApi.Update(ctx, updateSSH.ID, updateSSH, nil); err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
-	return resourceMetalSSHKeyRead(d, meta)
+	p.InvalidateAvailableResources(configuration.KindSSHKeys)
+
+	return resourceMetalSSHKeyRead(ctx, d, meta)
 }
 
 //nolint: dupl   // Ignoring issues in the existing code
-func resourceMetalSSHKeyDelete(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to delete ssh_key")
+func resourceMetalSSHKeyDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to delete ssh_key")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 	_, err = p.Client.SshkeysApi.Delete(ctx, d.Id(), nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 	d.SetId("")
-	return p.RefreshAvailableResources()
+	p.InvalidateAvailableResources(configuration.KindSSHKeys)
+
+	return nil
 }
@@ -0,0 +1,71 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSHKeyCRUD(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+
+	d := schema.TestResourceDataRaw(t, sshKeySchema(), map[string]interface{}{
+		sshKeyName:   "key1",
+		sshPublicKey: "ssh-rsa AAAAB3NzaC1yc2E key1@test",
+	})
+
+	assert.Nil(t, resourceMetalSSHKeyCreate(context.Background(), d, meta))
+	assert.NotEmpty(t, d.Id())
+	assert.Len(t, availableResources(t, cfg).SSHKeys, 2)
+
+	assert.Nil(t, d.Set(sshKeyName, "key2"))
+	assert.Nil(t, resourceMetalSSHKeyUpdate(context.Background(), d, meta))
+	assert.Nil(t, resourceMetalSSHKeyRead(context.Background(), d, meta))
+	assert.Equal(t, "key2", d.Get(sshKeyName))
+
+	id := d.Id()
+	assert.Nil(t, resourceMetalSSHKeyDelete(context.Background(), d, meta))
+	assert.Empty(t, d.Id())
+
+	d.SetId(id)
+	assert.NotNil(t, resourceMetalSSHKeyRead(context.Background(), d, meta))
+}
+
+func TestSSHKeyConcurrentCreate(t *testing.T) {
+	t.Parallel()
+
+	const keys = 10
+
+	_, cfg, meta := newFakePortalMeta(t)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < keys; i++ {
+		d := schema.TestResourceDataRaw(t, sshKeySchema(), map[string]interface{}{
+			sshKeyName:   fmt.Sprintf("key%d", i),
+			sshPublicKey: fmt.Sprintf("ssh-rsa AAAAB3NzaC1yc2E key%d@test", i),
+		})
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			assert.Nil(t, resourceMetalSSHKeyCreate(context.Background(), d, meta))
+			_, err := cfg.GetAvailableResources()
+			assert.Nil(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Len(t, availableResources(t, cfg).SSHKeys, keys+1)
+}
@@ -1,13 +1,14 @@
-// (C) Copyright 2020-2025 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2026 Hewlett Packard Enterprise Development LP
 
 package resources
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"strings"
-	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
@@ -64,10 +65,11 @@ func volumeSchema() map[string]*schema.Schema {
 		},
 
 		vFlavor: {
-			Type:        schema.TypeString,
-			Required:    true,
-			ForceNew:    true,
-			Description: "The flavor of the volume to be created.",
+			Type:             schema.TypeString,
+			Required:         true,
+			ForceNew:         true,
+			DiffSuppressFunc: suppressNameOrID(vFlavorID),
+			Description:      "The name or ID of the flavor of the volume to be created.",
 		},
 
 		vDescription: {
@@ -145,10 +147,11 @@ func volumeSchema() map[string]*schema.Schema {
 		},
 
 		vStoragePool: {
-			Type:        schema.TypeString,
-			Required:    false,
-			Optional:    true,
-			Description: "The storage pool of the volume to be created.",
+			Type:             schema.TypeString,
+			Required:         false,
+			Optional:         true,
+			DiffSuppressFunc: suppressNameOrID(vStoragePoolID),
+			Description:      "The name or ID of the storage pool of the volume to be created.",
 		},
 
 		vStoragePoolID: {
@@ -158,10 +161,11 @@ func volumeSchema() map[string]*schema.Schema {
 		},
 
 		vCollection: {
-			Type:        schema.TypeString,
-			Required:    false,
-			Optional:    true,
-			Description: "The volume collection of the volume to be created.",
+			Type:             schema.TypeString,
+			Required:         false,
+			Optional:         true,
+			DiffSuppressFunc: suppressNameOrID(vCollectionID),
+			Description:      "The name or ID of the volume collection of the volume to be created.",
 		},
 
 		vCollectionID: {
@@ -216,28 +220,29 @@ func volumeSchema() map[string]*schema.Schema {
 
 func VolumeResource() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceMetalVolumeCreate,
-		Read:   resourceMetalVolumeRead,
-		Update: resourceMetalVolumeUpdate,
-		Delete: resourceMetalVolumeDelete,
-		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
-		},
+		CreateContext: resourceMetalVolumeCreate,
+		ReadContext:   resourceMetalVolumeRead,
+		UpdateContext: resourceMetalVolumeUpdate,
+		DeleteContext: resourceMetalVolumeDelete,
+		Importer:      importByName("volume", listVolumesForImport),
 
 		Schema:      volumeSchema(),
 		Description: "Provides Volume resource. This allows creation, deletion and update of Metal volumes.",
 	}
 }
 
-func resourceMetalVolumeCreate(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to create volume")
+func resourceMetalVolumeCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to create volume")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 	// Need to create one
-	resources := p.AvailableResources
+	resources, err := p.GetAvailableResources(configuration.KindStorage, configuration.KindLocations)
+	if err != nil {
+		return diagFromErr(err)
+	}
 
 	var (
 		vfID, vfName string
@@ -248,7 +253,7 @@ func resourceMetalVolumeCreate(d *schema.ResourceData, meta interface{}) (err er
 		// from the volume-flavor-name.
 		for _, flavor := range resources.VolumeFlavors {
 			if vfName, ok = d.Get(vFlavor).(string); ok {
-				if flavor.Name == vfName {
+				if flavor.Name == vfName || flavor.ID == vfName {
 					vfID = flavor.ID
 					break
 				}
@@ -256,7 +261,7 @@ func resourceMetalVolumeCreate(d *schema.ResourceData, meta interface{}) (err er
 		}
 	}
 	if vfID == "" {
-		return fmt.Errorf("unable to locate a volume flavor")
+		return diag.Errorf("unable to locate a volume flavor")
 	}
 
 	// handle storage pool inputs
@@ -270,14 +275,14 @@ func resourceMetalVolumeCreate(d *schema.ResourceData, meta interface{}) (err er
 			vpID, _ = p.GetStoragePoolID(vpName)
 
 			if vpID == "" {
-				return fmt.Errorf("unable to locate storage pool")
+				return diag.Errorf("unable to locate storage pool")
 			}
 		}
 	}
 
 	capacity, ok := d.Get(vSize).(float64)
 	if !ok || capacity <= 0 {
-		return fmt.Errorf("invalid capacity %v GB", capacity)
+		return diag.Errorf("invalid capacity %v GB", capacity)
 	}
 
 	if vcID, ok = d.Get(vCollectionID).(string); !ok || vcID == "" {
@@ -286,7 +291,7 @@ func resourceMetalVolumeCreate(d *schema.ResourceData, meta interface{}) (err er
 			vcID, _ = p.GetVolumeCollectionID(vcName)
 
 			if vcID == "" {
-				return fmt.Errorf("unable to find volume collection")
+				return diag.Errorf("unable to find volume collection")
 			}
 		}
 	}
@@ -303,13 +308,13 @@ func resourceMetalVolumeCreate(d *schema.ResourceData, meta interface{}) (err er
 
 	targetLocation, ok := d.Get(vLocation).(string)
 	if !ok || targetLocation == "" {
-		return fmt.Errorf("%q must be set", vLocation)
+		return diag.Errorf("%q must be set", vLocation)
 	}
 
 	locations := []string{}
 	pieces := strings.Split(targetLocation, ":")
 	if len(pieces) != 3 {
-		return fmt.Errorf("%q must be of the form country:region:data-center", vLocation)
+		return diag.Errorf("%q must be of the form country:region:data-center", vLocation)
 	}
 
 	found := false
@@ -324,7 +329,7 @@ func resourceMetalVolumeCreate(d *schema.ResourceData, meta interface{}) (err er
 		locations = append(locations, fmt.Sprintf("%s:%s:%s", loc.Country, loc.Region, loc.DataCenter))
 	}
 	if !found {
-		return fmt.Errorf("location %q not found in %q", targetLocation, locations)
+		return diag.Errorf("location %q not found in %q", targetLocation, locations)
 	}
 
 	// add tags
@@ -332,17 +337,25 @@ func resourceMetalVolumeCreate(d *schema.ResourceData, meta interface{}) (err er
 		volume.Labels = convertMap(m)
 	}
 
-	ctx := p.GetContext()
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 	v, _, err := p.Client.VolumesApi.Add(ctx, volume, nil)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 	d.SetId(v.ID)
 	for {
-		time.Sleep(pollInterval)
+		if err = sleepContext(ctx, pollInterval); err != nil {
+			return diagFromErr(err)
+		}
 
-		ctx = p.GetContext()
-		vol, _, err := p.Client.VolumesApi.GetByID(ctx, v.ID, nil)
+		pollCtx, err := p.ContextWithToken(ctx)
+		if err != nil {
+			return diagFromErr(err)
+		}
+		vol, _, err := p.Client.VolumesApi.GetByID(pollCtx, v.ID, nil)
 		if err != nil {
 			break
 		}
@@ -351,77 +364,88 @@ func resourceMetalVolumeCreate(d *schema.ResourceData, meta interface{}) (err er
 			break
 		}
 	}
-	if err = p.RefreshAvailableResources(); err != nil {
-		return err
-	}
+	p.InvalidateAvailableResources(configuration.KindVolumes)
 
 	// Now populate additional volume fields.
-	return resourceMetalVolumeRead(d, meta)
+	return resourceMetalVolumeRead(ctx, d, meta)
 }
 
-func resourceMetalVolumeRead(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to read volume")
+func resourceMetalVolumeRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to read volume")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
-	ctx := p.GetContext()
-	volume, _, err := p.Client.VolumesApi.GetByID(ctx, d.Id(), nil)
+	ctx, err = p.ContextWithToken(ctx)
 	if err != nil {
-		return err
+		return diagFromErr(err)
+	}
+	volume, resp, err := p.Client.VolumesApi.GetByID(ctx, d.Id(), nil)
+	if isNotFound(resp) || (err == nil && volume.State == rest.VOLUMESTATE_DELETED) {
+		return resourceGone(d, "volume")
+	}
+
+	if err != nil {
+		return diagFromErr(err)
 	}
 
 	d.SetId(volume.ID)
 
 	// convert from KiB to GB
 	if err = d.Set(vSize, math.Round(float64(volume.Capacity)/KiBToGBConversion)); err != nil {
-		return fmt.Errorf("set Size: %v", err)
+		return diag.Errorf("set Size: %v", err)
 	}
 
 	if err = d.Set(vSizeInUse, math.Round(float64(volume.CapacityUsed)/KiBToGBConversion)); err != nil {
-		return fmt.Errorf("set %s : %v", vSizeInUse, err)
+		return diag.Errorf("set %s : %v", vSizeInUse, err)
 	}
 
 	if err := d.Set(vActiveSite, volume.ActiveSite); err != nil {
-		return fmt.Errorf("set %s : %v", vActiveSite, err)
+		return diag.Errorf("set %s : %v", vActiveSite, err)
 	}
 
 	if err := d.Set(vCreatedSite, volume.CreatedSite); err != nil {
-		return fmt.Errorf("set %s : %v", vCreatedSite, err)
+		return diag.Errorf("set %s : %v", vCreatedSite, err)
 	}
 
 	if err := d.Set(vUnManaged, volume.UnmanagedVolume); err != nil {
-		return fmt.Errorf("set %s : %v", vUnManaged, err)
+		return diag.Errorf("set %s : %v", vUnManaged, err)
 	}
 
 	if err := d.Set(vReplicationEnabled, volume.ReplicationEnabled); err != nil {
-		return fmt.Errorf("set %s : %v", vReplicationEnabled, err)
+		return diag.Errorf("set %s : %v", vReplicationEnabled, err)
 	}
 
 	if err = d.Set(vExportCount, volume.ExportCount); err != nil {
-		return fmt.Errorf("set %s: %v", vExportCount, err)
+		return diag.Errorf("set %s: %v", vExportCount, err)
 	}
 
 	d.Set(vName, volume.Name)
 	d.Set(vDescription, volume.Description)
-	flavorName, _ := p.GetVolumeFlavorName(volume.FlavorID)
+	flavorName, err := p.GetVolumeFlavorName(volume.FlavorID)
+	if err != nil {
+		diags = append(diags, warning("flavor of volume %s not resolved: %v", volume.Name, err))
+	}
 	d.Set(vFlavor, flavorName)
 	d.Set(vFlavorID, volume.FlavorID)
-	loc, _ := p.GetLocationName(volume.LocationID)
+	loc, err := p.GetLocationName(volume.LocationID)
+	if err != nil {
+		diags = append(diags, warning("location of volume %s not resolved: %v", volume.Name, err))
+	}
 	d.Set(vLocation, loc)
 	d.Set(vLocationID, volume.LocationID)
 
 	if err = d.Set(vShareable, volume.Shareable); err != nil {
-		return err
+		return append(diags, diagFromErr(err)...)
 	}
 
 	d.Set(vState, volume.State)
 	d.Set(vStatus, volume.Status)
 
 	if err = d.Set(vWWN, volume.WWN); err != nil {
-		return fmt.Errorf("set WWN: %v", err)
+		return append(diags, diag.Errorf("set WWN: %v", err)...)
 	}
 
 	if volume.Labels != nil {
@@ -432,44 +456,58 @@ func resourceMetalVolumeRead(d *schema.ResourceData, meta interface{}) (err erro
 		}
 
 		if err := d.Set(vLabels, tags); err != nil {
-			return fmt.Errorf("set labels: %v", err)
+			return append(diags, diag.Errorf("set labels: %v", err)...)
 		}
 	}
 
 	if err = d.Set(vStoragePoolID, volume.StoragePoolID); err != nil {
-		return fmt.Errorf("set storage pool id: %v", err)
+		return append(diags, diag.Errorf("set storage pool id: %v", err)...)
+	}
+
+	// A storage pool given by ID is written as its name, so that a switch to
+	// either shows no diff.
+	if volume.StoragePoolID != "" && safeString(d.Get(vStoragePool)) == volume.StoragePoolID {
+		if spName, err := p.GetStoragePoolName(volume.StoragePoolID); err == nil {
+			d.Set(vStoragePool, spName)
+		}
 	}
 
-	vcname, _ := p.GetVolumeCollectionName(volume.VolumeCollectionID)
+	vcname, err := p.GetVolumeCollectionName(volume.VolumeCollectionID)
+	if err != nil {
+		diags = append(diags, warning("volume collection of volume %s not resolved: %v", volume.Name, err))
+	}
 	if err = d.Set(vCollection, vcname); err != nil {
-		return fmt.Errorf("set volume collection: %v", err)
+		return append(diags, diag.Errorf("set volume collection: %v", err)...)
 	}
 
 	if err = d.Set(vCollectionID, volume.VolumeCollectionID); err != nil {
-		return fmt.Errorf("set volume collection id: %v", err)
+		return append(diags, diag.Errorf("set volume collection id: %v", err)...)
 	}
 
-	return nil
+	return diags
 }
 
-func resourceMetalVolumeUpdate(d *schema.ResourceData, meta interface{}) (err error) {
-	defer wrapResourceError(&err, "failed to update volume")
+func resourceMetalVolumeUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to update volume")
 
 	c, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return
+		return diagFromErr(err)
 	}
 
-	ctx := c.GetContext()
+	ctx, err = c.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
 
 	vol, _, err := c.Client.VolumesApi.GetByID(ctx, d.Id(), nil)
 	if err != nil {
-		return
+		return diagFromErr(err)
 	}
 
 	newSize, ok := d.Get(vSize).(float64)
 	if !ok {
-		return fmt.Errorf("size is not in the expected format")
+		return diag.Errorf("size is not in the expected format")
 	}
 
 	updateVol := rest.UpdateVolume{
@@ -488,17 +526,24 @@ func resourceMetalVolumeUpdate(d *schema.ResourceData, meta interface{}) (err er
 
 	_, _, err = c.Client.VolumesApi.Update(ctx, updateVol.ID, updateVol, nil)
 	if err != nil {
-		return
+		return diagFromErr(err)
 	}
 
 	pollCount := 0
 
 	for {
-		time.Sleep(pollInterval)
+		if err = sleepContext(ctx, pollInterval); err != nil {
+			return diagFromErr(err)
+		}
+
+		pollCtx, err := c.ContextWithToken(ctx)
+		if err != nil {
+			return diagFromErr(err)
+		}
 
-		vol, _, err := c.Client.VolumesApi.GetByID(ctx, vol.ID, nil)
+		vol, _, err := c.Client.VolumesApi.GetByID(pollCtx, updateVol.ID, nil)
 		if err != nil {
-			return fmt.Errorf("get volume %s: %w", vol.ID, err)
+			return diag.Errorf("get volume %s: %v", updateVol.ID, err)
 		}
 
 		if vol.SubState != rest.VOLUMESUBSTATE_UPDATE_REQUESTED &&
@@ -508,20 +553,21 @@ func resourceMetalVolumeUpdate(d *schema.ResourceData, meta interface{}) (err er
 
 		// Fail if volume state hasn't changed after max polls
 		if pollCount++; pollCount > pollCountMax {
-			return fmt.Errorf("waiting for volume update has timed out")
+			return diag.Errorf("waiting for volume update has timed out")
 		}
 	}
 
-	if err = c.RefreshAvailableResources(); err != nil {
-		return
-	}
+	c.InvalidateAvailableResources(configuration.KindVolumes)
 
-	return resourceMetalVolumeRead(d, meta)
+	return resourceMetalVolumeRead(ctx, d, meta)
 }
 
 // deleteVAsForVolume deletes all attachments for specified volume.
-func deleteVAsForVolume(p *configuration.Config, volID string) error {
-	ctx := p.GetContext()
+func deleteVAsForVolume(ctx context.Context, p *configuration.Config, volID string) error {
+	ctx, err := p.ContextWithToken(ctx)
+	if err != nil {
+		return err
+	}
 
 	// Get all attachments
 	vas, _, err := p.Client.VolumeAttachmentsApi.List(ctx, nil)
@@ -543,9 +589,16 @@ func deleteVAsForVolume(p *configuration.Config, volID string) error {
 	pollCount := 0
 
 	for {
-		time.Sleep(pollInterval)
+		if err = sleepContext(ctx, pollInterval); err != nil {
+			return err
+		}
 
-		volume, _, err := p.Client.VolumesApi.GetByID(ctx, volID, nil)
+		pollCtx, err := p.ContextWithToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		volume, _, err := p.Client.VolumesApi.GetByID(pollCtx, volID, nil)
 		if err != nil {
 			return fmt.Errorf("get volume %s: %w", volID, err)
 		}
@@ -563,80 +616,73 @@ func deleteVAsForVolume(p *configuration.Config, volID string) error {
 	return nil
 }
 
-//nolint:funlen    // Ignoring function length check on existing function
-func resourceMetalVolumeDelete(d *schema.ResourceData, meta interface{}) (err error) {
-	var volume rest.Volume
-
-	defer wrapResourceError(&err, "failed to delete volume")
+func resourceMetalVolumeDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to delete volume")
 
 	p, err := client.GetClientFromMetaMap(meta)
 	if err != nil {
-		return err
+		return diagFromErr(err)
 	}
 
-	defer func() {
-		// This is the last in the deferred chain to fire. If there has been no
-		// preceding error we will refresh the available resources and return
-		// any possible error that may have caused.
-		if err == nil {
-			err = p.RefreshAvailableResources()
-		}
-	}()
-
-	defer func() {
-		// Volume deletes are asynchronous in Metal svc and we can not delete terraform's
-		// reference to the volume until it has really gone from Metal svc. If we delete the
-		// reference too early, or in the presence of errors, we will never be able to retry
-		// the delete operation from Terraform (since it has no reference to the resource).
-		if err == nil {
-			// Volume deletes are async so wait here until Metal svc reports that the volume has really gone.
-			for {
-				time.Sleep(pollInterval)
-
-				ctx := p.GetContext()
-				volume, _, err = p.Client.VolumesApi.GetByID(ctx, d.Id(), nil)
-				if err != nil {
-					return
-				}
-				switch volume.State {
-				case rest.VOLUMESTATE_DELETED:
-					// Success; delete terraform reference.
-					d.SetId("")
-					return
-
-				case rest.VOLUMESTATE_FAILED:
-					// Metal svc has finished a delete attempts but failed. Retain the reference to
-					// the volume since it technically still exists so that terraform can attempt
-					// another delete at a later time.
-					err = fmt.Errorf("unable to delete volume")
-					return
-				}
-			}
-		}
-	}()
-
-	ctx := p.GetContext()
-	volume, _, err = p.Client.VolumesApi.GetByID(ctx, d.Id(), nil)
+	tokenCtx, err := p.ContextWithToken(ctx)
 	if err != nil {
-		return err
+		return diagFromErr(err)
+	}
+	volume, _, err := p.Client.VolumesApi.GetByID(tokenCtx, d.Id(), nil)
+	if err != nil {
+		return diagFromErr(err)
 	}
 
 	// Nothing to do if volume is already deleted
 	if volume.State == rest.VOLUMESTATE_DELETED {
 		d.SetId("")
+		p.InvalidateAvailableResources(configuration.KindVolumes)
+
 		return nil
 	}
 
 	// Delete attachments if volume is visible
 	if volume.State == rest.VOLUMESTATE_VISIBLE {
-		err = deleteVAsForVolume(p, d.Id())
+		err = deleteVAsForVolume(ctx, p, d.Id())
 		if err != nil {
-			return err
+			return diagFromErr(err)
 		}
 	}
 
-	_, err = p.Client.VolumesApi.Delete(ctx, d.Id(), nil)
-	return err
+	if _, err = p.Client.VolumesApi.Delete(tokenCtx, d.Id(), nil); err != nil {
+		return diagFromErr(err)
+	}
+
+	// Volume deletes are asynchronous in Metal svc and we can not delete terraform's
+	// reference to the volume until it has really gone from Metal svc. If we delete the
+	// reference too early, or in the presence of errors, we will never be able to retry
+	// the delete operation from Terraform (since it has no reference to the resource).
+	for {
+		if err = sleepContext(ctx, pollInterval); err != nil {
+			return diagFromErr(err)
+		}
+
+		if tokenCtx, err = p.ContextWithToken(ctx); err != nil {
+			return diagFromErr(err)
+		}
+		if volume, _, err = p.Client.VolumesApi.GetByID(tokenCtx, d.Id(), nil); err != nil {
+			return diagFromErr(err)
+		}
+		switch volume.State {
+		case rest.VOLUMESTATE_DELETED:
+			// Success; delete terraform reference.
+			d.SetId("")
+			p.InvalidateAvailableResources(configuration.KindVolumes)
+
+			return nil
+
+		case rest.VOLUMESTATE_FAILED:
+			// Metal svc has finished a delete attempts but failed. Retain the reference to
+			// the volume since it technically still exists so that terraform can attempt
+			// another delete at a later time.
+			return diag.Errorf("unable to delete volume")
+		}
+	}
 }
 
 func volumeInfoSchema() map[string]*schema.Schema {
@@ -0,0 +1,317 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/configuration"
+)
+
+// field names for a Metal volume attachment.
+const (
+	vaVolume      = "volume"
+	vaVolumeID    = "volume_id"
+	vaHost        = "host"
+	vaHostID      = "host_id"
+	vaName        = "name"
+	vaState       = "state"
+	vaTargetIQN   = "target_iqn"
+	vaDiscoveryIP = "discovery_ip"
+	vaLUN         = "lun"
+	vaProtocol    = "protocol"
+)
+
+func volumeAttachmentSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		vaVolume: {
+			Type:        schema.TypeString,
+			Required:    true,
+			ForceNew:    true,
+			Description: "The name or ID of the volume to attach.",
+		},
+		vaVolumeID: {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The ID of the attached volume.",
+		},
+		vaHost: {
+			Type:        schema.TypeString,
+			Required:    true,
+			ForceNew:    true,
+			Description: "The name or ID of the host the volume is attached to.",
+		},
+		vaHostID: {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The ID of the host the volume is attached to.",
+		},
+		vaName: {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The name of the volume attachment.",
+		},
+		vaState: {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The current state of the volume attachment.",
+		},
+		vaTargetIQN: {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "iSCSI Target IQN.",
+		},
+		vaDiscoveryIP: {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "iSCSI Discovery IP.",
+		},
+		vaLUN: {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "The LUN of the volume on the host.",
+		},
+		vaProtocol: {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The protocol used for the attachment (iscsi or fc).",
+		},
+	}
+}
+
+func VolumeAttachmentResource() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceMetalVolumeAttachmentCreate,
+		ReadContext:   resourceMetalVolumeAttachmentRead,
+		DeleteContext: resourceMetalVolumeAttachmentDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: volumeAttachmentSchema(),
+		Description: "Provides Volume Attachment resource. This allows a Metal volume to be attached to and " +
+			"detached from a host independently of the host resource.",
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(longTimeout),
+			Delete: schema.DefaultTimeout(longTimeout),
+		},
+	}
+}
+
+func resourceMetalVolumeAttachmentCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to create volume attachment")
+
+	p, err := client.GetClientFromMetaMap(meta)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	volumes, _, err := p.Client.VolumesApi.List(ctx, nil)
+	if err != nil {
+		return diag.Errorf("error reading volume information %v", err)
+	}
+
+	volume := safeString(d.Get(vaVolume))
+
+	volID, exists := volumeExists(volume, volumes)
+	if !exists {
+		return diag.Errorf("volume %q does not exist", volume)
+	}
+
+	hosts, _, err := p.Client.HostsApi.List(ctx, nil)
+	if err != nil {
+		return diag.Errorf("error reading host information %v", err)
+	}
+
+	host := safeString(d.Get(vaHost))
+
+	hostID, exists := hostExists(host, hosts)
+	if !exists {
+		return diag.Errorf("host %q does not exist", host)
+	}
+
+	va, _, err := p.Client.VolumesApi.Attach(ctx, volID, rest.VolumeAttachHostUuid{HostID: hostID}, nil)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	d.SetId(va.ID)
+
+	// The attachment changes the volumes that are available.
+	p.InvalidateAvailableResources(configuration.KindVolumes)
+
+	// volume attach is asynchronous in Metal svc. Wait until the attachment is ready.
+	createStateConf := &retry.StateChangeConf{
+		Pending: []string{
+			string(rest.VASTATEENUM_NEW),
+			string(rest.VASTATEENUM_EXPORTING),
+			string(rest.VASTATEENUM_ATTACHING),
+		},
+		Target: []string{
+			string(rest.VASTATEENUM_READY),
+		},
+		Refresh: func() (interface{}, string, error) {
+			va, _, err := p.Client.VolumeAttachmentsApi.GetByID(ctx, d.Id(), nil)
+			if err != nil {
+				return nil, "", fmt.Errorf("get volume attachment %v: %w", d.Id(), err)
+			}
+
+			return va, string(va.State), nil
+		},
+		Timeout:    d.Timeout(schema.TimeoutCreate),
+		Delay:      attachmentWaitDelay,
+		MinTimeout: attachmentPollInterval,
+	}
+
+	if _, err = createStateConf.WaitForStateContext(ctx); err != nil {
+		return diag.Errorf("waiting for volume attachment (%s) to be ready: %s", d.Id(), err)
+	}
+
+	return resourceMetalVolumeAttachmentRead(ctx, d, meta)
+}
+
+func resourceMetalVolumeAttachmentRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to read volume attachment")
+
+	p, err := client.GetClientFromMetaMap(meta)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	va, resp, err := p.Client.VolumeAttachmentsApi.GetByID(ctx, d.Id(), nil)
+	if isNotFound(resp) || (err == nil && va.State == rest.VASTATEENUM_DELETED) {
+		return resourceGone(d, "volume attachment")
+	}
+
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	// Only fill in the user-facing references when they are unset, e.g. after an import,
+	// so that whichever form (name or ID) was configured is retained.
+	if safeString(d.Get(vaVolume)) == "" {
+		d.Set(vaVolume, va.VolumeID)
+	}
+
+	if safeString(d.Get(vaHost)) == "" {
+		d.Set(vaHost, va.HostID)
+	}
+
+	d.Set(vaVolumeID, va.VolumeID)
+	d.Set(vaHostID, va.HostID)
+	d.Set(vaName, va.Name)
+	d.Set(vaState, va.State)
+	d.Set(vaTargetIQN, va.VolumeTargetIQN)
+	d.Set(vaDiscoveryIP, va.VolumeTargetIPAddress)
+	d.Set(vaProtocol, va.AttachProtocol)
+
+	if err = d.Set(vaLUN, int(va.LUN)); err != nil {
+		return diag.Errorf("set LUN: %v", err)
+	}
+
+	return nil
+}
+
+func resourceMetalVolumeAttachmentDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) (diags diag.Diagnostics) {
+	defer wrapResourceDiags(&diags, "failed to delete volume attachment")
+
+	p, err := client.GetClientFromMetaMap(meta)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	defer func() {
+		// This is the last in the deferred chain to fire. If there has been no
+		// preceding error the available volumes have changed.
+		if !diags.HasError() {
+			p.InvalidateAvailableResources(configuration.KindVolumes)
+		}
+	}()
+
+	ctx, err = p.ContextWithToken(ctx)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	va, resp, err := p.Client.VolumeAttachmentsApi.GetByID(ctx, d.Id(), nil)
+	if isNotFound(resp) || (err == nil && va.State == rest.VASTATEENUM_DELETED) {
+		return resourceGone(d, "volume attachment")
+	}
+
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	if _, err = p.Client.VolumesApi.Detach(ctx, va.VolumeID, rest.VolumeAttachHostUuid{HostID: va.HostID}, nil); err != nil {
+		return diagFromErr(err)
+	}
+
+	// volume detach is asynchronous in Metal svc. Wait until the attachment has gone
+	// so that the volume can be safely deleted or attached elsewhere.
+	deleteStateConf := &retry.StateChangeConf{
+		Pending: []string{
+			string(rest.VASTATEENUM_READY),
+			string(rest.VASTATEENUM_DETACHING),
+			string(rest.VASTATEENUM_UNEXPORTING),
+		},
+		Target: []string{
+			string(rest.VASTATEENUM_DELETED),
+		},
+		Refresh: func() (interface{}, string, error) {
+			va, resp, err := p.Client.VolumeAttachmentsApi.GetByID(ctx, d.Id(), nil)
+			if isNotFound(resp) {
+				// The attachment record has been removed altogether.
+				return va, string(rest.VASTATEENUM_DELETED), nil
+			}
+
+			if err != nil {
+				return nil, "", fmt.Errorf("get volume attachment %v: %w", d.Id(), err)
+			}
+
+			return va, string(va.State), nil
+		},
+		Timeout:    d.Timeout(schema.TimeoutDelete),
+		Delay:      attachmentWaitDelay,
+		MinTimeout: attachmentPollInterval,
+	}
+
+	if _, err = deleteStateConf.WaitForStateContext(ctx); err != nil {
+		return diag.Errorf("waiting for volume attachment (%s) to be deleted: %s", d.Id(), err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// hostExists returns true & the host ID, if the input matches
+// either the ID or the name from existing hosts.
+func hostExists(hID string, hosts []rest.Host) (string, bool) {
+	for _, host := range hosts {
+		if host.State == rest.HOSTSTATE_DELETED {
+			continue
+		}
+
+		if hID == host.ID || hID == host.Name {
+			return host.ID, true
+		}
+	}
+
+	return "", false
+}
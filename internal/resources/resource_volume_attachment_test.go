@@ -0,0 +1,67 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+)
+
+//nolint:bodyclose // Response body is closed by metal client.
+func TestVolumeAttachmentCRUD(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+	ctx := testContext(t, cfg)
+	ar := availableResources(t, cfg)
+
+	vol, _, err := cfg.Client.VolumesApi.Add(ctx, rest.NewVolume{
+		Name:       "vol1",
+		FlavorID:   ar.VolumeFlavors[0].ID,
+		Capacity:   10,
+		LocationID: ar.Locations[0].ID,
+	}, nil)
+	assert.Nil(t, err)
+
+	host, _, err := cfg.Client.HostsApi.Add(ctx, rest.NewHost{
+		Name:          "host1",
+		ServiceID:     ar.Images[0].ID,
+		LocationID:    ar.Locations[0].ID,
+		MachineSizeID: ar.MachineSizes[0].ID,
+		NetworkIDs:    []string{ar.Networks[0].ID},
+	}, nil)
+	assert.Nil(t, err)
+
+	d := schema.TestResourceDataRaw(t, volumeAttachmentSchema(), map[string]interface{}{
+		vaVolume: vol.Name,
+		vaHost:   host.Name,
+	})
+
+	assert.Nil(t, resourceMetalVolumeAttachmentCreate(context.Background(), d, meta))
+	assert.NotEmpty(t, d.Id())
+	assert.Equal(t, vol.ID, d.Get(vaVolumeID))
+	assert.Equal(t, host.ID, d.Get(vaHostID))
+	assert.Equal(t, string(rest.VASTATEENUM_READY), d.Get(vaState))
+	assert.NotEmpty(t, d.Get(vaTargetIQN))
+
+	id := d.Id()
+
+	assert.Nil(t, resourceMetalVolumeAttachmentDelete(context.Background(), d, meta))
+	assert.Empty(t, d.Id())
+
+	// An attachment that has already gone is removed from the state.
+	d.SetId(id)
+
+	diags := resourceMetalVolumeAttachmentDelete(context.Background(), d, meta)
+	assert.False(t, diags.HasError(), "unexpected diags %v", diags)
+	assert.Empty(t, d.Id())
+
+	vol, _, err = cfg.Client.VolumesApi.GetByID(ctx, vol.ID, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, rest.VOLUMESTATE_ALLOCATED, vol.State)
+}
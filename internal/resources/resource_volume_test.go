@@ -0,0 +1,142 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/internal/test-utils/fakeportal"
+)
+
+func TestVolumeCRUD(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+
+	d := schema.TestResourceDataRaw(t, volumeSchema(), map[string]interface{}{
+		vName:     "vol1",
+		vSize:     10,
+		vFlavor:   fakeportal.VolumeFlavor,
+		vLocation: fakeportal.Location,
+	})
+
+	assert.Nil(t, resourceMetalVolumeCreate(context.Background(), d, meta))
+	assert.NotEmpty(t, d.Id())
+	assert.Equal(t, string(rest.VOLUMESTATE_ALLOCATED), d.Get(vState))
+	assert.Equal(t, fakeportal.VolumeFlavor, d.Get(vFlavor))
+	assert.Len(t, availableResources(t, cfg).Volumes, 1)
+
+	assert.Nil(t, resourceMetalVolumeDelete(context.Background(), d, meta))
+	assert.Empty(t, d.Id())
+	assert.Empty(t, availableResources(t, cfg).Volumes)
+}
+
+func TestVolumeCreateCancelled(t *testing.T) {
+	t.Parallel()
+
+	_, _, meta := newFakePortalMeta(t)
+
+	d := schema.TestResourceDataRaw(t, volumeSchema(), map[string]interface{}{
+		vName:     "vol1",
+		vSize:     10,
+		vFlavor:   fakeportal.VolumeFlavor,
+		vLocation: fakeportal.Location,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), pollInterval/10)
+	defer cancel()
+
+	start := time.Now()
+	diags := resourceMetalVolumeCreate(ctx, d, meta)
+
+	assert.True(t, diags.HasError())
+	assert.Contains(t, diags[0].Summary, context.DeadlineExceeded.Error())
+	assert.Less(t, time.Since(start), pollInterval)
+}
+
+func TestVolumeDiffSuppressIDs(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+	ar := availableResources(t, cfg)
+
+	raw := map[string]interface{}{
+		vName:        "vol1",
+		vSize:        10,
+		vFlavor:      fakeportal.VolumeFlavor,
+		vLocation:    fakeportal.Location,
+		vStoragePool: fakeportal.StoragePool,
+		vCollection:  fakeportal.Collection,
+	}
+
+	d := schema.TestResourceDataRaw(t, volumeSchema(), raw)
+	d.SetId("vol1-id")
+	assert.Nil(t, d.Set(vFlavorID, ar.VolumeFlavors[0].ID))
+	assert.Nil(t, d.Set(vStoragePoolID, ar.StoragePools[0].ID))
+	assert.Nil(t, d.Set(vCollectionID, ar.VolumeCollections[0].ID))
+
+	raw[vFlavor] = ar.VolumeFlavors[0].ID
+	raw[vStoragePool] = ar.StoragePools[0].ID
+	raw[vCollection] = ar.VolumeCollections[0].ID
+
+	diff, err := VolumeResource().Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(raw), meta)
+	assert.NoError(t, err)
+
+	if diff != nil {
+		assert.False(t, diff.RequiresNew())
+
+		for _, key := range []string{vFlavor, vStoragePool, vCollection} {
+			assert.NotContains(t, diff.Attributes, key)
+		}
+	}
+}
+
+func TestVolumeReadStoragePoolID(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+	ar := availableResources(t, cfg)
+	poolID := ar.StoragePools[0].ID
+
+	vol, _, err := cfg.Client.VolumesApi.Add(testContext(t, cfg), rest.NewVolume{
+		Name:          "vol1",
+		FlavorID:      ar.VolumeFlavors[0].ID,
+		LocationID:    ar.Locations[0].ID,
+		StoragePoolID: poolID,
+		Capacity:      10,
+	}, nil)
+	assert.Nil(t, err)
+
+	raw := map[string]interface{}{
+		vName:        "vol1",
+		vSize:        10,
+		vFlavor:      fakeportal.VolumeFlavor,
+		vLocation:    fakeportal.Location,
+		vStoragePool: poolID,
+	}
+
+	// A storage pool given by ID is read back as its name.
+	d := schema.TestResourceDataRaw(t, volumeSchema(), raw)
+	d.SetId(vol.ID)
+	assert.Nil(t, resourceMetalVolumeRead(context.Background(), d, meta))
+	assert.Equal(t, fakeportal.StoragePool, d.Get(vStoragePool))
+
+	// Neither the ID nor a switch to the name is a diff.
+	for _, pool := range []string{poolID, fakeportal.StoragePool} {
+		raw[vStoragePool] = pool
+
+		diff, err := VolumeResource().Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(raw), meta)
+		assert.NoError(t, err)
+
+		if diff != nil {
+			assert.NotContains(t, diff.Attributes, vStoragePool)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"golang.org/x/crypto/ssh"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/configuration"
+)
+
+const (
+	skpUser       = "user"
+	skpPrivateKey = "private_key"
+	skpHost       = "host"
+	skpPort       = "port"
+	skpHostKey    = "host_key"
+	skpInsecure   = "insecure_ignore_host_key"
+
+	skpDefaultUser = "root"
+	skpDefaultPort = 22
+
+	// The commands that read and replace the authorized keys of the user on the
+	// host. Only a missing file is read as empty, so that keys aren't lost.
+	readAuthorizedKeysCmd  = "[ -e ~/.ssh/authorized_keys ] || exit 0; cat ~/.ssh/authorized_keys"
+	writeAuthorizedKeysCmd = "umask 077 && mkdir -p ~/.ssh && cat > ~/.ssh/authorized_keys.new && " +
+		"mv -f ~/.ssh/authorized_keys.new ~/.ssh/authorized_keys"
+)
+
+// sshKeyPushSchema is the schema of the ssh_key_push block of a host.
+func sshKeyPushSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		MaxItems: 1,
+		Description: "How to log in to the host to push the keys of ssh to it when they change, so that they change " +
+			"without the host being replaced. Once keys have been pushed, ssh and ssh_ids are no longer read back " +
+			"from the portal, so keys changed outside of Terraform don't show as changes to them.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				skpUser: {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     skpDefaultUser,
+					Description: "The user whose authorized keys are updated. The default is root.",
+				},
+				skpPrivateKey: {
+					Type:        schema.TypeString,
+					Required:    true,
+					Sensitive:   true,
+					Description: "The private key, in PEM format, of one of the keys that are on the host.",
+				},
+				skpHost: {
+					Type:     schema.TypeString,
+					Optional: true,
+					Description: "The address to log in to. The default is the IP address of the host on the network " +
+						"for the default route, or on the untagged network, or else on its first network.",
+				},
+				skpPort: {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      skpDefaultPort,
+					ValidateFunc: validation.IsPortNumber,
+					Description:  "The SSH port of the host. The default is 22.",
+				},
+				skpHostKey: {
+					Type:     schema.TypeString,
+					Optional: true,
+					Description: "The public key of the host, in authorized_keys format, that it must present. It is " +
+						"needed unless insecure_ignore_host_key is set.",
+				},
+				skpInsecure: {
+					Type:     schema.TypeBool,
+					Optional: true,
+					Default:  false,
+					Description: "Set to true to accept any host key when host_key isn't set. The keys are then pushed " +
+						"to whatever answers at the address, and a warning says so.",
+				},
+			},
+		},
+	}
+}
+
+// sshKeyPush is how to log in to a host to push its SSH keys.
+type sshKeyPush struct {
+	user       string
+	privateKey string
+	host       string
+	port       int
+	hostKey    string
+	insecure   bool
+}
+
+// sshKeyPushSettings returns the ssh_key_push block of a host, and whether it
+// is set.
+func sshKeyPushSettings(get func(string) interface{}) (sshKeyPush, bool) {
+	blocks, _ := get(hSSHKeyPush).([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return sshKeyPush{}, false
+	}
+
+	block, _ := blocks[0].(map[string]interface{})
+
+	return sshKeyPush{
+		user:       safeString(block[skpUser]),
+		privateKey: safeString(block[skpPrivateKey]),
+		host:       safeString(block[skpHost]),
+		port:       safeInt(block[skpPort]),
+		hostKey:    safeString(block[skpHostKey]),
+		insecure:   block[skpInsecure] == true,
+	}, true
+}
+
+// hostSSHAddress returns the IP address of the host to log in to: that on its
+// network for the default route, or on its untagged network, or else on its
+// first network.
+func hostSSHAddress(host rest.Host) string {
+	var untagged, first string
+
+	for _, con := range host.Connections {
+		for _, net := range con.Networks {
+			switch {
+			case net.IP == "":
+			case net.NetworkID == host.NetworkForDefaultRoute:
+				return net.IP
+			case untagged == "" && (net.Untagged || net.NetworkID == host.NetworkUntagged):
+				untagged = net.IP
+			case first == "":
+				first = net.IP
+			}
+		}
+	}
+
+	if untagged != "" {
+		return untagged
+	}
+
+	return first
+}
+
+// hostSSHKeys returns the IDs and public keys of the SSH keys of the project
+// given by name or ID. Keys that aren't found are returned in missing.
+func hostSSHKeys(keys []rest.SshKeyEntry, names []string) (ids, publicKeys, missing []string) {
+	for _, name := range names {
+		id, err := sshKeyID(keys, name)
+		if err != nil {
+			missing = append(missing, name)
+
+			continue
+		}
+
+		for _, k := range keys {
+			if k.ID == id {
+				ids = append(ids, id)
+				publicKeys = append(publicKeys, k.Key)
+
+				break
+			}
+		}
+	}
+
+	return ids, publicKeys, missing
+}
+
+// updateHostSSHKeys pushes the keys of ssh to the host in place of those that
+// it had, and sets ssh_ids to them. The portal only takes the SSH keys of a
+// host when it is created, so they are pushed over SSH as ssh_key_push says.
+// Nothing is pushed when the host has the keys already, in any order.
+func updateHostSSHKeys(ctx context.Context, d *schema.ResourceData, p *configuration.Config, host rest.Host,
+) diag.Diagnostics {
+	p.InvalidateAvailableResources(configuration.KindSSHKeys)
+
+	resources, err := p.GetAvailableResources(configuration.KindSSHKeys)
+	if err != nil {
+		return diagFromErr(err)
+	}
+
+	ids, add, missing := hostSSHKeys(resources.SSHKeys, convertStringArr(d.Get(hSSHKeys).([]interface{})))
+	if len(missing) > 0 {
+		return diag.Errorf("SSH keys %q not found", missing)
+	}
+
+	// The host has the keys in ssh_ids or, if they aren't known, those that it
+	// was created with.
+	current := convertStringArr(d.Get(hSSHKeyIDs).([]interface{}))
+	if len(current) == 0 {
+		current = host.SSHKeyIDs
+	}
+
+	if sameSSHKeys(ids, current) {
+		return nil
+	}
+
+	push, ok := sshKeyPushSettings(d.Get)
+	if !ok {
+		return diag.Errorf("%s is needed to change the SSH keys of a host", hSSHKeyPush)
+	}
+
+	// The keys that the host had are removed. Those that have since been
+	// deleted from the project can't be, as their public keys aren't known.
+	old, _ := d.GetChange(hSSHKeys)
+	_, remove, deleted := hostSSHKeys(resources.SSHKeys, convertStringArr(old.([]interface{})))
+
+	if push.host == "" {
+		if push.host = hostSSHAddress(host); push.host == "" {
+			return diag.Errorf("host %s has no IP address to push its SSH keys to", host.Name)
+		}
+	}
+
+	if err = pushAuthorizedKeys(ctx, push, remove, add); err != nil {
+		return diag.Errorf("push SSH keys to host %s: %v", host.Name, err)
+	}
+
+	var diags diag.Diagnostics
+
+	if len(deleted) > 0 {
+		diags = append(diags, warning("SSH keys %q were deleted from the project and are left on host %s",
+			deleted, host.Name))
+	}
+
+	if push.hostKey == "" {
+		diags = append(diags, warning("SSH keys pushed to host %s without checking its host key, as %s is set",
+			host.Name, skpInsecure))
+	}
+
+	return append(diags, diagFromErr(d.Set(hSSHKeyIDs, ids))...)
+}
+
+// sameSSHKeys returns whether a and b have the same SSH key IDs, in any order.
+func sameSSHKeys(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+// pushAuthorizedKeys logs in to a host and rewrites the authorized keys of the
+// user with the keys in remove taken out and those in add put in. Other keys
+// are left as they are.
+func pushAuthorizedKeys(ctx context.Context, push sshKeyPush, remove, add []string) error {
+	signer, err := ssh.ParsePrivateKey([]byte(push.privateKey))
+	if err != nil {
+		return fmt.Errorf("%s: %w", skpPrivateKey, err)
+	}
+
+	var hostKeyCallback ssh.HostKeyCallback
+
+	switch {
+	case push.hostKey != "":
+		hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(push.hostKey))
+		if err != nil {
+			return fmt.Errorf("%s: %w", skpHostKey, err)
+		}
+
+		hostKeyCallback = ssh.FixedHostKey(hostKey)
+	case push.insecure:
+		hostKeyCallback = ssh.InsecureIgnoreHostKey() //nolint:gosec // asked for with insecure_ignore_host_key
+	default:
+		return fmt.Errorf("%s is needed, or %s to accept any host key", skpHostKey, skpInsecure)
+	}
+
+	addr := net.JoinHostPort(push.host, strconv.Itoa(push.port))
+
+	conn, err := (&net.Dialer{Timeout: mediumTimeout}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User:            push.user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         mediumTimeout,
+	})
+	if err != nil {
+		conn.Close()
+
+		return err
+	}
+
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+	defer sshClient.Close()
+
+	current, err := runSSHCommand(sshClient, readAuthorizedKeysCmd, "")
+	if err != nil {
+		return fmt.Errorf("read authorized keys: %w", err)
+	}
+
+	if _, err = runSSHCommand(sshClient, writeAuthorizedKeysCmd, rotateAuthorizedKeys(current, remove, add)); err != nil {
+		return fmt.Errorf("write authorized keys: %w", err)
+	}
+
+	return nil
+}
+
+// runSSHCommand runs a command on the host with stdin as its input, and
+// returns its output.
+func runSSHCommand(c *ssh.Client, cmd, stdin string) (string, error) {
+	session, err := c.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+
+	session.Stdin = strings.NewReader(stdin)
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	if err = session.Run(cmd); err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+// rotateAuthorizedKeys returns the authorized_keys file current with the keys
+// in remove taken out and those in add that it doesn't have appended. Keys are
+// compared by their type and key data, so options and comments don't matter.
+func rotateAuthorizedKeys(current string, remove, add []string) string {
+	removed := make(map[string]bool, len(remove))
+	for _, key := range remove {
+		removed[authorizedKeyData(key)] = true
+	}
+
+	// Keys that are both removed and added stay.
+	for _, key := range add {
+		delete(removed, authorizedKeyData(key))
+	}
+
+	var buf strings.Builder
+
+	present := make(map[string]bool)
+
+	for _, line := range strings.Split(current, "\n") {
+		data := authorizedKeyData(line)
+		if strings.TrimSpace(line) == "" || removed[data] {
+			continue
+		}
+
+		present[data] = true
+
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+
+	for _, key := range add {
+		if data := authorizedKeyData(key); !present[data] {
+			present[data] = true
+
+			buf.WriteString(strings.TrimSpace(key))
+			buf.WriteString("\n")
+		}
+	}
+
+	return buf.String()
+}
+
+// authorizedKeyData returns the type and key data of a line of an
+// authorized_keys file, or the line itself if it isn't a key.
+func authorizedKeyData(line string) string {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return strings.TrimSpace(line)
+	}
+
+	return string(key.Marshal())
+}
@@ -0,0 +1,317 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/internal/test-utils/fakeportal"
+)
+
+// testSSHServer is an SSH server that runs the commands of pushAuthorizedKeys
+// against an authorized_keys file in memory.
+type testSSHServer struct {
+	addr    string
+	hostKey ssh.PublicKey
+
+	mu             sync.Mutex
+	authorizedKeys string
+}
+
+func (s *testSSHServer) keys() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.authorizedKeys
+}
+
+// newTestSSHServer starts an SSH server with the given authorized keys that
+// lets in the holder of a new client key, and returns it and the private key
+// of the client in PEM format.
+func newTestSSHServer(t *testing.T, authorizedKeys string) (*testSSHServer, string) {
+	t.Helper()
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	require.NoError(t, err)
+
+	clientPub, clientPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	clientKey, err := ssh.NewPublicKey(clientPub)
+	require.NoError(t, err)
+
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(clientKey.Marshal()) {
+				return nil, io.EOF
+			}
+
+			return nil, nil //nolint:nilnil // no permissions are needed
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	s := &testSSHServer{addr: listener.Addr().String(), hostKey: hostSigner.PublicKey(), authorizedKeys: authorizedKeys}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn, config)
+		}
+	}()
+
+	return s, string(pem.EncodeToMemory(block))
+}
+
+func (s *testSSHServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+
+	go ssh.DiscardRequests(reqs)
+
+	for newChan := range chans {
+		channel, requests, err := newChan.Accept()
+		if err != nil {
+			continue
+		}
+
+		go s.exec(channel, requests)
+	}
+}
+
+func (s *testSSHServer) exec(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+
+	for req := range requests {
+		var payload struct{ Command string }
+
+		if req.Type != "exec" || ssh.Unmarshal(req.Payload, &payload) != nil {
+			req.Reply(false, nil) //nolint:errcheck
+
+			continue
+		}
+
+		req.Reply(true, nil) //nolint:errcheck
+
+		status := uint32(0)
+
+		switch payload.Command {
+		case readAuthorizedKeysCmd:
+			io.WriteString(channel, s.keys()) //nolint:errcheck
+		case writeAuthorizedKeysCmd:
+			b, _ := io.ReadAll(channel)
+
+			s.mu.Lock()
+			s.authorizedKeys = string(b)
+			s.mu.Unlock()
+		default:
+			status = 127
+		}
+
+		channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status})) //nolint:errcheck
+
+		return
+	}
+}
+
+func TestRotateAuthorizedKeys(t *testing.T) {
+	const (
+		keyA = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFlFTN7SSEeMZgmOi6p1ZGGVhAzxzKKsK3Vt3wYo0B7M a@example.com"
+		keyB = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBE1AwlNBeGxMOuM0FeHzxyM36DflJTVWrC6z5NwaFWd b@example.com"
+		keyC = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIJP0mD2tvKajcCFsRI4n6RvjxjoPMbPkgtqAn1HP8czD c@example.com"
+	)
+
+	tests := []struct {
+		name    string
+		current string
+		remove  []string
+		add     []string
+		exp     string
+	}{
+		{"Rotate", keyA + "\n" + keyB + "\n", []string{keyA}, []string{keyC}, keyB + "\n" + keyC + "\n"},
+		{"Empty file", "", nil, []string{keyA}, keyA + "\n"},
+		{"Already there", keyA + "\n", nil, []string{keyA}, keyA + "\n"},
+		{"Kept", keyA + "\n", []string{keyA}, []string{keyA}, keyA + "\n"},
+		{
+			"Options and comments don't matter",
+			"# managed\nfrom=\"10.0.0.0/8\" " + strings.TrimSuffix(keyA, " a@example.com") + " old\n",
+			[]string{keyA},
+			nil,
+			"# managed\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.exp, rotateAuthorizedKeys(tt.current, tt.remove, tt.add))
+		})
+	}
+}
+
+func TestHostSSHAddress(t *testing.T) {
+	host := rest.Host{
+		NetworkUntagged: "net2",
+		Connections: []rest.HostConnection{{Networks: []rest.HostNetworkConnection{
+			{NetworkID: "net1", IP: "10.0.0.1"},
+			{NetworkID: "net2", IP: "10.0.1.1"},
+			{NetworkID: "net3", IP: "10.0.2.1"},
+		}}},
+	}
+
+	assert.Equal(t, "10.0.1.1", hostSSHAddress(host))
+
+	host.NetworkForDefaultRoute = "net3"
+	assert.Equal(t, "10.0.2.1", hostSSHAddress(host))
+
+	host.NetworkForDefaultRoute, host.NetworkUntagged = "", ""
+	assert.Equal(t, "10.0.0.1", hostSSHAddress(host))
+}
+
+func TestHostUpdateSSHKeys(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+
+	newKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBE1AwlNBeGxMOuM0FeHzxyM36DflJTVWrC6z5NwaFWd new@example.com"
+
+	added, _, err := cfg.Client.SshkeysApi.Add(testContext(t, cfg), rest.NewSshKey{Name: "New", Key: newKey}, nil)
+	require.NoError(t, err)
+	cfg.InvalidateAvailableResources()
+
+	oldKey := availableResources(t, cfg).SSHKeys[0].Key
+
+	raw := hostRawConfig(nil)
+
+	d := schema.TestResourceDataRaw(t, hostSchema(), raw)
+	require.Nil(t, resourceMetalHostCreate(context.Background(), d, meta))
+
+	srv, privateKey := newTestSSHServer(t, "ssh-rsa AAAA other@example.com\n"+oldKey+"\n")
+	host, portStr, err := net.SplitHostPort(srv.addr)
+	require.NoError(t, err)
+
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+
+	push := map[string]interface{}{
+		skpPrivateKey: privateKey,
+		skpHost:       host,
+		skpPort:       port,
+		skpHostKey:    string(ssh.MarshalAuthorizedKey(srv.hostKey)),
+	}
+
+	// plan returns the host planned to be updated from state to raw.
+	plan := func(state *terraform.InstanceState) *schema.ResourceData {
+		diff, err := HostResource().Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), meta)
+		require.NoError(t, err)
+		require.NotNil(t, diff)
+		assert.False(t, diff.RequiresNew(), "unexpected diff %v", diff)
+
+		u, err := schema.InternalMap(hostSchema()).Data(state, diff)
+		require.NoError(t, err)
+
+		return u
+	}
+
+	raw[hName] = "host2"
+	raw[hSSHKeys] = []interface{}{"New"}
+	raw[hSSHKeyPush] = []interface{}{push}
+
+	u := plan(d.State())
+	require.Nil(t, resourceMetalHostUpdate(context.Background(), u, meta))
+	assert.Equal(t, "ssh-rsa AAAA other@example.com\n"+newKey+"\n", srv.keys())
+	assert.Equal(t, []interface{}{added.ID}, u.Get(hSSHKeyIDs))
+
+	// The host is renamed in the portal, which still has the keys it was
+	// created with, and the keys that were pushed stay in the state.
+	assert.Nil(t, resourceMetalHostRead(context.Background(), u, meta))
+	assert.Equal(t, "host2", u.Get(hName))
+	assert.Equal(t, []interface{}{"New"}, u.Get(hSSHKeys))
+	assert.Equal(t, []interface{}{added.ID}, u.Get(hSSHKeyIDs))
+
+	portalHost, _, err := cfg.Client.HostsApi.GetByID(testContext(t, cfg), u.Id(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "host2", portalHost.Name)
+
+	// A host that presents another host key isn't trusted, and the keys that
+	// it has stay in the state.
+	raw[hSSHKeys] = []interface{}{fakeportal.SSHKey}
+	push[skpHostKey] = newKey
+
+	w := plan(u.State())
+
+	diags := resourceMetalHostUpdate(context.Background(), w, meta)
+	if assert.True(t, diags.HasError()) {
+		assert.Contains(t, diags[0].Summary, "push SSH keys to host host2")
+	}
+
+	assert.Equal(t, "ssh-rsa AAAA other@example.com\n"+newKey+"\n", srv.keys())
+	assert.Equal(t, "New", w.State().Attributes[hSSHKeys+".0"])
+
+	// Any host key is accepted when asked for, with a warning.
+	delete(push, skpHostKey)
+	push[skpInsecure] = true
+
+	x := plan(u.State())
+
+	diags = resourceMetalHostUpdate(context.Background(), x, meta)
+	if assert.False(t, diags.HasError(), "unexpected error %v", diags) && assert.NotEmpty(t, diags) {
+		assert.Contains(t, diags[0].Summary, "SSH keys pushed to host host2 without checking its host key")
+	}
+
+	assert.Equal(t, "ssh-rsa AAAA other@example.com\n"+oldKey+"\n", srv.keys())
+
+	// A key that was deleted from the project can't be removed from the host,
+	// which is said in a warning.
+	raw[hSSHKeys] = []interface{}{"New"}
+
+	y := plan(x.State())
+
+	diags = resourceMetalHostUpdate(context.Background(), y, meta)
+	require.False(t, diags.HasError(), "unexpected error %v", diags)
+
+	_, err = cfg.Client.SshkeysApi.Delete(testContext(t, cfg), added.ID, nil)
+	require.NoError(t, err)
+
+	raw[hSSHKeys] = []interface{}{fakeportal.SSHKey}
+
+	z := plan(y.State())
+
+	diags = resourceMetalHostUpdate(context.Background(), z, meta)
+	if assert.False(t, diags.HasError(), "unexpected error %v", diags) && assert.NotEmpty(t, diags) {
+		assert.Equal(t, `SSH keys ["New"] were deleted from the project and are left on host host2`, diags[0].Summary)
+	}
+
+	assert.Equal(t, "ssh-rsa AAAA other@example.com\n"+newKey+"\n"+oldKey+"\n", srv.keys())
+}
@@ -0,0 +1,356 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	ciPart        = "part"
+	ciContentType = "content_type"
+	ciContent     = "content"
+	ciFilename    = "filename"
+	ciMergeType   = "merge_type"
+
+	// user_data_encoding values.
+	userDataGzipBase64 = "gzip+base64"
+
+	// The cloud-init part content types that are accepted in cloud_init.
+	ctCloudConfig   = "text/cloud-config"
+	ctShellScript   = "text/x-shellscript"
+	ctIncludeURL    = "text/x-include-url"
+	ctCloudBoothook = "text/cloud-boothook"
+	ctPartHandler   = "text/part-handler"
+
+	// cloudInitBoundary separates the parts of the MIME multipart built from
+	// cloud_init. It is fixed so that the same parts always give the same
+	// user data.
+	cloudInitBoundary = "MIMEBOUNDARY"
+)
+
+// cloudInitSchema is the schema of the cloud_init block of a host.
+func cloudInitSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:          schema.TypeList,
+		Optional:      true,
+		ForceNew:      true,
+		MaxItems:      1,
+		ConflictsWith: []string{hUserData},
+		Description: "Cloud-init parts that are assembled into MIME multipart user data for the host, in place of " +
+			"user_data.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				ciPart: {
+					Type:        schema.TypeList,
+					Required:    true,
+					ForceNew:    true,
+					MinItems:    1,
+					Description: "The parts of the user data, in the order that cloud-init handles them.",
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							ciContentType: {
+								Type:     schema.TypeString,
+								Required: true,
+								ForceNew: true,
+								ValidateFunc: validation.StringInSlice([]string{
+									ctCloudConfig, ctShellScript, ctIncludeURL, ctCloudBoothook, ctPartHandler,
+								}, false),
+								Description: "The MIME type of the part, eg 'text/cloud-config' or 'text/x-shellscript'.",
+							},
+							ciContent: {
+								Type:        schema.TypeString,
+								Required:    true,
+								ForceNew:    true,
+								Description: "The content of the part.",
+							},
+							ciFilename: {
+								Type:        schema.TypeString,
+								Optional:    true,
+								ForceNew:    true,
+								Description: "The filename of the part, which cloud-init uses for scripts.",
+							},
+							ciMergeType: {
+								Type:        schema.TypeString,
+								Optional:    true,
+								ForceNew:    true,
+								Description: "How cloud-init merges a cloud-config part, eg 'list(append)+dict(recurse_array)'.",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// cloudInitPart is a part of the user data of a host.
+type cloudInitPart struct {
+	contentType string
+	content     string
+	filename    string
+	mergeType   string
+}
+
+// cloudInitParts returns the parts of the cloud_init block of a host.
+func cloudInitParts(get func(string) interface{}) []cloudInitPart {
+	blocks, _ := get(hCloudInit).([]interface{})
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	block, _ := blocks[0].(map[string]interface{})
+	raw, _ := block[ciPart].([]interface{})
+	parts := make([]cloudInitPart, 0, len(raw))
+
+	for _, r := range raw {
+		m, _ := r.(map[string]interface{})
+		parts = append(parts, cloudInitPart{
+			contentType: safeString(m[ciContentType]),
+			content:     safeString(m[ciContent]),
+			filename:    safeString(m[ciFilename]),
+			mergeType:   safeString(m[ciMergeType]),
+		})
+	}
+
+	return parts
+}
+
+// hostUserData returns the user data to create a host with, from its user_data
+// or cloud_init, encoded as user_data_encoding asks.
+func hostUserData(get func(string) interface{}) (string, error) {
+	data := safeString(get(hUserData))
+
+	if parts := cloudInitParts(get); len(parts) > 0 {
+		var err error
+		if data, err = buildCloudInit(parts); err != nil {
+			return "", fmt.Errorf("%s: %w", hCloudInit, err)
+		}
+	} else if err := checkUserData(data); err != nil {
+		return "", fmt.Errorf("%s: %w", hUserData, err)
+	}
+
+	return encodeUserData(data, safeString(get(hUserDataEncoding)))
+}
+
+// validateUserData is the ValidateFunc of user_data, which checks it at plan time.
+func validateUserData(v interface{}, k string) ([]string, []error) {
+	if err := checkUserData(safeString(v)); err != nil {
+		return nil, []error{fmt.Errorf("%s: %w", k, err)}
+	}
+
+	return nil, nil
+}
+
+// checkUserData checks user data by the kind that its first line declares, as
+// cloud-init does. Scripts and the like are not checked, MIME multipart has
+// each of its parts checked, and everything else must be cloud-config, which
+// the portal merges into the cloud-init of the host.
+func checkUserData(data string) error {
+	first, _, _ := strings.Cut(strings.TrimLeft(data, "\r\n"), "\n")
+	first = strings.TrimSpace(first)
+
+	switch {
+	case strings.TrimSpace(data) == "":
+		return nil
+	case strings.HasPrefix(first, "#!"), strings.HasPrefix(first, "#include"), strings.HasPrefix(first, "#cloud-boothook"),
+		strings.HasPrefix(first, "#part-handler"), strings.HasPrefix(first, "## template:"):
+		return nil
+	case strings.HasPrefix(strings.ToLower(first), "content-type:"),
+		strings.HasPrefix(strings.ToLower(first), "mime-version:"):
+		return checkMultipart(data)
+	default:
+		return checkCloudConfig(data)
+	}
+}
+
+// checkCloudConfig checks that data is YAML with a mapping of cloud-config
+// keys at the top level.
+func checkCloudConfig(data string) error {
+	var doc interface{}
+
+	if err := yaml.Unmarshal([]byte(data), &doc); err != nil {
+		return fmt.Errorf("invalid cloud-config: %w", err)
+	}
+
+	if _, ok := doc.(map[interface{}]interface{}); !ok && doc != nil {
+		return fmt.Errorf("invalid cloud-config: the top level must be a mapping of cloud-config keys, not %T", doc)
+	}
+
+	return nil
+}
+
+// checkCloudInitPart checks the content of a part of the given MIME type.
+func checkCloudInitPart(contentType, content string) error {
+	if strings.Contains(content, "--"+cloudInitBoundary) {
+		return fmt.Errorf("%s part must not contain the MIME boundary %q", contentType, cloudInitBoundary)
+	}
+
+	switch contentType {
+	case ctCloudConfig:
+		return checkCloudConfig(content)
+	case ctShellScript:
+		if !strings.HasPrefix(content, "#!") {
+			return fmt.Errorf("%s part must start with an interpreter line such as #!/bin/sh", contentType)
+		}
+	case ctIncludeURL:
+		scanner := bufio.NewScanner(strings.NewReader(content))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			if u, err := url.ParseRequestURI(line); err != nil || u.Scheme == "" || u.Host == "" {
+				return fmt.Errorf("%s part has an invalid URL %q", contentType, line)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkMultipart checks each part of MIME multipart user data.
+func checkMultipart(data string) error {
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("invalid MIME user data: %w", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("invalid MIME user data: %w", err)
+	}
+
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return checkCloudInitPart(mediaType, readAll(msg.Body))
+	}
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+
+	for i := 0; ; i++ {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("invalid MIME user data: %w", err)
+		}
+
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if err = checkCloudInitPart(contentType, readAll(part)); err != nil {
+			return fmt.Errorf("MIME part %d: %w", i, err)
+		}
+	}
+}
+
+// buildCloudInit checks the parts of cloud_init and assembles them into MIME
+// multipart user data.
+func buildCloudInit(parts []cloudInitPart) (string, error) {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\nMIME-Version: 1.0\r\n\r\n", cloudInitBoundary)
+
+	w := multipart.NewWriter(&buf)
+	if err := w.SetBoundary(cloudInitBoundary); err != nil {
+		return "", err
+	}
+
+	for i, p := range parts {
+		if err := checkCloudInitPart(p.contentType, p.content); err != nil {
+			return "", fmt.Errorf("%s %d: %w", ciPart, i, err)
+		}
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", p.contentType+`; charset="utf-8"`)
+		header.Set("MIME-Version", "1.0")
+		header.Set("Content-Transfer-Encoding", "7bit")
+
+		if p.filename != "" {
+			header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": p.filename}))
+		}
+
+		if p.mergeType != "" {
+			header.Set("X-Merge-Type", p.mergeType)
+		}
+
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return "", err
+		}
+
+		if _, err = io.WriteString(pw, p.content); err != nil {
+			return "", err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// encodeUserData encodes user data as user_data_encoding asks.
+func encodeUserData(data, encoding string) (string, error) {
+	if encoding != userDataGzipBase64 || data == "" {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(data)); err != nil {
+		return "", err
+	}
+
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// decodeUserData returns user data read back from the portal as it was before
+// it was encoded, or as it is if it isn't encoded.
+func decodeUserData(data string) (string, bool) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+	if err != nil {
+		return data, false
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return data, false
+	}
+
+	decoded, err := io.ReadAll(zr)
+	if err != nil {
+		return data, false
+	}
+
+	return string(decoded), true
+}
+
+// readAll returns what is read from r, up to any error.
+func readAll(r io.Reader) string {
+	b, _ := io.ReadAll(r)
+
+	return string(b)
+}
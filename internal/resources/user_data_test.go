@@ -0,0 +1,192 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package resources
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testCloudConfig = "#cloud-config\npackages:\n  - nginx\n"
+	testShellScript = "#!/bin/sh\necho hello\n"
+)
+
+func TestCheckUserData(t *testing.T) {
+	multipartData, err := buildCloudInit([]cloudInitPart{{contentType: ctCloudConfig, content: testCloudConfig}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		data   string
+		expErr string
+	}{
+		{"Empty", "", ""},
+		{"Cloud-config", testCloudConfig, ""},
+		{"YAML without header", "runcmd:\n  - [ls, -l]\n", ""},
+		{"Only the header", "#cloud-config\n", ""},
+		{"Script", testShellScript, ""},
+		{"Include", "#include\nhttps://example.com/cloud-config.yaml\n", ""},
+		{"Multipart", multipartData, ""},
+		{"Invalid YAML", "#cloud-config\npackages: [nginx\n", "invalid cloud-config: yaml: line 2"},
+		{"Not a mapping", "#cloud-config\n- nginx\n", "the top level must be a mapping of cloud-config keys"},
+		{
+			"Invalid multipart part",
+			strings.Replace(multipartData, "packages:\n  - nginx", "packages: [nginx", 1),
+			"MIME part 0: invalid cloud-config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkUserData(tt.data)
+			if tt.expErr == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.expErr)
+			}
+		})
+	}
+}
+
+func TestBuildCloudInit(t *testing.T) {
+	parts := []cloudInitPart{
+		{contentType: ctCloudConfig, content: testCloudConfig, mergeType: "list(append)+dict(recurse_array)"},
+		{contentType: ctShellScript, content: testShellScript, filename: "hello.sh"},
+	}
+
+	data, err := buildCloudInit(parts)
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+
+	for _, exp := range parts {
+		part, err := reader.NextPart()
+		require.NoError(t, err)
+
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		assert.Equal(t, exp.contentType, contentType)
+		assert.Equal(t, exp.filename, part.FileName())
+		assert.Equal(t, exp.mergeType, part.Header.Get("X-Merge-Type"))
+
+		content, err := io.ReadAll(part)
+		require.NoError(t, err)
+		assert.Equal(t, exp.content, string(content))
+	}
+
+	_, err = reader.NextPart()
+	assert.Equal(t, io.EOF, err)
+
+	again, err := buildCloudInit(parts)
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+
+	tests := []struct {
+		name   string
+		part   cloudInitPart
+		expErr string
+	}{
+		{"Invalid cloud-config", cloudInitPart{contentType: ctCloudConfig, content: "- a"}, "part 0: invalid cloud-config"},
+		{"Script without interpreter", cloudInitPart{contentType: ctShellScript, content: "echo"}, "must start with"},
+		{"Invalid URL", cloudInitPart{contentType: ctIncludeURL, content: "# urls\nexample.com/a\n"}, `invalid URL "example.com/a"`},
+		{"Boundary", cloudInitPart{contentType: ctCloudBoothook, content: "--" + cloudInitBoundary}, "must not contain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := buildCloudInit([]cloudInitPart{tt.part})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.expErr)
+			}
+		})
+	}
+}
+
+func TestEncodeUserData(t *testing.T) {
+	encoded, err := encodeUserData(testCloudConfig, userDataGzipBase64)
+	require.NoError(t, err)
+	assert.NotEqual(t, testCloudConfig, encoded)
+
+	decoded, ok := decodeUserData(encoded)
+	assert.True(t, ok)
+	assert.Equal(t, testCloudConfig, decoded)
+
+	plain, err := encodeUserData(testCloudConfig, "")
+	require.NoError(t, err)
+	assert.Equal(t, testCloudConfig, plain)
+
+	decoded, ok = decodeUserData(testCloudConfig)
+	assert.False(t, ok)
+	assert.Equal(t, testCloudConfig, decoded)
+}
+
+func TestValidateHostUserData(t *testing.T) {
+	raw := hostRawConfig(map[string]interface{}{
+		hUserData: "#cloud-config\npackages: [nginx\n",
+	})
+
+	diags := HostResource().Validate(terraform.NewResourceConfigRaw(raw))
+	if assert.True(t, diags.HasError()) {
+		assert.Contains(t, diags[0].Summary, "user_data: invalid cloud-config")
+	}
+
+	raw[hUserData] = testCloudConfig
+	assert.False(t, HostResource().Validate(terraform.NewResourceConfigRaw(raw)).HasError())
+}
+
+func TestHostCloudInit(t *testing.T) {
+	t.Parallel()
+
+	_, cfg, meta := newFakePortalMeta(t)
+
+	raw := hostRawConfig(map[string]interface{}{
+		hUserDataEncoding: userDataGzipBase64,
+		hCloudInit: []interface{}{map[string]interface{}{
+			ciPart: []interface{}{
+				map[string]interface{}{ciContentType: ctCloudConfig, ciContent: testCloudConfig},
+				map[string]interface{}{ciContentType: ctShellScript, ciContent: testShellScript, ciFilename: "hello.sh"},
+			},
+		}},
+	})
+
+	d := schema.TestResourceDataRaw(t, hostSchema(), raw)
+	require.Nil(t, resourceMetalHostCreate(context.Background(), d, meta))
+
+	host, _, err := cfg.Client.HostsApi.GetByID(testContext(t, cfg), d.Id(), nil)
+	require.NoError(t, err)
+
+	expected, err := buildCloudInit(cloudInitParts(d.Get))
+	require.NoError(t, err)
+
+	decoded, ok := decodeUserData(host.UserData)
+	assert.True(t, ok)
+	assert.Equal(t, expected, decoded)
+
+	assert.Nil(t, resourceMetalHostRead(context.Background(), d, meta))
+	assert.Empty(t, d.Get(hUserData))
+
+	diff, err := HostResource().Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(raw), meta)
+	assert.NoError(t, err)
+	assert.True(t, diff == nil || diff.Empty(), "unexpected diff %v", diff)
+}
@@ -1,13 +1,18 @@
-// (C) Copyright 2020-2022 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2022, 2026 Hewlett Packard Enterprise Development LP
 
 package resources
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
@@ -67,10 +72,10 @@ func getFilters(d *schema.ResourceData) (filters []filter, err error) {
 	if !ok {
 		return
 	}
-	values := []*regexp.Regexp{}
 	for _, f := range flts.List() {
 		m := f.(map[string]interface{})
 		if name, ok := m["name"].(string); ok {
+			values := []*regexp.Regexp{}
 			for _, v := range m["values"].([]interface{}) {
 				if value, ok := v.(string); ok {
 					r, err := regexp.Compile(value)
@@ -89,6 +94,18 @@ func getFilters(d *schema.ResourceData) (filters []filter, err error) {
 	return
 }
 
+// matchFilters reports whether the fields, by name, match all the filters on
+// them. Filters on other fields are ignored.
+func matchFilters(filters []filter, fields map[string]string) bool {
+	for _, f := range filters {
+		if value, ok := fields[f.name]; ok && !f.match(f.name, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func convertStringArr(a []interface{}) []string {
 	ret := make([]string, len(a))
 
@@ -149,21 +166,87 @@ func difference(a, b []string) []string {
 	return diff
 }
 
-// wrapResourceError ensures that any non-nil error is wrapped.
-func wrapResourceError(err *error, msg string) {
-	if err == nil || *err == nil {
-		return
+// intersection returns the elements in `a` that are also in `b`.
+func intersection(a, b []string) []string {
+	mb := make(map[string]struct{}, len(b))
+	for _, x := range b {
+		mb[x] = struct{}{}
+	}
+
+	common := make([]string, 0)
+
+	for _, x := range a {
+		if _, found := mb[x]; found {
+			common = append(common, x)
+		}
+	}
+
+	return common
+}
+
+// diagFromErr returns err as an error diagnostic. The message of an error
+// response from Metal is added to the summary.
+func diagFromErr(err error) diag.Diagnostics {
+	if err == nil {
+		return nil
 	}
 
 	nErr := rest.GenericOpenAPIError{}
 
-	if errors.As(*err, &nErr) {
-		*err = fmt.Errorf("%s %s: %w", msg, strings.Trim(nErr.Message(), "\n "), *err)
+	if errors.As(err, &nErr) {
+		return diag.Errorf("%s: %v", strings.Trim(nErr.Message(), "\n "), err)
+	}
 
+	return diag.FromErr(err)
+}
+
+// wrapResourceDiags ensures that the summary of any error diagnostic is wrapped.
+func wrapResourceDiags(diags *diag.Diagnostics, msg string) {
+	if diags == nil {
 		return
 	}
 
-	*err = fmt.Errorf("%s %w", msg, *err)
+	for i := range *diags {
+		if (*diags)[i].Severity == diag.Error {
+			(*diags)[i].Summary = fmt.Sprintf("%s %s", msg, (*diags)[i].Summary)
+		}
+	}
+}
+
+// isNotFound reports whether resp is the response of Metal for a resource that
+// doesn't exist, e.g. because it was deleted outside of Terraform.
+func isNotFound(resp *http.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusNotFound
+}
+
+// resourceGone removes a resource that no longer exists in Metal from the
+// state, so that it is planned to be created again.
+func resourceGone(d *schema.ResourceData, kind string) diag.Diagnostics {
+	id := d.Id()
+	d.SetId("")
+
+	return diag.Diagnostics{warning("%s %s no longer exists, removing it from the state", kind, id)}
+}
+
+// warning returns a warning diagnostic.
+func warning(format string, a ...interface{}) diag.Diagnostic {
+	return diag.Diagnostic{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf(format, a...),
+	}
+}
+
+// sleepContext waits for d, or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
 }
 
 // convertMap returns map of string key to string value.
@@ -205,3 +288,30 @@ func flattenStringList(list []string) []interface{} {
 
 	return vs
 }
+
+// suppressNameOrID suppresses the diff of an attribute that takes the name or
+// the ID of a resource, when it is set to the ID that the computed idKey
+// attribute holds for the resource named in the state. Read writes the name in
+// place of an ID in the state, so a switch from the ID to the name is no diff
+// either.
+func suppressNameOrID(idKey string) schema.SchemaDiffSuppressFunc {
+	return func(_, old, new string, d *schema.ResourceData) bool {
+		return old != "" && (new == old || new == safeString(d.Get(idKey)))
+	}
+}
+
+// suppressListNameOrID is suppressNameOrID for the elements of a list of names
+// or IDs, whose IDs are in the same order in the computed idsKey list.
+func suppressListNameOrID(idsKey string) schema.SchemaDiffSuppressFunc {
+	return func(k, old, new string, d *schema.ResourceData) bool {
+		idx, err := strconv.Atoi(k[strings.LastIndex(k, ".")+1:])
+		if err != nil || old == "" {
+			// The number of elements, or an element that is added.
+			return false
+		}
+
+		ids, _ := d.Get(idsKey).([]interface{})
+
+		return new == old || (idx < len(ids) && new == safeString(ids[idx]))
+	}
+}
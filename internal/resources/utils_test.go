@@ -1,10 +1,14 @@
-// (C) Copyright 2021-2022 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2021-2022, 2026 Hewlett Packard Enterprise Development LP
 
 package resources
 
 import (
+	"context"
+	"errors"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -42,3 +46,120 @@ func TestDifference(t *testing.T) {
 		})
 	}
 }
+
+func TestIntersection(t *testing.T) {
+	tests := []struct {
+		name   string
+		slice1 []string
+		slice2 []string
+		retval []string
+	}{
+		{
+			name:   "Test1Common",
+			slice1: []string{"aaa", "bbb", "ccc"},
+			slice2: []string{"bbb", "ddd"},
+			retval: []string{"bbb"},
+		},
+		{
+			name:   "Test2NoCommon",
+			slice1: []string{"aaa", "bbb"},
+			slice2: []string{"ccc"},
+			retval: []string{},
+		},
+		{
+			name:   "Test3Same",
+			slice1: []string{"aaa", "bbb"},
+			slice2: []string{"aaa", "bbb"},
+			retval: []string{"aaa", "bbb"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ret := intersection(tt.slice1, tt.slice2)
+			assert.Equal(t, tt.retval, ret)
+		})
+	}
+}
+
+func TestWrapResourceDiags(t *testing.T) {
+	diags := diag.Diagnostics{
+		warning("location %s not resolved", "loc1"),
+	}
+	diags = append(diags, diagFromErr(errors.New("not found"))...)
+
+	wrapResourceDiags(&diags, "failed to read host")
+
+	assert.Equal(t, diag.Warning, diags[0].Severity)
+	assert.Equal(t, "location loc1 not resolved", diags[0].Summary)
+	assert.Equal(t, diag.Error, diags[1].Severity)
+	assert.Equal(t, "failed to read host not found", diags[1].Summary)
+	assert.Nil(t, diagFromErr(nil))
+}
+
+func TestSuppressNameOrID(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, map[string]*schema.Schema{
+		"size_id": {Type: schema.TypeString, Computed: true},
+		"key_ids": {Type: schema.TypeList, Computed: true, Elem: &schema.Schema{Type: schema.TypeString}},
+	}, map[string]interface{}{})
+
+	assert.Nil(t, d.Set("size_id", "size-id"))
+	assert.Nil(t, d.Set("key_ids", []string{"key1-id", "key2-id"}))
+
+	sizeSuppress := suppressNameOrID("size_id")
+	keySuppress := suppressListNameOrID("key_ids")
+
+	tests := []struct {
+		name     string
+		suppress schema.SchemaDiffSuppressFunc
+		key      string
+		old      string
+		new      string
+		expected bool
+	}{
+		{"ID of named resource", sizeSuppress, "size", "Small", "size-id", true},
+		{"Other ID", sizeSuppress, "size", "Small", "other-id", false},
+		{"Other name", sizeSuppress, "size", "Small", "Large", false},
+		{"New resource", sizeSuppress, "size", "", "size-id", false},
+		{"ID of named list element", keySuppress, "keys.1", "key2", "key2-id", true},
+		{"ID of other list element", keySuppress, "keys.1", "key2", "key1-id", false},
+		{"Added list element", keySuppress, "keys.2", "", "key3-id", false},
+		{"Number of list elements", keySuppress, "keys.#", "2", "3", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.suppress(tt.key, tt.old, tt.new, d))
+		})
+	}
+}
+
+func TestReadGone(t *testing.T) {
+	t.Parallel()
+
+	_, _, meta := newFakePortalMeta(t)
+
+	tests := []struct {
+		name     string
+		resource *schema.Resource
+		id       string
+	}{
+		{"Host", HostResource(), "missing"},
+		{"Volume", VolumeResource(), "missing"},
+		{"Volume attachment", VolumeAttachmentResource(), "missing"},
+		{"Network", ProjectNetworkResource(), "missing"},
+		{"IP", IPResource(), "missing:10.0.0.1"},
+		{"SSH key", SshKeyResource(), "missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, tt.resource.Schema, map[string]interface{}{})
+			d.SetId(tt.id)
+
+			diags := tt.resource.ReadContext(context.Background(), d, meta)
+			assert.False(t, diags.HasError(), "unexpected error %v", diags)
+			assert.Empty(t, d.Id())
+		})
+	}
+}
@@ -0,0 +1,507 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+// Package fakeportal provides an in-process fake of the Metal portal REST API.
+// It is intended for unit tests that need to drive the provider through full
+// create/read/update/delete cycles without access to a real Metal service.
+//
+// Asynchronous behaviour of the portal is modelled by queuing state changes
+// against each object. Every GET request served advances all queued changes
+// by one step, so a resource that polls for a target state will observe the
+// intermediate states before reaching it.
+package fakeportal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/configuration"
+)
+
+// Names of the resources seeded into every fake portal.
+const (
+	Location       = "USA:Central:AFCDCC1"
+	ImageFlavor    = "ubuntu"
+	ImageVersion   = "20.04-20201102"
+	MachineSize    = "G2i"
+	SSHKey         = "User1 - Linux"
+	PublicNetwork  = "Public"
+	StorageNetwork = "Storage"
+	VolumeFlavor   = "Fast"
+	StoragePool    = "Array1"
+	Collection     = "Collection1"
+	Token          = "fake-portal-token"
+	MemberID       = "fake-portal-member"
+)
+
+const (
+	basePath         = "/rest/v1"
+	machineInventory = 10
+	storageCapacity  = 100
+)
+
+// object is an entry in one of the portal stores together with the state
+// changes that are still to be applied to it.
+type object struct {
+	value   interface{}
+	pending []func()
+}
+
+// Server is a fake Metal portal. Create one with New and release it with Close.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	nextID  int
+	stores  map[string]map[string]*object
+	order   map[string][]string
+	seeded  rest.AvailableResources
+	ipIndex map[string]int
+	// statuses returned by the next requests, before they are handled
+	failures []int
+	// messages of the next hosts to fail to provision
+	provisionFailures []string
+}
+
+// New starts a fake portal seeded with a location, an image, a machine size,
+// an SSH key, public and storage networks and a volume flavor.
+func New() *Server {
+	s := &Server{
+		stores:  make(map[string]map[string]*object),
+		order:   make(map[string][]string),
+		ipIndex: make(map[string]int),
+	}
+
+	s.seed()
+
+	mux := http.NewServeMux()
+	s.routes(mux)
+	s.Server = httptest.NewServer(s.authenticate(mux))
+
+	return s
+}
+
+// Qjwt returns Metal login details that are valid for this portal.
+func (s *Server) Qjwt() *configuration.Qjwt {
+	return &configuration.Qjwt{
+		RestURL:     s.URL,
+		OriginalURL: s.URL,
+		User:        "fake-user",
+		Token:       Token,
+		MemberID:    MemberID,
+		NoTLS:       true,
+	}
+}
+
+// Options returns the configuration create options that point a Metal
+// client at this portal. Failed requests are retried without a long wait.
+func (s *Server) Options() []configuration.CreateOpt {
+	return []configuration.CreateOpt{
+		configuration.WithQjwt(s.Qjwt()),
+		configuration.WithHTTPClient(s.Client()),
+		configuration.WithRetry(configuration.DefaultRetryMaxAttempts, 10*time.Millisecond),
+	}
+}
+
+// FailNext makes the portal reject the next requests with the given statuses,
+// one per request, without handling them.
+func (s *Server) FailNext(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, statuses...)
+}
+
+// FailProvisioning makes the next hosts that are created fail to provision,
+// one per message, with the message as their alert.
+func (s *Server) FailProvisioning(messages ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.provisionFailures = append(s.provisionFailures, messages...)
+}
+
+// AddLocation adds a location, of the form country:region:centre, with its
+// own public and storage networks and returns its ID.
+func (s *Server) AddLocation(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts := strings.SplitN(name, ":", 3)
+	for len(parts) < 3 {
+		parts = append(parts, "")
+	}
+
+	loc := rest.LocationInfo{ID: s.newID(), Country: rest.Country(parts[0]), Region: parts[1], DataCenter: parts[2]}
+	s.seeded.Locations = append(s.seeded.Locations, loc)
+	s.addLocationNetworks(loc.ID)
+
+	return loc.ID
+}
+
+// AddMachineSize adds a machine size, without any machines in inventory,
+// and returns its ID.
+func (s *Server) AddMachineSize(name string, details rest.FlavorDesc) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	size := rest.MachineSize{ID: s.newID(), Name: name, Details: details}
+	s.seeded.MachineSizes = append(s.seeded.MachineSizes, size)
+
+	return size.ID
+}
+
+// SetMachineInventory sets the number of machines of a size, by ID, that are
+// in inventory at a location, by ID, before any are used by hosts.
+func (s *Server) SetMachineInventory(locationID, sizeID string, number int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.seeded.MachineInventory {
+		if inv := &s.seeded.MachineInventory[i]; inv.LocationID == locationID && inv.SizeID == sizeID {
+			inv.Number = number
+
+			return
+		}
+	}
+
+	s.seeded.MachineInventory = append(s.seeded.MachineInventory,
+		rest.MachineInventory{LocationID: locationID, SizeID: sizeID, Number: number})
+}
+
+// NewConfig returns a Metal client configuration for this portal with the
+// available resources already cached.
+func (s *Server) NewConfig() (*configuration.Config, error) {
+	c, err := configuration.NewConfig("", s.Options()...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.RefreshAvailableResources(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (s *Server) seed() {
+	loc := rest.LocationInfo{
+		ID:         s.newID(),
+		Country:    "USA",
+		Region:     "Central",
+		DataCenter: "AFCDCC1",
+	}
+
+	size := rest.MachineSize{
+		ID:   s.newID(),
+		Name: MachineSize,
+		Details: rest.FlavorDesc{
+			Banner1: "2 x Intel Xeon Silver 4210 - 20 cores",
+			Banner2: "192GB RAM",
+			Bullets: []string{"2 x 480GB SSD", "2 x 25Gb NIC"},
+		},
+	}
+
+	flavor := rest.VolumeFlavor{ID: s.newID(), Name: VolumeFlavor}
+	pool := rest.StoragePool{ID: s.newID(), Name: StoragePool, LocationID: loc.ID, Capacity: storageCapacity}
+
+	s.seeded = rest.AvailableResources{
+		Locations:    []rest.LocationInfo{loc},
+		MachineSizes: []rest.MachineSize{size},
+		MachineInventory: []rest.MachineInventory{
+			{LocationID: loc.ID, SizeID: size.ID, Number: machineInventory},
+		},
+		VolumeFlavors: []rest.VolumeFlavor{flavor},
+		StoragePools:  []rest.StoragePool{pool},
+		VolumeCollections: []rest.VolumeCollection{
+			{ID: s.newID(), Name: Collection, LocationID: loc.ID, StoragePoolIDs: []string{pool.ID}},
+		},
+		StorageInventory: []rest.StorageInventory{
+			{FlavorID: flavor.ID, LocationID: loc.ID, Capacity: storageCapacity, StoragePoolID: pool.ID},
+		},
+	}
+
+	s.put(kindServices, &rest.OsServiceImage{
+		ID:       s.newID(),
+		Name:     fmt.Sprintf("%s-%s", ImageFlavor, ImageVersion),
+		Category: "linux",
+		Flavor:   ImageFlavor,
+		Version:  ImageVersion,
+	})
+
+	s.put(kindSSHKeys, &rest.SshKey{ID: s.newID(), Name: SSHKey, Key: "ssh-rsa AAAAB3NzaC1yc2E fake@portal"})
+
+	s.addLocationNetworks(loc.ID)
+}
+
+// addLocationNetworks adds the public and storage networks to a location.
+func (s *Server) addLocationNetworks(locationID string) {
+	for i, name := range []string{PublicNetwork, StorageNetwork} {
+		s.addNetwork(rest.NewNetwork{
+			Name:       name,
+			LocationID: locationID,
+			HostUse:    rest.NETWORKHOSTUSE_REQUIRED,
+			VLAN:       int32(100 + i),
+			NewIPPool: &rest.NewIpPool{
+				Name:      name,
+				IPVersion: rest.IPVER_I_PV4,
+				BaseIP:    fmt.Sprintf("10.0.%d.0", i),
+				Netmask:   rest.NETMASK__24,
+				Sources:   []rest.IpSource{{Base: fmt.Sprintf("10.0.%d.10", i), Count: 200}},
+			},
+		})
+	}
+}
+
+// newID returns a unique identifier in UUID format.
+func (s *Server) newID() string {
+	s.nextID++
+
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", s.nextID)
+}
+
+// put adds or replaces an object in the store for kind.
+func (s *Server) put(kind string, value interface{}, pending ...func()) {
+	id := objectID(value)
+
+	store, ok := s.stores[kind]
+	if !ok {
+		store = make(map[string]*object)
+		s.stores[kind] = store
+	}
+
+	if o, ok := store[id]; ok {
+		o.value = value
+		o.pending = append(o.pending, pending...)
+
+		return
+	}
+
+	store[id] = &object{value: value, pending: pending}
+	s.order[kind] = append(s.order[kind], id)
+}
+
+// get returns the object of the specified kind and ID.
+func (s *Server) get(kind, id string) (*object, bool) {
+	o, ok := s.stores[kind][id]
+
+	return o, ok
+}
+
+// purge removes an object from the store so that it is no longer found.
+func (s *Server) purge(kind, id string) {
+	delete(s.stores[kind], id)
+
+	ids := s.order[kind]
+	for i := range ids {
+		if ids[i] == id {
+			s.order[kind] = append(ids[:i:i], ids[i+1:]...)
+
+			break
+		}
+	}
+}
+
+// list returns the objects of kind in the order they were created.
+func (s *Server) list(kind string) []*object {
+	objects := make([]*object, 0, len(s.order[kind]))
+	for _, id := range s.order[kind] {
+		objects = append(objects, s.stores[kind][id])
+	}
+
+	return objects
+}
+
+// tick applies the next pending state change of every object.
+func (s *Server) tick() {
+	var steps []func()
+
+	for _, store := range s.stores {
+		for _, o := range store {
+			if len(o.pending) > 0 {
+				steps = append(steps, o.pending[0])
+				o.pending = o.pending[1:]
+			}
+		}
+	}
+
+	// Apply the steps once all have been collected, as a step may purge an object.
+	for _, step := range steps {
+		step()
+	}
+}
+
+func objectID(value interface{}) string {
+	switch v := value.(type) {
+	case *rest.Host:
+		return v.ID
+	case *rest.Volume:
+		return v.ID
+	case *rest.VolumeAttachment:
+		return v.ID
+	case *rest.Network:
+		return v.ID
+	case *rest.IpPool:
+		return v.ID
+	case *rest.SshKey:
+		return v.ID
+	case *rest.Project:
+		return v.ID
+	case *rest.OsServiceImage:
+		return v.ID
+	}
+
+	panic(fmt.Sprintf("fakeportal: unsupported object %T", value))
+}
+
+// availableResources builds the available resources from the seeded
+// resources and the current contents of the stores.
+func (s *Server) availableResources() rest.AvailableResources {
+	ar := s.seeded
+	ar.MachineInventory = append([]rest.MachineInventory(nil), s.seeded.MachineInventory...)
+
+	for _, o := range s.list(kindServices) {
+		svc := o.value.(*rest.OsServiceImage)
+		ar.Images = append(ar.Images, rest.AvailableImage{
+			ID:          svc.ID,
+			Category:    svc.Category,
+			Flavor:      svc.Flavor,
+			Name:        svc.Name,
+			Version:     svc.Version,
+			Description: svc.Description,
+		})
+	}
+
+	for _, o := range s.list(kindNetworks) {
+		n := o.value.(*rest.Network)
+		ar.Networks = append(ar.Networks, rest.AvailableNetwork{
+			ID:          n.ID,
+			Name:        n.Name,
+			LocationID:  n.LocationID,
+			Description: n.Description,
+			HostUse:     n.HostUse,
+			Purpose:     n.Purpose,
+			IPPoolID:    n.IPPoolID,
+			NoIPPool:    n.NoIPPool,
+			VLAN:        n.VLAN,
+			VNI:         n.VNI,
+		})
+	}
+
+	for _, o := range s.list(kindSSHKeys) {
+		k := o.value.(*rest.SshKey)
+		ar.SSHKeys = append(ar.SSHKeys, rest.SshKeyEntry{ID: k.ID, Name: k.Name, Key: k.Key})
+	}
+
+	for _, o := range s.list(kindVolumes) {
+		v := o.value.(*rest.Volume)
+		if v.State == rest.VOLUMESTATE_DELETED {
+			continue
+		}
+
+		ar.Volumes = append(ar.Volumes, rest.VolumeInfo{
+			ID:                 v.ID,
+			Name:               v.Name,
+			Description:        v.Description,
+			FlavorID:           v.FlavorID,
+			StoragePoolID:      v.StoragePoolID,
+			Capacity:           v.Capacity,
+			Shareable:          v.Shareable,
+			LocationID:         v.LocationID,
+			VolumeCollectionID: v.VolumeCollectionID,
+			State:              v.State,
+			Status:             v.Status,
+		})
+	}
+
+	// Hosts consume machine inventory until they have been deleted.
+	for _, o := range s.list(kindHosts) {
+		h := o.value.(*rest.Host)
+		if h.State == rest.HOSTSTATE_DELETED {
+			continue
+		}
+
+		for i := range ar.MachineInventory {
+			inv := &ar.MachineInventory[i]
+			if inv.LocationID == h.LocationID && inv.SizeID == h.MachineSizeID && inv.Number > 0 {
+				inv.Number--
+			}
+		}
+	}
+
+	return ar
+}
+
+// authenticate rejects requests that do not carry the portal token and membership.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+Token || r.Header.Get("Membership") != MemberID {
+			writeError(w, http.StatusUnauthorized, "invalid token or membership")
+
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if len(s.failures) > 0 {
+			status := s.failures[0]
+			s.failures = s.failures[1:]
+			writeError(w, status, "%s", http.StatusText(status))
+
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			s.tick()
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if v != nil {
+		_ = json.NewEncoder(w).Encode(v)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSON(w, status, rest.ErrorResponse{Message: fmt.Sprintf(format, args...)})
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: %v", err)
+
+		return false
+	}
+
+	return true
+}
+
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+func etag() string {
+	return fmt.Sprintf("%d", time.Now().UnixNano())
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, s) {
+			return true
+		}
+	}
+
+	return false
+}
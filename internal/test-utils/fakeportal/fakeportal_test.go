@@ -0,0 +1,329 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package fakeportal
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/configuration"
+)
+
+func newTestPortal(t *testing.T) (*Server, *configuration.Config) {
+	t.Helper()
+
+	s := New()
+	t.Cleanup(s.Close)
+
+	c, err := s.NewConfig()
+	require.NoError(t, err)
+
+	return s, c
+}
+
+func availableResources(t *testing.T, c *configuration.Config) rest.AvailableResources {
+	t.Helper()
+
+	ar, err := c.GetAvailableResources()
+	require.NoError(t, err)
+
+	return ar
+}
+
+func testContext(t *testing.T, c *configuration.Config) context.Context {
+	t.Helper()
+
+	ctx, err := c.GetContextWithError()
+	require.NoError(t, err)
+
+	return ctx
+}
+
+func TestAvailableResources(t *testing.T) {
+	_, c := newTestPortal(t)
+	ar := availableResources(t, c)
+
+	require.Len(t, ar.Locations, 1)
+	require.Len(t, ar.Images, 1)
+	assert.Equal(t, ImageFlavor, ar.Images[0].Flavor)
+	assert.Equal(t, ImageVersion, ar.Images[0].Version)
+	require.Len(t, ar.MachineSizes, 1)
+	assert.Equal(t, MachineSize, ar.MachineSizes[0].Name)
+	require.Len(t, ar.SSHKeys, 1)
+	assert.Equal(t, SSHKey, ar.SSHKeys[0].Name)
+	require.Len(t, ar.Networks, 2)
+	assert.Equal(t, PublicNetwork, ar.Networks[0].Name)
+	assert.Equal(t, StorageNetwork, ar.Networks[1].Name)
+	require.Len(t, ar.VolumeFlavors, 1)
+	assert.Equal(t, VolumeFlavor, ar.VolumeFlavors[0].Name)
+
+	locID, err := c.GetLocationID(Location)
+	require.NoError(t, err)
+	assert.Equal(t, ar.Locations[0].ID, locID)
+}
+
+func TestUnauthorized(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	q := s.Qjwt()
+	q.Token = "wrong"
+
+	c, err := configuration.NewConfig("", configuration.WithQjwt(q))
+	require.NoError(t, err)
+
+	//nolint:bodyclose // Response body is closed by metal client.
+	_, resp, err := c.Client.AvailableResourcesApi.List(testContext(t, c), nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+//nolint:bodyclose // Response body is closed by metal client.
+func TestRetries(t *testing.T) {
+	s, c := newTestPortal(t)
+	ctx := testContext(t, c)
+	ar := availableResources(t, c)
+
+	// Reads are retried until the portal recovers.
+	s.FailNext(http.StatusServiceUnavailable, http.StatusBadGateway)
+
+	_, _, err := c.Client.AvailableResourcesApi.List(ctx, nil)
+	require.NoError(t, err)
+
+	// Creates are retried when the portal is too busy to handle them.
+	s.FailNext(http.StatusTooManyRequests)
+
+	key, _, err := c.Client.SshkeysApi.Add(ctx, rest.NewSshKey{Name: "key1", Key: "ssh-rsa AAAA key1"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "key1", key.Name)
+
+	// but not when the portal may have handled them.
+	s.FailNext(http.StatusBadGateway)
+
+	_, resp, err := c.Client.SshkeysApi.Add(ctx, rest.NewSshKey{Name: "key2", Key: "ssh-rsa AAAA key2"}, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+
+	keys, _, err := c.Client.SshkeysApi.List(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, keys, len(ar.SSHKeys)+1)
+}
+
+//nolint:bodyclose // Response body is closed by metal client.
+func TestHostLifecycle(t *testing.T) {
+	_, c := newTestPortal(t)
+	ctx := testContext(t, c)
+	ar := availableResources(t, c)
+
+	h, _, err := c.Client.HostsApi.Add(ctx, rest.NewHost{
+		Name:          "host1",
+		ServiceID:     ar.Images[0].ID,
+		LocationID:    ar.Locations[0].ID,
+		MachineSizeID: ar.MachineSizes[0].ID,
+		SSHKeyIDs:     []string{ar.SSHKeys[0].ID},
+		NetworkIDs:    []string{ar.Networks[0].ID, ar.Networks[1].ID},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, rest.HOSTSTATE_NEW, h.State)
+
+	var states []rest.HostState
+
+	for i := 0; i < 3; i++ {
+		h, _, err = c.Client.HostsApi.GetByID(ctx, h.ID, nil)
+		require.NoError(t, err)
+
+		states = append(states, h.State)
+	}
+
+	assert.Equal(t, []rest.HostState{rest.HOSTSTATE_IMAGING, rest.HOSTSTATE_READY, rest.HOSTSTATE_READY}, states)
+	assert.Equal(t, rest.HOSTPOWERSTATE_ON, h.PowerStatus)
+	require.Len(t, h.Connections, 1)
+	require.Len(t, h.Connections[0].Networks, 2)
+	assert.NotEmpty(t, h.Connections[0].Networks[0].IP)
+	assert.NotNil(t, h.ISCSIConfig)
+
+	// A host consumes machine inventory.
+	ar2, _, err := c.Client.AvailableResourcesApi.List(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ar.MachineInventory[0].Number-1, ar2.MachineInventory[0].Number)
+
+	_, err = c.Client.HostsApi.Delete(ctx, h.ID, nil)
+	require.NoError(t, err)
+
+	h, _, err = c.Client.HostsApi.GetByID(ctx, h.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, rest.HOSTSTATE_DELETED, h.State)
+
+	_, resp, err := c.Client.HostsApi.GetByID(ctx, h.ID, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+//nolint:bodyclose // Response body is closed by metal client.
+func TestVolumeAttachDetach(t *testing.T) {
+	_, c := newTestPortal(t)
+	ctx := testContext(t, c)
+	ar := availableResources(t, c)
+
+	v, resp, err := c.Client.VolumesApi.Add(ctx, rest.NewVolume{
+		Name:       "vol1",
+		FlavorID:   ar.VolumeFlavors[0].ID,
+		Capacity:   10,
+		LocationID: ar.Locations[0].ID,
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, rest.VOLUMESTATE_ALLOCATING, v.State)
+
+	h, _, err := c.Client.HostsApi.Add(ctx, rest.NewHost{
+		Name:          "host1",
+		ServiceID:     ar.Images[0].ID,
+		LocationID:    ar.Locations[0].ID,
+		MachineSizeID: ar.MachineSizes[0].ID,
+		NetworkIDs:    []string{ar.Networks[0].ID},
+	}, nil)
+	require.NoError(t, err)
+
+	v, _, err = c.Client.VolumesApi.GetByID(ctx, v.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, rest.VOLUMESTATE_ALLOCATED, v.State)
+
+	va, _, err := c.Client.VolumesApi.Attach(ctx, v.ID, rest.VolumeAttachHostUuid{HostID: h.ID}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, rest.VASTATEENUM_ATTACHING, va.State)
+
+	va, _, err = c.Client.VolumeAttachmentsApi.GetByID(ctx, va.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, rest.VASTATEENUM_READY, va.State)
+
+	v, _, err = c.Client.VolumesApi.GetByID(ctx, v.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, rest.VOLUMESTATE_VISIBLE, v.State)
+
+	// An attached volume can not be deleted.
+	_, err = c.Client.VolumesApi.Delete(ctx, v.ID, nil)
+	require.Error(t, err)
+
+	_, err = c.Client.VolumesApi.Detach(ctx, v.ID, rest.VolumeAttachHostUuid{HostID: h.ID}, nil)
+	require.NoError(t, err)
+
+	va, _, err = c.Client.VolumeAttachmentsApi.GetByID(ctx, va.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, rest.VASTATEENUM_DELETED, va.State)
+
+	v, _, err = c.Client.VolumesApi.GetByID(ctx, v.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, rest.VOLUMESTATE_ALLOCATED, v.State)
+
+	_, err = c.Client.VolumesApi.Delete(ctx, v.ID, nil)
+	require.NoError(t, err)
+
+	v, _, err = c.Client.VolumesApi.GetByID(ctx, v.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, rest.VOLUMESTATE_DELETED, v.State)
+}
+
+//nolint:bodyclose // Response body is closed by metal client.
+func TestNetworkAndIPPool(t *testing.T) {
+	_, c := newTestPortal(t)
+	ctx := testContext(t, c)
+
+	n, _, err := c.Client.NetworksApi.Add(ctx, rest.NewNetwork{
+		Name:       "net1",
+		LocationID: availableResources(t, c).Locations[0].ID,
+		NewIPPool: &rest.NewIpPool{
+			Name:      "pool1",
+			IPVersion: rest.IPVER_I_PV4,
+			BaseIP:    "192.168.1.0",
+			Netmask:   rest.NETMASK__24,
+			Sources:   []rest.IpSource{{Base: "192.168.1.10", Count: 10}},
+		},
+	}, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, n.IPPoolID)
+
+	pool, _, err := c.Client.IppoolsApi.AllocateIPs(ctx, n.IPPoolID,
+		[]rest.IpAllocation{{Base: "192.168.1.11", Count: 1, Usage: "vip"}}, nil)
+	require.NoError(t, err)
+	require.Len(t, pool.UseRecords, 1)
+	assert.Equal(t, "vip", pool.UseRecords[0].Usage)
+
+	_, _, err = c.Client.IppoolsApi.AllocateIPs(ctx, n.IPPoolID,
+		[]rest.IpAllocation{{Base: "192.168.1.11", Count: 1}}, nil)
+	require.Error(t, err)
+
+	pool, _, err = c.Client.IppoolsApi.ReturnIPs(ctx, n.IPPoolID, []string{"192.168.1.11"}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, pool.UseRecords)
+
+	_, err = c.Client.NetworksApi.Delete(ctx, n.ID, nil)
+	require.NoError(t, err)
+
+	_, resp, err := c.Client.IppoolsApi.GetByID(ctx, n.IPPoolID, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+//nolint:bodyclose // Response body is closed by metal client.
+func TestServiceUpload(t *testing.T) {
+	_, c := newTestPortal(t)
+	ctx := testContext(t, c)
+
+	path := filepath.Join(t.TempDir(), "service.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+name: CentOS-7
+svc_category: linux
+svc_flavor: centos
+svc_ver: "7"
+info:
+  - contents: aG9zdG5hbWU6IHt7IC5OYW1lIH19
+    encoding: base64
+    templating: go-text-template
+    templating_input: hostdef-v2
+    target: hdd
+    path: /etc/cloud/cloud.cfg.d/95_datasource.cfg
+`), 0o600))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	svc, _, err := c.Client.ServicesApi.Add(ctx, f, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "centos", svc.Flavor)
+	require.Len(t, svc.Info, 1)
+	assert.Equal(t, rest.TEMPLATINGINFO_V2, svc.Info[0].TemplatingInput)
+
+	require.NoError(t, c.RefreshAvailableResources())
+	assert.Len(t, availableResources(t, c).Images, 2)
+	assert.Len(t, c.AvailableResources.Images, 2) //nolint:staticcheck // the deprecated field is still refreshed
+
+	// Refreshes may run at once.
+	var wg sync.WaitGroup
+
+	for range 2 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, c.RefreshAvailableResources())
+		}()
+	}
+
+	wg.Wait()
+
+	_, err = c.Client.ServicesApi.Delete(context.Background(), svc.ID, nil)
+	require.Error(t, err, "requests without a token are rejected")
+
+	_, err = c.Client.ServicesApi.Delete(ctx, svc.ID, nil)
+	require.NoError(t, err)
+}
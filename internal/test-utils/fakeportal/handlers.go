@@ -0,0 +1,1120 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package fakeportal
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+)
+
+// Kinds of object held by the portal.
+const (
+	kindHosts       = "hosts"
+	kindVolumes     = "volumes"
+	kindAttachments = "volume-attachments"
+	kindNetworks    = "networks"
+	kindIPPools     = "ippools"
+	kindSSHKeys     = "sshkeys"
+	kindProjects    = "projects"
+	kindServices    = "services"
+)
+
+// serviceFormField is the multipart form field holding a service YAML file.
+const serviceFormField = "fileName"
+
+func (s *Server) routes(mux *http.ServeMux) {
+	handle := func(pattern string, h http.HandlerFunc) {
+		method, path, _ := strings.Cut(pattern, " ")
+		mux.HandleFunc(method+" "+basePath+path, h)
+	}
+
+	handle("GET /available-resources", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, s.availableResources())
+	})
+
+	handle("GET /version", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"Version": "fakeportal"})
+	})
+
+	handle("GET /hosts", s.lister(kindHosts))
+	handle("POST /hosts", s.addHost)
+	handle("GET /hosts/{id}", s.getter(kindHosts))
+	handle("PUT /hosts/{id}", s.updateHost)
+	handle("DELETE /hosts/{id}", s.deleteHost)
+	handle("POST /hosts/{id}/poweroff", s.powerHost(rest.HOSTPOWERSTATE_OFF))
+	handle("POST /hosts/{id}/poweron", s.powerHost(rest.HOSTPOWERSTATE_ON))
+	handle("POST /hosts/{id}/powerreset", s.powerHost(rest.HOSTPOWERSTATE_ON))
+
+	handle("GET /volumes", s.lister(kindVolumes))
+	handle("POST /volumes", s.addVolume)
+	handle("GET /volumes/{id}", s.getter(kindVolumes))
+	handle("PUT /volumes/{id}", s.updateVolume)
+	handle("DELETE /volumes/{id}", s.deleteVolume)
+	handle("POST /volumes/{id}/attach", s.attachVolume)
+	handle("POST /volumes/{id}/detach", s.detachVolume)
+
+	handle("GET /volume-attachments", s.lister(kindAttachments))
+	handle("POST /volume-attachments", s.addAttachment)
+	handle("GET /volume-attachments/{id}", s.getter(kindAttachments))
+	handle("DELETE /volume-attachments/{id}", s.deleteAttachment)
+
+	handle("GET /networks", s.lister(kindNetworks))
+	handle("POST /networks", s.postNetwork)
+	handle("GET /networks/{id}", s.getter(kindNetworks))
+	handle("PUT /networks/{id}", s.updateNetwork)
+	handle("DELETE /networks/{id}", s.deleteNetwork)
+
+	handle("GET /ippools", s.lister(kindIPPools))
+	handle("GET /ippools/{id}", s.getter(kindIPPools))
+	handle("PUT /ippools/{id}", s.updateIPPool)
+	handle("POST /ippools/{id}/allocation", s.allocateIPs)
+	handle("POST /ippools/{id}/return", s.returnIPs)
+
+	handle("GET /sshkeys", s.lister(kindSSHKeys))
+	handle("POST /sshkeys", s.addSSHKey)
+	handle("GET /sshkeys/{id}", s.getter(kindSSHKeys))
+	handle("PUT /sshkeys/{id}", s.updateSSHKey)
+	handle("DELETE /sshkeys/{id}", s.deleter(kindSSHKeys))
+
+	handle("GET /projects", s.lister(kindProjects))
+	handle("POST /projects", s.addProject)
+	handle("GET /projects/{id}", s.getter(kindProjects))
+	handle("PUT /projects/{id}", s.updateProject)
+	handle("DELETE /projects/{id}", s.deleter(kindProjects))
+
+	handle("GET /services", s.lister(kindServices))
+	handle("POST /services", s.putService)
+	handle("GET /services/{id}", s.getter(kindServices))
+	handle("PUT /services/{id}", s.putService)
+	handle("DELETE /services/{id}", s.deleter(kindServices))
+}
+
+// lister returns a handler that lists all objects of kind.
+func (s *Server) lister(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		values := make([]interface{}, 0, len(s.order[kind]))
+		for _, o := range s.list(kind) {
+			values = append(values, o.value)
+		}
+
+		writeJSON(w, http.StatusOK, values)
+	}
+}
+
+// getter returns a handler that gets a single object of kind.
+func (s *Server) getter(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if o, ok := s.lookup(w, r, kind); ok {
+			writeJSON(w, http.StatusOK, o.value)
+		}
+	}
+}
+
+// deleter returns a handler that deletes an object of kind immediately.
+func (s *Server) deleter(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := s.lookup(w, r, kind); ok {
+			s.purge(kind, r.PathValue("id"))
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// lookup finds the object of kind named in the request path, writing a
+// not found error if there is none.
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request, kind string) (*object, bool) {
+	id := r.PathValue("id")
+
+	o, ok := s.get(kind, id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "%s %q not found", strings.TrimSuffix(kind, "s"), id)
+	}
+
+	return o, ok
+}
+
+func (s *Server) addHost(w http.ResponseWriter, r *http.Request) {
+	var nh rest.NewHost
+	if !readJSON(w, r, &nh) {
+		return
+	}
+
+	svcObj, ok := s.get(kindServices, nh.ServiceID)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "service %q not found", nh.ServiceID)
+
+		return
+	}
+
+	size, ok := s.machineSize(nh.MachineSizeID)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "machine size %q not found", nh.MachineSizeID)
+
+		return
+	}
+
+	var available int32
+
+	for _, inv := range s.availableResources().MachineInventory {
+		if inv.LocationID == nh.LocationID && inv.SizeID == nh.MachineSizeID {
+			available += inv.Number
+		}
+	}
+
+	if available == 0 {
+		writeError(w, http.StatusBadRequest, "no machines of size %q available", size.Name)
+
+		return
+	}
+
+	var authorizedKeys []string
+
+	for _, keyID := range nh.SSHKeyIDs {
+		k, ok := s.get(kindSSHKeys, keyID)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "ssh key %q not found", keyID)
+
+			return
+		}
+
+		authorizedKeys = append(authorizedKeys, k.value.(*rest.SshKey).Key)
+	}
+
+	if err := s.checkNetworks(nh.LocationID, nh.NetworkIDs); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+
+		return
+	}
+
+	for _, volID := range nh.VolumeIDs {
+		if _, ok := s.get(kindVolumes, volID); !ok {
+			writeError(w, http.StatusBadRequest, "volume %q not found", volID)
+
+			return
+		}
+	}
+
+	svc := svcObj.value.(*rest.OsServiceImage)
+	h := &rest.Host{
+		ID:                     s.newID(),
+		ETag:                   etag(),
+		Name:                   nh.Name,
+		Created:                now(),
+		Modified:               now(),
+		Description:            nh.Description,
+		ServiceID:              svc.ID,
+		ServiceFlavor:          svc.Flavor,
+		ServiceVersion:         svc.Version,
+		LocationID:             nh.LocationID,
+		MachineSizeID:          size.ID,
+		MachineSizeName:        size.Name,
+		MachineID:              s.newID(),
+		SSHKeyIDs:              nh.SSHKeyIDs,
+		SSHAuthorizedKeys:      authorizedKeys,
+		NetworkIDs:             nh.NetworkIDs,
+		NetworkForDefaultRoute: nh.NetworkForDefaultRoute,
+		NetworkUntagged:        nh.NetworkUntagged,
+		PreAllocatedIPs:        nh.PreAllocatedIPs,
+		UserData:               nh.UserData,
+		PortalCommOkay:         true,
+		PowerStatus:            rest.HOSTPOWERSTATE_OFF,
+		State:                  rest.HOSTSTATE_NEW,
+		Substate:               rest.HOSTSUBSTATE_INIT,
+		SummaryStatus:          rest.HEALTHSTATUS_OK,
+		Labels:                 nh.Labels,
+		ISCSIConfig: &rest.HostIscsiConfig{
+			InitiatorName:         "iqn.2026-01.com.hpe.fakeportal:" + nh.Name,
+			ISCSIDiscoveryAddress: "10.0.1.2",
+		},
+	}
+
+	h.Connections = s.connect(h)
+
+	deploy := func() {
+		h.State = rest.HOSTSTATE_IMAGING
+		h.Substate = rest.HOSTSUBSTATE_DEPLOY
+	}
+
+	if len(s.provisionFailures) > 0 {
+		msg := s.provisionFailures[0]
+		s.provisionFailures = s.provisionFailures[1:]
+
+		s.put(kindHosts, h, deploy, func() {
+			h.Substate = rest.HOSTSUBSTATE_FAILED
+			h.SummaryStatus = rest.HEALTHSTATUS_CRITICAL
+			h.Alert = true
+			h.AlertInfo = append(h.AlertInfo, rest.HostAlertInfo{
+				Alert:    "Deploy",
+				State:    h.State,
+				Substate: h.Substate,
+				Message:  msg,
+				Time:     now(),
+			})
+		})
+	} else {
+		s.put(kindHosts, h, deploy, func() {
+			h.State = rest.HOSTSTATE_READY
+			h.Substate = rest.HOSTSUBSTATE_COMPLETE
+			h.PowerStatus = rest.HOSTPOWERSTATE_ON
+		})
+	}
+
+	for _, volID := range nh.VolumeIDs {
+		s.attach(volID, h.ID)
+	}
+
+	writeJSON(w, http.StatusOK, h)
+}
+
+func (s *Server) updateHost(w http.ResponseWriter, r *http.Request) {
+	o, ok := s.lookup(w, r, kindHosts)
+	if !ok {
+		return
+	}
+
+	var uh rest.UpdateHost
+	if !readJSON(w, r, &uh) {
+		return
+	}
+
+	h := o.value.(*rest.Host)
+
+	if err := s.checkNetworks(h.LocationID, uh.NetworkIDs); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+
+		return
+	}
+
+	networksChanged := !sameStrings(h.NetworkIDs, uh.NetworkIDs) ||
+		h.NetworkForDefaultRoute != uh.NetworkForDefaultRoute || h.NetworkUntagged != uh.NetworkUntagged
+
+	h.ETag = etag()
+	h.Modified = now()
+	h.Name = uh.Name
+	h.Description = uh.Description
+	h.Labels = uh.Labels
+
+	if uh.ISCSIConfig != nil && uh.ISCSIConfig.InitiatorName != "" {
+		h.ISCSIConfig.InitiatorName = uh.ISCSIConfig.InitiatorName
+	}
+
+	if networksChanged {
+		s.disconnect(h)
+
+		h.NetworkIDs = uh.NetworkIDs
+		h.NetworkForDefaultRoute = uh.NetworkForDefaultRoute
+		h.NetworkUntagged = uh.NetworkUntagged
+		h.Connections = s.connect(h)
+		h.State = rest.HOSTSTATE_UPDATING_CONNECTIONS
+
+		o.pending = append(o.pending, func() { h.State = rest.HOSTSTATE_READY })
+	}
+
+	writeJSON(w, http.StatusOK, h)
+}
+
+func (s *Server) deleteHost(w http.ResponseWriter, r *http.Request) {
+	o, ok := s.lookup(w, r, kindHosts)
+	if !ok {
+		return
+	}
+
+	h := o.value.(*rest.Host)
+	h.State = rest.HOSTSTATE_DELETING
+	h.Substate = rest.HOSTSUBSTATE_RELEASE
+
+	for _, va := range s.list(kindAttachments) {
+		if a := va.value.(*rest.VolumeAttachment); a.HostID == h.ID && a.State != rest.VASTATEENUM_DELETED {
+			s.detach(a)
+		}
+	}
+
+	o.pending = append(o.pending,
+		func() {
+			s.disconnect(h)
+			h.State = rest.HOSTSTATE_DELETED
+			h.Deleted = true
+			h.PowerStatus = rest.HOSTPOWERSTATE_OFF
+		},
+		func() { s.purge(kindHosts, h.ID) },
+	)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) powerHost(state rest.HostPowerState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		o, ok := s.lookup(w, r, kindHosts)
+		if !ok {
+			return
+		}
+
+		h := o.value.(*rest.Host)
+		o.pending = append(o.pending, func() { h.PowerStatus = state })
+
+		writeJSON(w, http.StatusOK, h)
+	}
+}
+
+// checkNetworks verifies that all networks exist in the location.
+func (s *Server) checkNetworks(locationID string, networkIDs []string) error {
+	for _, netID := range networkIDs {
+		n, ok := s.get(kindNetworks, netID)
+		if !ok {
+			return fmt.Errorf("network %q not found", netID)
+		}
+
+		if n.value.(*rest.Network).LocationID != locationID {
+			return fmt.Errorf("network %q is not in location %q", netID, locationID)
+		}
+	}
+
+	return nil
+}
+
+// connect allocates an IP address on each of the host's networks.
+func (s *Server) connect(h *rest.Host) []rest.HostConnection {
+	conn := rest.HostConnection{Name: "bond0", Speed: "25G", HA: true}
+
+	for _, netID := range h.NetworkIDs {
+		n := s.stores[kindNetworks][netID].value.(*rest.Network)
+		hn := rest.HostNetworkConnection{
+			Name:      n.Name,
+			NetworkID: n.ID,
+			VLAN:      n.VLAN,
+			VNI:       n.VNI,
+			Untagged:  n.ID == h.NetworkUntagged,
+		}
+
+		if p, ok := s.get(kindIPPools, n.IPPoolID); ok {
+			pool := p.value.(*rest.IpPool)
+
+			hn.IP = s.nextIP(pool, h.ID)
+			hn.Subnet = pool.BaseIP
+			hn.Netmask = string(pool.Netmask)
+			hn.Gateway = pool.DefaultRoute
+			hn.DNS = pool.DNS
+			hn.Proxy = pool.Proxy
+			hn.NoProxy = pool.NoProxy
+		}
+
+		conn.Networks = append(conn.Networks, hn)
+	}
+
+	return []rest.HostConnection{conn}
+}
+
+// disconnect returns the IP addresses allocated to the host.
+func (s *Server) disconnect(h *rest.Host) {
+	for _, p := range s.list(kindIPPools) {
+		pool := p.value.(*rest.IpPool)
+
+		records := pool.UseRecords[:0]
+		for _, rec := range pool.UseRecords {
+			if rec.HostID != h.ID {
+				records = append(records, rec)
+			}
+		}
+
+		pool.UseRecords = records
+	}
+
+	h.Connections = nil
+}
+
+// nextIP allocates the next free address from the pool.
+func (s *Server) nextIP(pool *rest.IpPool, hostID string) string {
+	if len(pool.Sources) == 0 {
+		return ""
+	}
+
+	base, err := netip.ParseAddr(pool.Sources[0].Base)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		idx := s.ipIndex[pool.ID]
+		s.ipIndex[pool.ID]++
+
+		ip := base
+		for i := 0; i < idx%int(pool.Sources[0].Count); i++ {
+			ip = ip.Next()
+		}
+
+		if !inUse(pool, ip.String()) {
+			pool.UseRecords = append(pool.UseRecords, rest.UseRecord{Base: ip.String(), HostID: hostID, Usage: "host"})
+
+			return ip.String()
+		}
+	}
+}
+
+func inUse(pool *rest.IpPool, ip string) bool {
+	for _, rec := range pool.UseRecords {
+		if rec.Base == ip {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Server) machineSize(id string) (rest.MachineSize, bool) {
+	for _, size := range s.seeded.MachineSizes {
+		if size.ID == id {
+			return size, true
+		}
+	}
+
+	return rest.MachineSize{}, false
+}
+
+func (s *Server) addVolume(w http.ResponseWriter, r *http.Request) {
+	var nv rest.NewVolume
+	if !readJSON(w, r, &nv) {
+		return
+	}
+
+	var flavorOK, locationOK bool
+
+	for _, vf := range s.seeded.VolumeFlavors {
+		flavorOK = flavorOK || vf.ID == nv.FlavorID
+	}
+
+	for _, loc := range s.seeded.Locations {
+		locationOK = locationOK || loc.ID == nv.LocationID
+	}
+
+	if !flavorOK || !locationOK {
+		writeError(w, http.StatusBadRequest, "invalid volume flavor %q or location %q", nv.FlavorID, nv.LocationID)
+
+		return
+	}
+
+	v := &rest.Volume{
+		ID:                 s.newID(),
+		ETag:               etag(),
+		Name:               nv.Name,
+		Created:            now(),
+		Modified:           now(),
+		Description:        nv.Description,
+		FlavorID:           nv.FlavorID,
+		StoragePoolID:      nv.StoragePoolID,
+		Capacity:           nv.Capacity,
+		Shareable:          nv.Shareable,
+		LocationID:         nv.LocationID,
+		VolumeCollectionID: nv.VolumeCollectionID,
+		State:              rest.VOLUMESTATE_ALLOCATING,
+		SubState:           rest.VOLUMESUBSTATE_IDLE,
+		Status:             rest.VOLUMESTATUS_OK,
+		Labels:             nv.Labels,
+		WWN:                strings.ReplaceAll(s.newID(), "-", ""),
+	}
+
+	if v.StoragePoolID == "" {
+		v.StoragePoolID = s.seeded.StoragePools[0].ID
+	}
+
+	for _, sp := range s.seeded.StoragePools {
+		if sp.ID == v.StoragePoolID {
+			v.StoragePoolName = sp.Name
+		}
+	}
+
+	s.put(kindVolumes, v, func() { v.State = rest.VOLUMESTATE_ALLOCATED })
+
+	writeJSON(w, http.StatusCreated, v)
+}
+
+func (s *Server) updateVolume(w http.ResponseWriter, r *http.Request) {
+	o, ok := s.lookup(w, r, kindVolumes)
+	if !ok {
+		return
+	}
+
+	var uv rest.UpdateVolume
+	if !readJSON(w, r, &uv) {
+		return
+	}
+
+	v := o.value.(*rest.Volume)
+	if uv.Capacity < v.Capacity {
+		writeError(w, http.StatusBadRequest, "volume capacity can not be reduced")
+
+		return
+	}
+
+	v.ETag = etag()
+	v.Modified = now()
+	v.Name = uv.Name
+	v.Capacity = uv.Capacity
+	v.Labels = uv.Labels
+	v.VolumeCollectionID = uv.VolumeCollectionID
+	v.SubState = rest.VOLUMESUBSTATE_UPDATE_REQUESTED
+
+	o.pending = append(o.pending,
+		func() { v.SubState = rest.VOLUMESUBSTATE_UPDATING },
+		func() { v.SubState = rest.VOLUMESUBSTATE_IDLE },
+	)
+
+	writeJSON(w, http.StatusOK, v)
+}
+
+func (s *Server) deleteVolume(w http.ResponseWriter, r *http.Request) {
+	o, ok := s.lookup(w, r, kindVolumes)
+	if !ok {
+		return
+	}
+
+	v := o.value.(*rest.Volume)
+	if s.liveAttachments(v.ID) > 0 {
+		writeError(w, http.StatusBadRequest, "volume %q is attached", v.Name)
+
+		return
+	}
+
+	v.State = rest.VOLUMESTATE_DELETING
+
+	o.pending = append(o.pending,
+		func() { v.State = rest.VOLUMESTATE_DELETED },
+		func() { s.purge(kindVolumes, v.ID) },
+	)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) attachVolume(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.lookup(w, r, kindVolumes); !ok {
+		return
+	}
+
+	var body rest.VolumeAttachHostUuid
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	if _, ok := s.get(kindHosts, body.HostID); !ok {
+		writeError(w, http.StatusBadRequest, "host %q not found", body.HostID)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.attach(r.PathValue("id"), body.HostID))
+}
+
+func (s *Server) detachVolume(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.lookup(w, r, kindVolumes); !ok {
+		return
+	}
+
+	var body rest.VolumeAttachHostUuid
+	if !readJSON(w, r, &body) {
+		return
+	}
+
+	for _, o := range s.list(kindAttachments) {
+		va := o.value.(*rest.VolumeAttachment)
+		if va.VolumeID == r.PathValue("id") && va.HostID == body.HostID && va.State != rest.VASTATEENUM_DELETED {
+			s.detach(va)
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+	}
+
+	writeError(w, http.StatusBadRequest, "volume %q is not attached to host %q", r.PathValue("id"), body.HostID)
+}
+
+// attach creates an attachment of the volume to the host. The attachment
+// becomes ready, and the volume visible, asynchronously.
+func (s *Server) attach(volID, hostID string) *rest.VolumeAttachment {
+	v := s.stores[kindVolumes][volID].value.(*rest.Volume)
+
+	va := &rest.VolumeAttachment{
+		ID:                    s.newID(),
+		ETag:                  etag(),
+		Name:                  v.Name,
+		Created:               now(),
+		Modified:              now(),
+		VolumeID:              volID,
+		HostID:                hostID,
+		LUN:                   int32(s.liveAttachments(volID)),
+		VolumeTargetIQN:       "iqn.2026-01.com.hpe.fakeportal:" + v.Name,
+		VolumeTargetIPAddress: "10.0.1.2",
+		State:                 rest.VASTATEENUM_ATTACHING,
+		AttachProtocol:        rest.PROTOCOLKIND_ISCSI,
+	}
+
+	s.put(kindAttachments, va, func() {
+		va.State = rest.VASTATEENUM_READY
+		v.State = rest.VOLUMESTATE_VISIBLE
+		v.ExportCount++
+	})
+
+	return va
+}
+
+// detach removes the attachment asynchronously. The volume reverts to
+// allocated once it has no remaining attachments.
+func (s *Server) detach(va *rest.VolumeAttachment) {
+	o := s.stores[kindAttachments][va.ID]
+
+	va.State = rest.VASTATEENUM_DETACHING
+	o.pending = append(o.pending[:0],
+		func() {
+			va.State = rest.VASTATEENUM_DELETED
+
+			if vo, ok := s.get(kindVolumes, va.VolumeID); ok {
+				v := vo.value.(*rest.Volume)
+				if v.ExportCount > 0 {
+					v.ExportCount--
+				}
+
+				if s.liveAttachments(v.ID) == 0 && v.State == rest.VOLUMESTATE_VISIBLE {
+					v.State = rest.VOLUMESTATE_ALLOCATED
+				}
+			}
+		},
+		func() { s.purge(kindAttachments, va.ID) },
+	)
+}
+
+// liveAttachments returns the number of attachments of the volume that have not been deleted.
+func (s *Server) liveAttachments(volID string) int {
+	count := 0
+
+	for _, o := range s.list(kindAttachments) {
+		va := o.value.(*rest.VolumeAttachment)
+		if va.VolumeID == volID && va.State != rest.VASTATEENUM_DELETED {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (s *Server) addAttachment(w http.ResponseWriter, r *http.Request) {
+	var nva rest.NewVolumeAttachment
+	if !readJSON(w, r, &nva) {
+		return
+	}
+
+	if _, ok := s.get(kindVolumes, nva.VolumeID); !ok {
+		writeError(w, http.StatusBadRequest, "volume %q not found", nva.VolumeID)
+
+		return
+	}
+
+	va := s.attach(nva.VolumeID, "")
+	if nva.Name != "" {
+		va.Name = nva.Name
+	}
+
+	writeJSON(w, http.StatusOK, va)
+}
+
+func (s *Server) deleteAttachment(w http.ResponseWriter, r *http.Request) {
+	o, ok := s.lookup(w, r, kindAttachments)
+	if !ok {
+		return
+	}
+
+	if va := o.value.(*rest.VolumeAttachment); va.State != rest.VASTATEENUM_DELETED {
+		s.detach(va)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) postNetwork(w http.ResponseWriter, r *http.Request) {
+	var nn rest.NewNetwork
+	if !readJSON(w, r, &nn) {
+		return
+	}
+
+	for _, o := range s.list(kindNetworks) {
+		if n := o.value.(*rest.Network); n.Name == nn.Name && n.LocationID == nn.LocationID {
+			writeError(w, http.StatusBadRequest, "network %q already exists", nn.Name)
+
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, s.addNetwork(nn))
+}
+
+// addNetwork creates a network, along with its IP pool if one is specified.
+func (s *Server) addNetwork(nn rest.NewNetwork) *rest.Network {
+	n := &rest.Network{
+		ID:          s.newID(),
+		ETag:        etag(),
+		Name:        nn.Name,
+		Created:     now(),
+		Modified:    now(),
+		LocationID:  nn.LocationID,
+		Description: nn.Description,
+		HostUse:     nn.HostUse,
+		Purpose:     nn.Purpose,
+		NoIPPool:    nn.NewIPPool == nil,
+		VLAN:        nn.VLAN,
+		VNI:         nn.VNI,
+	}
+
+	if np := nn.NewIPPool; np != nil {
+		pool := &rest.IpPool{
+			ID:           s.newID(),
+			ETag:         etag(),
+			Name:         np.Name,
+			Created:      now(),
+			Modified:     now(),
+			Description:  np.Description,
+			IPVersion:    np.IPVersion,
+			NetworkID:    n.ID,
+			BaseIP:       np.BaseIP,
+			Netmask:      np.Netmask,
+			DefaultRoute: np.DefaultRoute,
+			Sources:      np.Sources,
+			DNS:          np.DNS,
+			Proxy:        np.Proxy,
+			NoProxy:      np.NoProxy,
+			NTP:          np.NTP,
+			UseRecords:   []rest.UseRecord{},
+		}
+
+		s.put(kindIPPools, pool)
+		n.IPPoolID = pool.ID
+	}
+
+	s.put(kindNetworks, n)
+
+	return n
+}
+
+func (s *Server) updateNetwork(w http.ResponseWriter, r *http.Request) {
+	o, ok := s.lookup(w, r, kindNetworks)
+	if !ok {
+		return
+	}
+
+	var un rest.UpdateNetwork
+	if !readJSON(w, r, &un) {
+		return
+	}
+
+	n := o.value.(*rest.Network)
+	n.ETag = etag()
+	n.Modified = now()
+	n.Name = un.Name
+	n.Description = un.Description
+	n.HostUse = un.HostUse
+	n.Purpose = un.Purpose
+
+	writeJSON(w, http.StatusOK, n)
+}
+
+func (s *Server) deleteNetwork(w http.ResponseWriter, r *http.Request) {
+	o, ok := s.lookup(w, r, kindNetworks)
+	if !ok {
+		return
+	}
+
+	n := o.value.(*rest.Network)
+
+	for _, ho := range s.list(kindHosts) {
+		if h := ho.value.(*rest.Host); h.State != rest.HOSTSTATE_DELETED && contains(h.NetworkIDs, n.ID) {
+			writeError(w, http.StatusBadRequest, "network %q is in use by host %q", n.Name, h.Name)
+
+			return
+		}
+	}
+
+	if n.IPPoolID != "" {
+		s.purge(kindIPPools, n.IPPoolID)
+	}
+
+	s.purge(kindNetworks, n.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) updateIPPool(w http.ResponseWriter, r *http.Request) {
+	o, ok := s.lookup(w, r, kindIPPools)
+	if !ok {
+		return
+	}
+
+	var up rest.UpdateIpPool
+	if !readJSON(w, r, &up) {
+		return
+	}
+
+	pool := o.value.(*rest.IpPool)
+	pool.ETag = etag()
+	pool.Modified = now()
+	pool.Name = up.Name
+	pool.Description = up.Description
+	pool.DefaultRoute = up.DefaultRoute
+	pool.DNS = up.DNS
+	pool.Proxy = up.Proxy
+	pool.NoProxy = up.NoProxy
+	pool.NTP = up.NTP
+
+	writeJSON(w, http.StatusOK, pool)
+}
+
+func (s *Server) allocateIPs(w http.ResponseWriter, r *http.Request) {
+	o, ok := s.lookup(w, r, kindIPPools)
+	if !ok {
+		return
+	}
+
+	var allocations []rest.IpAllocation
+	if !readJSON(w, r, &allocations) {
+		return
+	}
+
+	pool := o.value.(*rest.IpPool)
+
+	for _, a := range allocations {
+		if a.Base == "" {
+			ip := s.nextIP(pool, "")
+			pool.UseRecords[len(pool.UseRecords)-1].Usage = a.Usage
+
+			a.Base = ip
+		} else {
+			if inUse(pool, a.Base) {
+				writeError(w, http.StatusBadRequest, "IP %q is already allocated", a.Base)
+
+				return
+			}
+
+			pool.UseRecords = append(pool.UseRecords, rest.UseRecord{Base: a.Base, Usage: a.Usage})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, pool)
+}
+
+func (s *Server) returnIPs(w http.ResponseWriter, r *http.Request) {
+	o, ok := s.lookup(w, r, kindIPPools)
+	if !ok {
+		return
+	}
+
+	var ips []string
+	if !readJSON(w, r, &ips) {
+		return
+	}
+
+	pool := o.value.(*rest.IpPool)
+
+	records := pool.UseRecords[:0]
+	for _, rec := range pool.UseRecords {
+		if !contains(ips, rec.Base) {
+			records = append(records, rec)
+		}
+	}
+
+	pool.UseRecords = records
+
+	writeJSON(w, http.StatusOK, pool)
+}
+
+func (s *Server) addSSHKey(w http.ResponseWriter, r *http.Request) {
+	var nk rest.NewSshKey
+	if !readJSON(w, r, &nk) {
+		return
+	}
+
+	k := &rest.SshKey{
+		ID:       s.newID(),
+		ETag:     etag(),
+		Name:     nk.Name,
+		Created:  now(),
+		Modified: now(),
+		Key:      nk.Key,
+	}
+
+	s.put(kindSSHKeys, k)
+
+	writeJSON(w, http.StatusOK, k)
+}
+
+func (s *Server) updateSSHKey(w http.ResponseWriter, r *http.Request) {
+	o, ok := s.lookup(w, r, kindSSHKeys)
+	if !ok {
+		return
+	}
+
+	var uk rest.UpdateSshKey
+	if !readJSON(w, r, &uk) {
+		return
+	}
+
+	k := o.value.(*rest.SshKey)
+	k.ETag = etag()
+	k.Modified = now()
+	k.Name = uk.Name
+	k.Key = uk.Key
+
+	writeJSON(w, http.StatusOK, k)
+}
+
+func (s *Server) addProject(w http.ResponseWriter, r *http.Request) {
+	var np rest.NewProject
+	if !readJSON(w, r, &np) {
+		return
+	}
+
+	p := &rest.Project{
+		ID:                       s.newID(),
+		ETag:                     etag(),
+		Name:                     np.Name,
+		Created:                  now(),
+		Modified:                 now(),
+		Profile:                  np.Profile,
+		Limits:                   np.Limits,
+		PermittedSites:           np.PermittedSites,
+		PermittedOSImages:        np.PermittedOSImages,
+		VolumeReplicationEnabled: np.VolumeReplicationEnabled,
+		BootFromSANSupport:       np.BootFromSANSupport,
+	}
+
+	s.put(kindProjects, p)
+
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) updateProject(w http.ResponseWriter, r *http.Request) {
+	o, ok := s.lookup(w, r, kindProjects)
+	if !ok {
+		return
+	}
+
+	var up rest.UpdateProject
+	if !readJSON(w, r, &up) {
+		return
+	}
+
+	p := o.value.(*rest.Project)
+	p.ETag = etag()
+	p.Modified = now()
+	p.Name = up.Name
+	p.Profile = rest.Profile(up.Profile)
+	p.PermittedOSImages = up.PermittedOSImages
+	p.PermittedSites = up.PermittedSites
+	p.BootFromSANSupport = up.BootFromSANSupport
+
+	writeJSON(w, http.StatusOK, p)
+}
+
+// serviceFile is the subset of a service YAML file understood by the portal.
+type serviceFile struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Category    string `yaml:"svc_category"`
+	Flavor      string `yaml:"svc_flavor"`
+	Version     string `yaml:"svc_ver"`
+	Timeout     int64  `yaml:"timeout"`
+	Info        []struct {
+		Contents        string `yaml:"contents"`
+		Encoding        string `yaml:"encoding"`
+		Templating      string `yaml:"templating"`
+		TemplatingInput string `yaml:"templating_input"`
+		Target          string `yaml:"target"`
+		Path            string `yaml:"path"`
+	} `yaml:"info"`
+}
+
+// putService handles both service creation and update from a multipart upload of a service YAML file.
+func (s *Server) putService(w http.ResponseWriter, r *http.Request) {
+	var svc *rest.OsServiceImage
+
+	if r.Method == http.MethodPut {
+		o, ok := s.lookup(w, r, kindServices)
+		if !ok {
+			return
+		}
+
+		svc = o.value.(*rest.OsServiceImage)
+	}
+
+	f, _, err := r.FormFile(serviceFormField)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "read service file: %v", err)
+
+		return
+	}
+	defer f.Close()
+
+	contents, err := io.ReadAll(f)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "read service file: %v", err)
+
+		return
+	}
+
+	var sf serviceFile
+	if err = yaml.Unmarshal(contents, &sf); err != nil {
+		writeError(w, http.StatusBadRequest, "parse service file: %v", err)
+
+		return
+	}
+
+	if sf.Name == "" || sf.Flavor == "" || sf.Version == "" {
+		writeError(w, http.StatusBadRequest, "service file must specify name, svc_flavor and svc_ver")
+
+		return
+	}
+
+	if svc == nil {
+		svc = &rest.OsServiceImage{ID: s.newID(), Created: now()}
+	}
+
+	svc.ETag = etag()
+	svc.Modified = now()
+	svc.Name = sf.Name
+	svc.Description = sf.Description
+	svc.Category = sf.Category
+	svc.Flavor = sf.Flavor
+	svc.Version = sf.Version
+	svc.Timeout = sf.Timeout
+	svc.Info = nil
+
+	for _, info := range sf.Info {
+		svc.Info = append(svc.Info, rest.PassedInfo{
+			Contents:        info.Contents,
+			Encoding:        rest.Encoding(info.Encoding),
+			Templating:      rest.Templating(info.Templating),
+			TemplatingInput: rest.TemplatingInfo(info.TemplatingInput),
+			Target:          rest.Target(info.Target),
+			Path:            info.Path,
+		})
+	}
+
+	s.put(kindServices, svc)
+
+	writeJSON(w, http.StatusOK, svc)
+}
+
+// sameStrings returns true if a and b hold the same strings in the same order.
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
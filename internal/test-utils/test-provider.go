@@ -1,4 +1,4 @@
-// (C) Copyright 2022 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2022, 2026 Hewlett Packard Enterprise Development LP
 
 package testutils
 
@@ -10,17 +10,27 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
 
 	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/client"
+	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/configuration"
 	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/registration"
 	"github.com/hewlettpackard/hpegl-provider-lib/pkg/provider"
 )
 
 func ProviderFunc() plugin.ProviderFunc {
-	return provider.NewProviderFunc(provider.ServiceRegistrationSlice(registration.Registration{}), providerConfigure)
+	return ProviderFuncWithOpts()
 }
 
-func providerConfigure(p *schema.Provider) schema.ConfigureContextFunc { // nolint staticcheck
+// ProviderFuncWithOpts returns a provider whose Metal client is created with the
+// additional configuration options, e.g. to point it at a fake portal.
+func ProviderFuncWithOpts(opts ...configuration.CreateOpt) plugin.ProviderFunc {
+	return provider.NewProviderFunc(provider.ServiceRegistrationSlice(registration.Registration{}),
+		func(p *schema.Provider) schema.ConfigureContextFunc {
+			return providerConfigure(p, opts...)
+		})
+}
+
+func providerConfigure(p *schema.Provider, opts ...configuration.CreateOpt) schema.ConfigureContextFunc { // nolint staticcheck
 	return func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
-		cli, err := client.InitialiseClient{}.NewClient(d)
+		cli, err := client.InitialiseClient{}.NewClientWithOpts(d, opts...)
 		if err != nil {
 			return nil, diag.Errorf("error in creating client: %s", err)
 		}
@@ -1,4 +1,4 @@
-// (C) Copyright 2022 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2022, 2026 Hewlett Packard Enterprise Development LP
 
 package client
 
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
@@ -26,6 +27,12 @@ type InitialiseClient struct{}
 // The hpegl provider will put *Client at the value of keyForGLClientMap (returned by ServiceName) in
 // the map of clients that it creates and passes down to provider code.  hpegl executes NewClient for each service.
 func (i InitialiseClient) NewClient(r *schema.ResourceData) (interface{}, error) {
+	return i.NewClientWithOpts(r)
+}
+
+// NewClientWithOpts is NewClient with additional configuration create options. The options
+// are applied after those derived from the service block, so they take precedence.
+func (i InitialiseClient) NewClientWithOpts(r *schema.ResourceData, opts ...configuration.CreateOpt) (interface{}, error) {
 	var err error
 
 	defer func() {
@@ -46,12 +53,56 @@ func (i InitialiseClient) NewClient(r *schema.ResourceData) (interface{}, error)
 		return nil, nil
 	}
 
+	ttl := configuration.DefaultAvailableResourcesTTL
+	if v, ok := metalMap["available_resources_ttl"].(string); ok && v != "" {
+		if ttl, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("error in parsing available_resources_ttl: %s", err)
+		}
+	}
+
+	retryAttempts := configuration.DefaultRetryMaxAttempts
+	if v, ok := metalMap["retry_max_attempts"].(int); ok && v > 0 {
+		retryAttempts = v
+	}
+
+	retryWait := configuration.DefaultRetryMaxWait
+	if v, ok := metalMap["retry_max_wait"].(string); ok && v != "" {
+		if retryWait, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("error in parsing retry_max_wait: %s", err)
+		}
+	}
+
+	tlsOptions := configuration.TLSOptions{}
+	tlsOptions.CABundle, _ = metalMap["ca_bundle"].(string)
+	tlsOptions.InsecureSkipVerify, _ = metalMap["insecure_skip_verify"].(bool)
+	tlsOptions.ClientCert, _ = metalMap["client_cert"].(string)
+	tlsOptions.ClientKey, _ = metalMap["client_key"].(string)
+
+	credentials := configuration.Credentials{}
+	credentials.Token, _ = metalMap["token"].(string)
+	credentials.MemberID, _ = metalMap["member_id"].(string)
+	credentials.QjwtFile, _ = metalMap["qjwt_file"].(string)
+	credentials.Profile, _ = metalMap["profile"].(string)
+	credentials.RestURL, _ = metalMap["rest_url"].(string)
+	credentials.ProjectID, _ = metalMap["project_id"].(string)
+	credentials.SpaceName, _ = metalMap["space_name"].(string)
+
+	clientCredentials := configuration.ClientCredentials{}
+	clientCredentials.ClientID, _ = metalMap["client_id"].(string)
+	clientCredentials.ClientSecret, _ = metalMap["client_secret"].(string)
+	clientCredentials.TokenURL, _ = metalMap["token_url"].(string)
+
 	// Initialize the metal client
-	metalConfig, err := configuration.NewConfig("",
+	metalConfig, err := configuration.NewConfig("", append([]configuration.CreateOpt{
 		configuration.WithGLToken(metalMap["gl_token"].(bool)),
 		configuration.WithRole(metalMap["glp_role"].(string)),
 		configuration.WithWorkspace(metalMap["glp_workspace"].(string)),
-	)
+		configuration.WithAvailableResourcesTTL(ttl),
+		configuration.WithRetry(retryAttempts, retryWait),
+		configuration.WithTLS(tlsOptions),
+		configuration.WithCredentials(credentials),
+		configuration.WithClientCredentials(clientCredentials),
+	}, opts...)...)
 	if err != nil {
 		return nil, fmt.Errorf("error in creating metal client: %s", err)
 	}
@@ -0,0 +1,166 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package configuration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenExpiryMargin is how long before its expiry a cached access token is replaced.
+const tokenExpiryMargin = time.Minute
+
+// ClientCredentials are the OAuth2 client credentials used to get GreenLake
+// access tokens from TokenURL.
+type ClientCredentials struct {
+	ClientID     string
+	ClientSecret string
+	TokenURL     string
+}
+
+// WithClientCredentials returns a create option with the provided OAuth2
+// client credentials. GreenLake access tokens are requested with them in
+// place of those in the .gltform file or from the token retrieve function.
+func WithClientCredentials(cc ClientCredentials) CreateOpt {
+	return func(c *Config) {
+		c.clientCredentials = cc
+	}
+}
+
+// isSet reports whether any of the client credentials are set.
+func (cc ClientCredentials) isSet() bool {
+	return cc != ClientCredentials{}
+}
+
+func (cc ClientCredentials) validate() error {
+	var missing []string
+
+	if cc.ClientID == "" {
+		missing = append(missing, "client_id")
+	}
+
+	if cc.ClientSecret == "" {
+		missing = append(missing, "client_secret")
+	}
+
+	if cc.TokenURL == "" {
+		missing = append(missing, "token_url")
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("client credentials are incomplete, %s not set", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// clientCredentialsSource gets access tokens with the OAuth2 client credentials
+// grant, and caches each until shortly before it expires.
+type clientCredentialsSource struct {
+	cc         ClientCredentials
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// tokenResponse is a successful response of the token endpoint.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// tokenErrorResponse is an error response of the token endpoint.
+type tokenErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Token returns the cached access token, or a new one if it is about to expire.
+// It satisfies retrieve.TokenRetrieveFuncCtx.
+func (s *clientCredentialsSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Now().Before(s.expiry) {
+		return s.token, nil
+	}
+
+	token, expiresIn, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	s.token = ""
+
+	// Tokens without an expiry aren't cached.
+	if expiresIn > 0 {
+		s.token = token
+		s.expiry = time.Now().Add(expiresIn - min(tokenExpiryMargin, expiresIn/2))
+	}
+
+	return token, nil
+}
+
+func (s *clientCredentialsSource) fetch(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {s.cc.ClientID},
+		"client_secret": {s.cc.ClientSecret},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cc.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("token request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	httpClient := s.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("token request to %s: %w", s.cc.TokenURL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", 0, fmt.Errorf("token response from %s: %w", s.cc.TokenURL, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var tErr tokenErrorResponse
+		if json.Unmarshal(body, &tErr) == nil && tErr.Error != "" {
+			return "", 0, fmt.Errorf("token request to %s failed with %s: %s %s",
+				s.cc.TokenURL, resp.Status, tErr.Error, tErr.ErrorDescription)
+		}
+
+		return "", 0, fmt.Errorf("token request to %s failed with %s", s.cc.TokenURL, resp.Status)
+	}
+
+	var tResp tokenResponse
+	if err = json.Unmarshal(body, &tResp); err != nil {
+		return "", 0, fmt.Errorf("token response from %s: %w", s.cc.TokenURL, err)
+	}
+
+	if tResp.AccessToken == "" {
+		return "", 0, errors.New("token response from " + s.cc.TokenURL + " has no access_token")
+	}
+
+	return tResp.AccessToken, time.Duration(tResp.ExpiresIn) * time.Second, nil
+}
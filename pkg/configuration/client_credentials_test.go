@@ -0,0 +1,216 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package configuration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestTokenEndpoint starts a stand-in OAuth2 token endpoint that issues
+// numbered tokens valid for expiresIn seconds to client1 with secret1.
+func newTestTokenEndpoint(t *testing.T, expiresIn int, requests *int32) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(requests, 1)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != http.MethodPost || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"unsupported_grant_type"}`))
+
+			return
+		}
+
+		if r.FormValue("client_id") != "client1" || r.FormValue("client_secret") != "secret1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"Client authentication failed"}`))
+
+			return
+		}
+
+		expiry := ""
+		if expiresIn > 0 {
+			expiry = fmt.Sprintf(`,"expires_in":%d`, expiresIn)
+		}
+
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer"%s}`, n, expiry)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestClientCredentialsSource(t *testing.T) {
+	var requests int32
+
+	srv := newTestTokenEndpoint(t, 3600, &requests)
+	s := &clientCredentialsSource{cc: ClientCredentials{ClientID: "client1", ClientSecret: "secret1", TokenURL: srv.URL}}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		token, err := s.Token(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if token != "token-1" {
+			t.Fatalf("expected cached token-1, got %s", token)
+		}
+	}
+
+	if until := time.Until(s.expiry); until > time.Hour-tokenExpiryMargin || until < time.Hour-2*tokenExpiryMargin {
+		t.Fatalf("expected token to be replaced %v before it expires, it is replaced in %v", tokenExpiryMargin, until)
+	}
+
+	// A token that is about to expire is replaced.
+	s.mu.Lock()
+	s.expiry = time.Now().Add(-time.Second)
+	s.mu.Unlock()
+
+	token, err := s.Token(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if token != "token-2" || requests != 2 {
+		t.Fatalf("expected a new token-2 after 2 requests, got %s after %d", token, requests)
+	}
+}
+
+func TestClientCredentialsSourceShortLived(t *testing.T) {
+	tCases := []struct {
+		name        string
+		expiresIn   int
+		expRequests int32
+	}{
+		{
+			name:        "Cached for half its lifetime",
+			expiresIn:   60,
+			expRequests: 1,
+		},
+		{
+			name:        "Without expiry not cached",
+			expRequests: 2,
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests int32
+
+			srv := newTestTokenEndpoint(t, tc.expiresIn, &requests)
+			s := &clientCredentialsSource{cc: ClientCredentials{ClientID: "client1", ClientSecret: "secret1", TokenURL: srv.URL}}
+
+			for i := 0; i < 2; i++ {
+				if _, err := s.Token(context.Background()); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if requests != tc.expRequests {
+				t.Fatalf("expected %d requests, got %d", tc.expRequests, requests)
+			}
+		})
+	}
+}
+
+func TestClientCredentialsSourceError(t *testing.T) {
+	var requests int32
+
+	srv := newTestTokenEndpoint(t, 3600, &requests)
+	s := &clientCredentialsSource{cc: ClientCredentials{ClientID: "client1", ClientSecret: "wrong", TokenURL: srv.URL}}
+
+	_, err := s.Token(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized: invalid_client Client authentication failed") {
+		t.Fatalf("expected invalid_client error, got %v", err)
+	}
+}
+
+func TestClientCredentialsConfig(t *testing.T) {
+	var requests int32
+
+	tokenSrv := newTestTokenEndpoint(t, 3600, &requests)
+
+	portal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-1" || r.Header.Get("Project") != "project1" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer portal.Close()
+
+	tCases := []struct {
+		name    string
+		glToken bool
+		cc      ClientCredentials
+		creds   Credentials
+		expErr  string
+	}{
+		{
+			name:    "Client credentials",
+			glToken: true,
+			cc:      ClientCredentials{ClientID: "client1", ClientSecret: "secret1", TokenURL: tokenSrv.URL},
+			creds:   Credentials{RestURL: portal.URL, ProjectID: "project1"},
+		},
+		{
+			name:    "Incomplete client credentials",
+			glToken: true,
+			cc:      ClientCredentials{ClientID: "client1"},
+			creds:   Credentials{RestURL: portal.URL},
+			expErr:  "client credentials are incomplete, client_secret, token_url not set",
+		},
+		{
+			name:    "Client credentials without rest_url",
+			glToken: true,
+			cc:      ClientCredentials{ClientID: "client1", ClientSecret: "secret1", TokenURL: tokenSrv.URL},
+			expErr:  "client credentials are set in the provider configuration but rest_url is not",
+		},
+		{
+			name:   "Client credentials with Metal tokens",
+			cc:     ClientCredentials{ClientID: "client1", ClientSecret: "secret1", TokenURL: tokenSrv.URL},
+			creds:  Credentials{RestURL: portal.URL},
+			expErr: "only used with GreenLake tokens",
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewConfig("", WithGLToken(tc.glToken), WithCredentials(tc.creds), WithClientCredentials(tc.cc))
+			if tc.expErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for i := 0; i < 2; i++ {
+				//nolint:bodyclose // Response body is closed by metal client.
+				if _, _, err = c.Client.AvailableResourcesApi.List(testContext(t, c), nil); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if n := atomic.LoadInt32(&requests); n != 1 {
+				t.Fatalf("expected 1 token request, got %d", n)
+			}
+		})
+	}
+}
@@ -1,4 +1,4 @@
-// (C) Copyright 2020-2023 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2023, 2026 Hewlett Packard Enterprise Development LP
 
 package configuration
 
@@ -6,13 +6,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"net/url"
-	"os"
 	"strings"
+	"time"
 
 	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
 	"github.com/hewlettpackard/hpegl-metal-terraform-resources/pkg/constants"
-	"github.com/hewlettpackard/hpegl-provider-lib/pkg/gltform"
 	"github.com/hewlettpackard/hpegl-provider-lib/pkg/token/retrieve"
 )
 
@@ -33,11 +33,29 @@ type Config struct {
 	role       string
 	trf        retrieve.TokenRetrieveFuncCtx
 	useGLToken bool
-	context    context.Context
+	qjwt       *Qjwt
+	httpClient *http.Client
+	tls        TLSOptions
+	// login details from the provider configuration and where those used were found
+	credentials       Credentials
+	clientCredentials ClientCredentials
+	tokenSource       *clientCredentialsSource
+	tokens            *tokenCache
+	source            string
+	context           context.Context
+	// failed requests are retried up to retryMaxAttempts times
+	retryMaxAttempts int
+	retryMaxWait     time.Duration
+	// available resources are cached here for the life of the provider
+	resources *resourceCache
 	// Exported fields
 	PortalURL string
 	Client    *rest.APIClient
-	// we will cache this here for the life of the provider
+	// AvailableResources holds the available resources as of the last call to
+	// RefreshAvailableResources, which sets it under the lock of the cache.
+	//
+	// Deprecated: use GetAvailableResources, which keeps them up to date and is
+	// safe for concurrent use.
 	AvailableResources rest.AvailableResources
 }
 
@@ -75,20 +93,101 @@ func WithTRF(trf retrieve.TokenRetrieveFuncCtx) CreateOpt {
 	}
 }
 
+// WithQjwt returns a create option with the provided Metal login details.
+// These are used in place of the contents of the .qjwt file.
+func WithQjwt(q *Qjwt) CreateOpt {
+	return func(c *Config) {
+		c.qjwt = q
+	}
+}
+
+// WithHTTPClient returns a create option with the HTTP client used to
+// talk to the portal. If not set http.DefaultClient is used.
+func WithHTTPClient(h *http.Client) CreateOpt {
+	return func(c *Config) {
+		c.httpClient = h
+	}
+}
+
+// WithRetry returns a create option with the number of times a request is
+// sent to the portal before its failure is returned, and the longest wait
+// between attempts. A maxAttempts of 1 disables retries.
+func WithRetry(maxAttempts int, maxWait time.Duration) CreateOpt {
+	return func(c *Config) {
+		c.retryMaxAttempts = maxAttempts
+		c.retryMaxWait = maxWait
+	}
+}
+
+// WithAvailableResourcesTTL returns a create option with the time for which
+// the available resources are cached. A TTL of zero caches them until they are
+// invalidated.
+func WithAvailableResourcesTTL(ttl time.Duration) CreateOpt {
+	return func(c *Config) {
+		if c.resources != nil {
+			c.resources.ttl = ttl
+		}
+	}
+}
+
+// GetAvailableResources returns the cached available resources. They are
+// fetched from the portal first if the cache has expired or any of the
+// specified kinds, or any kind at all if none are specified, have been
+// invalidated. Concurrent callers share a single fetch.
+func (c *Config) GetAvailableResources(kinds ...ResourceKind) (rest.AvailableResources, error) {
+	return c.resources.get(kindMask(kinds), c.listAvailableResources)
+}
+
+// InvalidateAvailableResources marks the specified kinds of cached available
+// resources, or all of them if none are specified, as stale so that they are
+// fetched again on next use.
+func (c *Config) InvalidateAvailableResources(kinds ...ResourceKind) {
+	c.resources.invalidate(kindMask(kinds))
+}
+
+// RefreshAvailableResources fetches all available resources from the portal,
+// and sets the deprecated AvailableResources field to them.
 func (c *Config) RefreshAvailableResources() error {
-	ctx := c.GetContext()
+	c.InvalidateAvailableResources()
 
-	resources, _, err := c.Client.AvailableResourcesApi.List(ctx, nil)
+	resources, err := c.GetAvailableResources()
 	if err != nil {
 		return err
 	}
 
+	c.resources.mu.Lock()
+	defer c.resources.mu.Unlock()
+
 	c.AvailableResources = resources
+
 	return nil
 }
 
+func (c *Config) listAvailableResources() (rest.AvailableResources, error) {
+	if c.Client == nil {
+		return rest.AvailableResources{}, fmt.Errorf("client is not initialised")
+	}
+
+	ctx, err := c.GetContextWithError()
+	if err != nil {
+		return rest.AvailableResources{}, err
+	}
+
+	resources, _, err := c.Client.AvailableResourcesApi.List(ctx, nil)
+	if err != nil {
+		return rest.AvailableResources{}, err
+	}
+
+	return resources, nil
+}
+
 func (c *Config) GetLocationName(locationID string) (string, error) {
-	for _, loc := range c.AvailableResources.Locations {
+	available, err := c.GetAvailableResources(KindLocations)
+	if err != nil {
+		return "", err
+	}
+
+	for _, loc := range available.Locations {
 		if loc.ID == locationID {
 			return makeLocationName(string(loc.Country), loc.Region, loc.DataCenter), nil
 		}
@@ -98,10 +197,15 @@ func (c *Config) GetLocationName(locationID string) (string, error) {
 }
 
 func (c *Config) GetLocationID(locationName string) (locationID string, err error) {
+	available, err := c.GetAvailableResources(KindLocations)
+	if err != nil {
+		return "", err
+	}
+
 	locations := []string{}
 	pieces := strings.Split(locationName, ":")
 
-	for _, loc := range c.AvailableResources.Locations {
+	for _, loc := range available.Locations {
 		if len(pieces) == 3 {
 			if string(loc.Country) == pieces[0] && loc.Region == pieces[1] && loc.DataCenter == pieces[2] {
 				return loc.ID, nil
@@ -113,7 +217,12 @@ func (c *Config) GetLocationID(locationName string) (locationID string, err erro
 }
 
 func (c *Config) GetVolumeFlavorName(flavorID string) (string, error) {
-	for _, vf := range c.AvailableResources.VolumeFlavors {
+	available, err := c.GetAvailableResources(KindStorage)
+	if err != nil {
+		return "", err
+	}
+
+	for _, vf := range available.VolumeFlavors {
 		if flavorID == vf.ID {
 			return vf.Name, nil
 		}
@@ -122,7 +231,12 @@ func (c *Config) GetVolumeFlavorName(flavorID string) (string, error) {
 }
 
 func (c *Config) GetStoragePoolName(storagePoolID string) (string, error) {
-	for _, sp := range c.AvailableResources.StoragePools {
+	available, err := c.GetAvailableResources(KindStorage)
+	if err != nil {
+		return "", err
+	}
+
+	for _, sp := range available.StoragePools {
 		if storagePoolID == sp.ID {
 			return sp.Name, nil
 		}
@@ -131,9 +245,15 @@ func (c *Config) GetStoragePoolName(storagePoolID string) (string, error) {
 	return "", fmt.Errorf("StoragePoolID %s not found", storagePoolID)
 }
 
+// GetStoragePoolID returns the ID of the storage pool with the given name or ID.
 func (c *Config) GetStoragePoolID(storagePoolName string) (string, error) {
-	for _, sp := range c.AvailableResources.StoragePools {
-		if storagePoolName == sp.Name {
+	available, err := c.GetAvailableResources(KindStorage)
+	if err != nil {
+		return "", err
+	}
+
+	for _, sp := range available.StoragePools {
+		if storagePoolName == sp.Name || storagePoolName == sp.ID {
 			return sp.ID, nil
 		}
 	}
@@ -145,18 +265,18 @@ func (c *Config) GetStoragePoolID(storagePoolName string) (string, error) {
 // If the token retrieve function is nil the context in Config is returned
 // If there is a token retrieve function it is executed to retrieve a GL IAM token, which is
 // placed in the context before it is returned.
-// If we get an error we log it and return the context with the new token.
+// If we get an error we log it and return the context without a token.
+//
+// Deprecated: use GetContextWithError, which returns the error.
 func (c *Config) GetContext() context.Context {
-	if c.trf == nil {
-		return c.context
-	}
-
-	token, err := c.trf(c.context)
+	ctx, err := c.GetContextWithError()
 	if err != nil {
 		log.Printf("error in retrieving token %s", err)
+
+		return c.context
 	}
 
-	return context.WithValue(c.context, rest.ContextAccessToken, token)
+	return ctx
 }
 
 func makeLocationName(country, region, dataCenter string) string {
@@ -170,6 +290,10 @@ func NewConfig(portalURL string, opts ...CreateOpt) (*Config, error) {
 
 	config.useGLToken = false
 	config.trf = nil
+	config.resources = &resourceCache{ttl: DefaultAvailableResourcesTTL}
+	config.tokens = &tokenCache{}
+	config.retryMaxAttempts = DefaultRetryMaxAttempts
+	config.retryMaxWait = DefaultRetryMaxWait
 
 	// run overrides
 	for _, opt := range opts {
@@ -179,29 +303,25 @@ func NewConfig(portalURL string, opts ...CreateOpt) (*Config, error) {
 	}
 
 	if config.useGLToken || config.trf != nil {
-		// Use GetGLConfig from gltform
-		glconfig, err := gltform.GetGLConfig()
-		if err != nil {
-			return nil, fmt.Errorf("error reading GL token file:  %w", err)
+		if err := config.loadGLCredentials(); err != nil {
+			return nil, err
 		}
-
-		config.restURL = glconfig.RestURL
-		config.token = glconfig.Token
-		config.user = glconfig.ProjectID
-		config.space = glconfig.SpaceName
 	} else {
-		qtoken, err := getQConfig()
+		qtoken, err := config.loadMetalCredentials()
 		if err != nil {
-			return nil, fmt.Errorf("error reading Metal token file:  %w", err)
+			return nil, err
 		}
 
 		if portalURL != "" && portalURL != qtoken.OriginalURL {
-			return nil, fmt.Errorf("Provider explicitly states portal is %q yet token is valid for %q", portalURL, qtoken.OriginalURL)
+			return nil, fmt.Errorf("Provider explicitly states portal is %q yet token in %s is valid for %q",
+				portalURL, config.source, qtoken.OriginalURL)
 		}
 		config.restURL = qtoken.RestURL
 		config.token = qtoken.Token
 		config.user = qtoken.MemberID
 		config.PortalURL = qtoken.OriginalURL
+		// no_tls marks a portal whose certificate can't be verified
+		config.tls.InsecureSkipVerify = config.tls.InsecureSkipVerify || qtoken.NoTLS
 	}
 
 	// add access token for auth to Client Context as required by the Client API
@@ -217,9 +337,22 @@ func NewConfig(portalURL string, opts ...CreateOpt) (*Config, error) {
 
 	cfg.BasePath = basePath
 
+	httpClient := config.httpClient
+	if httpClient == nil {
+		if httpClient, err = config.tls.newHTTPClient(); err != nil {
+			return nil, fmt.Errorf("configuration error: %v", err)
+		}
+	}
+
+	cfg.HTTPClient = withRetries(httpClient, config.retryMaxAttempts, config.retryMaxWait)
+
+	if config.tokenSource != nil {
+		config.tokenSource.httpClient = cfg.HTTPClient
+	}
+
 	if config.useGLToken || config.trf != nil {
 		if err := validateGLConfig(*config); err != nil {
-			return config, fmt.Errorf("configuration error: %v", err)
+			return config, fmt.Errorf("configuration error in %s: %v", config.source, err)
 		}
 
 		// Add required headers if GL authentication method
@@ -240,7 +373,7 @@ func NewConfig(portalURL string, opts ...CreateOpt) (*Config, error) {
 		}
 	} else {
 		if err := validateMetalConfig(*config); err != nil {
-			return config, fmt.Errorf("configuration error: %v", err)
+			return config, fmt.Errorf("configuration error in %s: %v", config.source, err)
 		}
 
 		// Add membership field to header if Q authentication method
@@ -283,19 +416,6 @@ func validateGLConfig(config Config) error {
 	return nil
 }
 
-func getQConfig() (qjwt *Qjwt, err error) {
-	homeDir, _ := os.UserHomeDir()
-	workingDir, _ := os.Getwd()
-
-	for _, p := range []string{homeDir, workingDir} {
-		qjwt, err = loadConfig(p)
-		if err == nil {
-			break
-		}
-	}
-	return qjwt, err
-}
-
 // IsHosterContext determines whether the provider configuration
 // is project scope or hoster scope when GL IAM token is used.
 // Project operations with Metal token not supported yet via Terraform, so
@@ -314,10 +434,15 @@ func (c *Config) IsHosterContext() bool {
 	return false
 }
 
-// GetVolumeCollectionID returns volume collection ID from volume collection name.
+// GetVolumeCollectionID returns volume collection ID from volume collection name or ID.
 func (c *Config) GetVolumeCollectionID(vcolName string) (string, error) {
-	for _, vc := range c.AvailableResources.VolumeCollections {
-		if vcolName == vc.Name {
+	available, err := c.GetAvailableResources(KindStorage)
+	if err != nil {
+		return "", err
+	}
+
+	for _, vc := range available.VolumeCollections {
+		if vcolName == vc.Name || vcolName == vc.ID {
 			return vc.ID, nil
 		}
 	}
@@ -331,7 +456,12 @@ func (c *Config) GetVolumeCollectionName(vcolID string) (string, error) {
 		return "", nil
 	}
 
-	for _, vc := range c.AvailableResources.VolumeCollections {
+	available, err := c.GetAvailableResources(KindStorage)
+	if err != nil {
+		return "", err
+	}
+
+	for _, vc := range available.VolumeCollections {
 		if vcolID == vc.ID {
 			return vc.Name, nil
 		}
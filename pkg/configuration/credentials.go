@@ -0,0 +1,188 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package configuration
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hewlettpackard/hpegl-provider-lib/pkg/gltform"
+)
+
+// Credentials are login details supplied in the provider configuration.
+//
+// With Metal tokens they are used in this order of precedence:
+//  1. Token, with MemberID and RestURL.
+//  2. The .qjwt file at QjwtFile.
+//  3. The .qjwt file in the home directory, then in the current directory.
+//
+// Profile selects the login details in a .qjwt file that has several.
+//
+// With GreenLake tokens they are used in this order of precedence:
+//  1. Token, with RestURL and the optional ProjectID and SpaceName.
+//  2. Access tokens requested with the client credentials, see WithClientCredentials,
+//     with RestURL and the optional ProjectID and SpaceName.
+//  3. The .gltform file in the current directory, then in the home directory.
+type Credentials struct {
+	// Token is a Metal token or a GreenLake access token, depending on which are used.
+	Token string
+	// MemberID is the Metal membership that Token is valid for.
+	MemberID string
+	// QjwtFile is the path of a .qjwt file.
+	QjwtFile string
+	// Profile is the name of the login details to use in the .qjwt file.
+	Profile string
+	// RestURL is the portal URL.
+	RestURL string
+	// ProjectID and SpaceName identify the GreenLake project.
+	ProjectID string
+	SpaceName string
+}
+
+// WithCredentials returns a create option with the provided login details.
+func WithCredentials(c Credentials) CreateOpt {
+	return func(cfg *Config) {
+		cfg.credentials = c
+	}
+}
+
+// loadGLCredentials sets the GreenLake login details of c from the
+// credentials or the .gltform file.
+func (c *Config) loadGLCredentials() error {
+	cr := c.credentials
+
+	if cr.MemberID != "" || cr.QjwtFile != "" || cr.Profile != "" {
+		return errors.New("member_id, qjwt_file and profile are only used with Metal tokens, set gl_token to false to use them")
+	}
+
+	if cr.Token != "" {
+		if cr.RestURL == "" {
+			return errors.New("token is set in the provider configuration but rest_url is not")
+		}
+
+		c.source = "token in the provider configuration"
+		c.restURL = cr.RestURL
+		c.token = cr.Token
+		c.user = cr.ProjectID
+		c.space = cr.SpaceName
+		// The token takes precedence over any token retrieve function.
+		c.trf = nil
+
+		return nil
+	}
+
+	if c.clientCredentials.isSet() {
+		if err := c.clientCredentials.validate(); err != nil {
+			return err
+		}
+
+		if cr.RestURL == "" {
+			return errors.New("client credentials are set in the provider configuration but rest_url is not")
+		}
+
+		c.source = "client credentials for " + c.clientCredentials.TokenURL
+		c.restURL = cr.RestURL
+		c.user = cr.ProjectID
+		c.space = cr.SpaceName
+		c.tokenSource = &clientCredentialsSource{cc: c.clientCredentials}
+		c.trf = c.tokenSource.Token
+
+		return nil
+	}
+
+	glconfig, err := gltform.GetGLConfig()
+	if err != nil {
+		return fmt.Errorf("error reading GL token file:  %w", err)
+	}
+
+	c.source = "GL token file .gltform"
+	c.restURL = glconfig.RestURL
+	c.token = glconfig.Token
+	c.user = glconfig.ProjectID
+	c.space = glconfig.SpaceName
+
+	return nil
+}
+
+// loadMetalCredentials sets the Metal login details of c from the
+// credentials or a .qjwt file.
+func (c *Config) loadMetalCredentials() (*Qjwt, error) {
+	if c.qjwt != nil {
+		c.source = "Metal login details"
+
+		return c.qjwt, nil
+	}
+
+	cr := c.credentials
+
+	if c.clientCredentials.isSet() {
+		return nil, errors.New("client_id, client_secret and token_url are only used with GreenLake tokens, " +
+			"set gl_token to true to use them")
+	}
+
+	if cr.Token != "" {
+		switch {
+		case cr.MemberID == "":
+			return nil, errors.New("token is set in the provider configuration but member_id is not")
+		case cr.RestURL == "":
+			return nil, errors.New("token is set in the provider configuration but rest_url is not")
+		}
+
+		c.source = "token in the provider configuration"
+
+		return &Qjwt{
+			RestURL:     cr.RestURL,
+			OriginalURL: cr.RestURL,
+			Token:       cr.Token,
+			MemberID:    cr.MemberID,
+		}, nil
+	}
+
+	if cr.MemberID != "" {
+		return nil, errors.New("member_id is set in the provider configuration but token is not")
+	}
+
+	path := cr.QjwtFile
+	if path == "" {
+		var err error
+
+		if path, err = findQjwtFile(); err != nil {
+			return nil, err
+		}
+	}
+
+	c.source = "Metal token file " + path
+	if cr.Profile != "" {
+		c.source += " profile " + cr.Profile
+	}
+
+	q, err := loadQjwtFile(path, cr.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s:  %w", c.source, err)
+	}
+
+	return q, nil
+}
+
+// findQjwtFile returns the path of the .qjwt file in the home directory, or
+// failing that in the current directory.
+func findQjwtFile() (string, error) {
+	homeDir, _ := os.UserHomeDir()
+	workingDir, _ := os.Getwd()
+
+	for _, dir := range []string{homeDir, workingDir} {
+		if dir == "" {
+			continue
+		}
+
+		path := filepath.Join(dir, QjwtExtension)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("no Metal token file %s found in %q or %q, set token and member_id or qjwt_file instead",
+		QjwtExtension, homeDir, workingDir)
+}
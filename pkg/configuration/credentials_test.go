@@ -0,0 +1,188 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package configuration
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	homeQJWT = `rest_url: https://home-portal
+original_url: https://home-portal
+jwt: home-token
+member_id: home-member
+`
+	fileQJWT = `rest_url: https://file-portal
+original_url: https://file-portal
+jwt: file-token
+member_id: file-member
+`
+)
+
+func TestCredentials(t *testing.T) {
+	dir := t.TempDir()
+	qjwtFile := writeTestFile(t, "ci.qjwt", []byte(fileQJWT))
+	noJWTFile := writeTestFile(t, "nojwt.qjwt", []byte("rest_url: https://file-portal\nmember_id: file-member\n"))
+	profilesFile := writeTestFile(t, "profiles.qjwt", []byte(`profiles:
+  dev:
+    rest_url: https://dev-portal
+    original_url: https://dev-portal
+    jwt: dev-token
+    member_id: dev-member
+  prod:
+    rest_url: https://prod-portal
+    original_url: https://prod-portal
+    jwt: prod-token
+    member_id: prod-member
+`))
+
+	tCases := []struct {
+		name       string
+		homeQjwt   bool
+		glToken    bool
+		portalURL  string
+		creds      Credentials
+		expErr     string
+		expRestURL string
+		expToken   string
+		expUser    string
+	}{
+		{
+			name:       "Default .qjwt file",
+			homeQjwt:   true,
+			expRestURL: "https://home-portal",
+			expToken:   "home-token",
+			expUser:    "home-member",
+		},
+		{
+			name:       "qjwt_file before default .qjwt file",
+			homeQjwt:   true,
+			creds:      Credentials{QjwtFile: qjwtFile},
+			expRestURL: "https://file-portal",
+			expToken:   "file-token",
+			expUser:    "file-member",
+		},
+		{
+			name:       "token before qjwt_file",
+			homeQjwt:   true,
+			creds:      Credentials{Token: "token", MemberID: "member", RestURL: "https://portal", QjwtFile: qjwtFile},
+			expRestURL: "https://portal",
+			expToken:   "token",
+			expUser:    "member",
+		},
+		{
+			name:       "Profile",
+			creds:      Credentials{QjwtFile: profilesFile, Profile: "prod"},
+			portalURL:  "https://prod-portal",
+			expRestURL: "https://prod-portal",
+			expToken:   "prod-token",
+			expUser:    "prod-member",
+		},
+		{
+			name:      "Portal does not match profile",
+			creds:     Credentials{QjwtFile: profilesFile, Profile: "dev"},
+			portalURL: "https://prod-portal",
+			expErr:    "token in Metal token file " + profilesFile + " profile dev is valid for \"https://dev-portal\"",
+		},
+		{
+			name:   "Profile not found",
+			creds:  Credentials{QjwtFile: profilesFile, Profile: "test"},
+			expErr: `profile "test" not found, the profiles are dev, prod`,
+		},
+		{
+			name:   "Profile not selected",
+			creds:  Credentials{QjwtFile: profilesFile},
+			expErr: "select one of the profiles dev, prod with profile or HPEGL_METAL_PROFILE",
+		},
+		{
+			name:   "No .qjwt file",
+			expErr: "no Metal token file .qjwt found",
+		},
+		{
+			name:   "qjwt_file not found",
+			creds:  Credentials{QjwtFile: filepath.Join(dir, "missing.qjwt")},
+			expErr: "error reading Metal token file " + filepath.Join(dir, "missing.qjwt"),
+		},
+		{
+			name:   "qjwt_file without jwt",
+			creds:  Credentials{QjwtFile: noJWTFile},
+			expErr: "configuration error in Metal token file " + noJWTFile + ": jwt is not set",
+		},
+		{
+			name:   "token without member_id",
+			creds:  Credentials{Token: "token", RestURL: "https://portal"},
+			expErr: "token is set in the provider configuration but member_id is not",
+		},
+		{
+			name:   "token without rest_url",
+			creds:  Credentials{Token: "token", MemberID: "member"},
+			expErr: "token is set in the provider configuration but rest_url is not",
+		},
+		{
+			name:     "member_id without token",
+			homeQjwt: true,
+			creds:    Credentials{MemberID: "member"},
+			expErr:   "member_id is set in the provider configuration but token is not",
+		},
+		{
+			name:       "GL token",
+			glToken:    true,
+			creds:      Credentials{Token: "gl-token", RestURL: "https://portal", ProjectID: "project"},
+			expRestURL: "https://portal",
+			expToken:   "gl-token",
+			expUser:    "project",
+		},
+		{
+			name:    "GL token without rest_url",
+			glToken: true,
+			creds:   Credentials{Token: "gl-token"},
+			expErr:  "token is set in the provider configuration but rest_url is not",
+		},
+		{
+			name:    "GL token with profile",
+			glToken: true,
+			creds:   Credentials{Token: "gl-token", RestURL: "https://portal", Profile: "dev"},
+			expErr:  "only used with Metal tokens",
+		},
+		{
+			name:    "GL token with qjwt_file",
+			glToken: true,
+			creds:   Credentials{Token: "gl-token", RestURL: "https://portal", QjwtFile: qjwtFile},
+			expErr:  "only used with Metal tokens",
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+
+			if tc.homeQjwt {
+				if err := os.WriteFile(filepath.Join(home, QjwtExtension), []byte(homeQJWT), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			c, err := NewConfig(tc.portalURL, WithGLToken(tc.glToken), WithCredentials(tc.creds))
+			if tc.expErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if c.restURL != tc.expRestURL || c.token != tc.expToken || c.user != tc.expUser {
+				t.Fatalf("expected %s %s %s, got %s %s %s",
+					tc.expRestURL, tc.expToken, tc.expUser, c.restURL, c.token, c.user)
+			}
+		})
+	}
+}
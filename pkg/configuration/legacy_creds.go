@@ -1,17 +1,26 @@
-// (C) Copyright 2020-2022 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2022, 2026 Hewlett Packard Enterprise Development LP
 
 package configuration
 
 import (
+	"errors"
+	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 )
 
-const QjwtExtension = ".qjwt"
+const (
+	QjwtExtension = ".qjwt"
+
+	// DefaultProfile is the profile used when none is selected. Login details
+	// at the top level of a .qjwt file belong to it.
+	DefaultProfile = "default"
+)
 
 // Qjwt declares the contents of the login file.
 type Qjwt struct {
@@ -23,24 +32,116 @@ type Qjwt struct {
 	NoTLS       bool   `yaml:"no_tls"`
 }
 
-func loadConfig(dir string) (*Qjwt, error) {
-	f, err := os.Open(filepath.Clean(filepath.Join(dir, QjwtExtension)))
+// qjwtDocument is a document in a .qjwt file. A file holds either a single
+// document with the login details of one portal, several documents each named
+// by profile, or a document with a profiles map of name to login details.
+type qjwtDocument struct {
+	Qjwt     `yaml:",inline"`
+	Profile  string          `yaml:"profile"`
+	Profiles map[string]Qjwt `yaml:"profiles"`
+}
+
+func loadQjwtFile(path, profile string) (*Qjwt, error) {
+	f, err := os.Open(filepath.Clean(path))
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
-	return parseStream(f)
+	return parseProfile(f, profile)
 }
 
 func parseStream(s io.Reader) (*Qjwt, error) {
-	contents, err := ioutil.ReadAll(s)
+	return parseProfile(s, "")
+}
+
+// parseProfile returns the login details of the named profile. If no profile is
+// named the default profile is returned, or the only profile if there is one.
+func parseProfile(s io.Reader, profile string) (*Qjwt, error) {
+	profiles, err := parseProfiles(s)
 	if err != nil {
 		return nil, err
 	}
 
-	q := &Qjwt{}
-	if err = yaml.Unmarshal(contents, q); err != nil {
-		return nil, err
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
 	}
+
+	sort.Strings(names)
+
+	if profile == "" {
+		if q, ok := profiles[DefaultProfile]; ok {
+			return q, nil
+		}
+
+		if len(profiles) == 1 {
+			return profiles[names[0]], nil
+		}
+
+		return nil, fmt.Errorf("select one of the profiles %s with profile or HPEGL_METAL_PROFILE",
+			strings.Join(names, ", "))
+	}
+
+	q, ok := profiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found, the profiles are %s", profile, strings.Join(names, ", "))
+	}
+
 	return q, nil
 }
+
+// parseProfiles returns the login details in all the documents of s by profile name.
+func parseProfiles(s io.Reader) (map[string]*Qjwt, error) {
+	profiles := make(map[string]*Qjwt)
+
+	add := func(name string, q Qjwt) error {
+		if _, ok := profiles[name]; ok {
+			return fmt.Errorf("profile %q is defined more than once", name)
+		}
+
+		profiles[name] = &q
+
+		return nil
+	}
+
+	dec := yaml.NewDecoder(s)
+
+	for {
+		var doc qjwtDocument
+
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for name, q := range doc.Profiles {
+			if err = add(name, q); err != nil {
+				return nil, err
+			}
+		}
+
+		// A document of profiles may not have login details of its own.
+		if doc.Profiles != nil && doc.Profile == "" && doc.Qjwt == (Qjwt{}) {
+			continue
+		}
+
+		name := doc.Profile
+		if name == "" {
+			name = DefaultProfile
+		}
+
+		if err = add(name, doc.Qjwt); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(profiles) == 0 {
+		return nil, errors.New("no login details found")
+	}
+
+	return profiles, nil
+}
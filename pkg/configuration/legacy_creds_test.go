@@ -1,9 +1,10 @@
-// (C) Copyright 2020-2022 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2022, 2026 Hewlett Packard Enterprise Development LP
 
 package configuration
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 )
 
@@ -14,6 +15,28 @@ user: h1@quattronetworks.com
 jwt: eyJhbGciOiJSUzI1NiIsInR5cCI6IkpXVCIsImtpZCI6IlJFTk
 member_id: B901063C-DB35-4FF8-8A78-EA44C62C61F8
 no_tls: true
+`
+
+	fakeMultiDocQJWT = `profile: dev
+rest_url: https://dev-portal
+jwt: dev-token
+member_id: dev-member
+---
+profile: prod
+rest_url: https://prod-portal
+jwt: prod-token
+member_id: prod-member
+`
+
+	fakeProfilesQJWT = `profiles:
+  dev:
+    rest_url: https://dev-portal
+    jwt: dev-token
+    member_id: dev-member
+  prod:
+    rest_url: https://prod-portal
+    jwt: prod-token
+    member_id: prod-member
 `
 )
 
@@ -35,3 +58,100 @@ func TestGetConfig(t *testing.T) {
 		t.Fatal("MemberID empty")
 	}
 }
+
+func TestParseProfile(t *testing.T) {
+	tCases := []struct {
+		name       string
+		qjwt       string
+		profile    string
+		expRestURL string
+		expErr     string
+	}{
+		{
+			name:       "Single document",
+			qjwt:       fakeQJWT,
+			expRestURL: "http://15.242.208.109",
+		},
+		{
+			name:       "Single document default profile",
+			qjwt:       fakeQJWT,
+			profile:    DefaultProfile,
+			expRestURL: "http://15.242.208.109",
+		},
+		{
+			name:    "Single document named profile",
+			qjwt:    fakeQJWT,
+			profile: "dev",
+			expErr:  `profile "dev" not found, the profiles are default`,
+		},
+		{
+			name:       "Multiple documents",
+			qjwt:       fakeMultiDocQJWT,
+			profile:    "prod",
+			expRestURL: "https://prod-portal",
+		},
+		{
+			name:   "Multiple documents without a profile",
+			qjwt:   fakeMultiDocQJWT,
+			expErr: "select one of the profiles dev, prod",
+		},
+		{
+			name:       "Multiple documents with a default",
+			qjwt:       fakeQJWT + "---\n" + fakeMultiDocQJWT,
+			expRestURL: "http://15.242.208.109",
+		},
+		{
+			name:       "Profiles map",
+			qjwt:       fakeProfilesQJWT,
+			profile:    "dev",
+			expRestURL: "https://dev-portal",
+		},
+		{
+			name:       "Profiles map with a default",
+			qjwt:       fakeQJWT + fakeProfilesQJWT,
+			expRestURL: "http://15.242.208.109",
+		},
+		{
+			name:       "Profiles map with a single profile",
+			qjwt:       "profiles:\n  dev:\n    rest_url: https://dev-portal\n",
+			expRestURL: "https://dev-portal",
+		},
+		{
+			name:    "Unknown profile",
+			qjwt:    fakeProfilesQJWT,
+			profile: "test",
+			expErr:  `profile "test" not found, the profiles are dev, prod`,
+		},
+		{
+			name:   "Duplicate profile",
+			qjwt:   fakeProfilesQJWT + "---\n" + fakeMultiDocQJWT,
+			expErr: `profile "dev" is defined more than once`,
+		},
+		{
+			name:   "Empty",
+			qjwt:   "",
+			expErr: "no login details found",
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := parseProfile(bytes.NewBufferString(tc.qjwt), tc.profile)
+			if tc.expErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if q.RestURL != tc.expRestURL {
+				t.Fatalf("expected rest_url %q, got %q", tc.expRestURL, q.RestURL)
+			}
+		})
+	}
+}
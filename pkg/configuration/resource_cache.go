@@ -0,0 +1,155 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package configuration
+
+import (
+	"sync"
+	"time"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+)
+
+// DefaultAvailableResourcesTTL is how long cached available resources are used
+// before they are fetched again from the portal.
+const DefaultAvailableResourcesTTL = 5 * time.Minute
+
+// ResourceKind identifies a category of available resources that can be
+// invalidated independently of the others.
+type ResourceKind uint
+
+const (
+	// KindImages covers OS service images.
+	KindImages ResourceKind = 1 << iota
+	// KindLocations covers locations.
+	KindLocations
+	// KindNetworks covers networks.
+	KindNetworks
+	// KindMachines covers machine sizes and machine inventory.
+	KindMachines
+	// KindVolumes covers volumes and storage inventory.
+	KindVolumes
+	// KindStorage covers volume flavors, storage pools and volume collections.
+	KindStorage
+	// KindSSHKeys covers SSH keys.
+	KindSSHKeys
+
+	kindAll = KindImages | KindLocations | KindNetworks | KindMachines | KindVolumes | KindStorage | KindSSHKeys
+)
+
+func kindMask(kinds []ResourceKind) ResourceKind {
+	if len(kinds) == 0 {
+		return kindAll
+	}
+
+	var mask ResourceKind
+	for _, k := range kinds {
+		mask |= k
+	}
+
+	return mask
+}
+
+// resourceCache is a concurrency-safe cache of the available resources.
+// Concurrent requests for stale resources share a single fetch from the portal.
+// The zero value is an empty cache whose entries never expire, a nil cache
+// fetches the resources on every request.
+type resourceCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	resources rest.AvailableResources
+	fetched   time.Time
+	loaded    bool
+	stale     ResourceKind
+	inflight  *refreshCall
+}
+
+// refreshCall is a fetch of the available resources that is in progress.
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+// freshLocked reports whether the cached resources can be used for the kinds in mask.
+// The caller must hold rc.mu.
+func (rc *resourceCache) freshLocked(mask ResourceKind) bool {
+	if !rc.loaded || rc.stale&mask != 0 {
+		return false
+	}
+
+	return rc.ttl <= 0 || time.Since(rc.fetched) < rc.ttl
+}
+
+// get returns the cached resources, calling fetch first if any of the kinds in
+// mask are stale or the cache has expired.
+func (rc *resourceCache) get(mask ResourceKind, fetch func() (rest.AvailableResources, error),
+) (rest.AvailableResources, error) {
+	if rc == nil {
+		return fetch()
+	}
+
+	rc.mu.Lock()
+
+	for {
+		if rc.freshLocked(mask) {
+			defer rc.mu.Unlock()
+
+			return rc.resources, nil
+		}
+
+		call := rc.inflight
+		if call == nil {
+			break
+		}
+
+		// Wait for the fetch in progress then check again, since the resources
+		// may have been invalidated while it was running.
+		rc.mu.Unlock()
+		<-call.done
+
+		if call.err != nil {
+			return rest.AvailableResources{}, call.err
+		}
+
+		rc.mu.Lock()
+	}
+
+	call := &refreshCall{done: make(chan struct{})}
+	rc.inflight = call
+	cleared := rc.stale
+	rc.mu.Unlock()
+
+	resources, err := fetch()
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.inflight = nil
+	call.err = err
+
+	close(call.done)
+
+	if err != nil {
+		return rest.AvailableResources{}, err
+	}
+
+	// Only clear the kinds that were stale when the fetch started. Any invalidated
+	// while it was in progress are left stale for the next caller.
+	rc.resources = resources
+	rc.fetched = time.Now()
+	rc.loaded = true
+	rc.stale &^= cleared
+
+	return resources, nil
+}
+
+// invalidate marks the kinds in mask as stale.
+func (rc *resourceCache) invalidate(mask ResourceKind) {
+	if rc == nil {
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.stale |= mask
+}
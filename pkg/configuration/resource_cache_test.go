@@ -0,0 +1,244 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package configuration
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+)
+
+// countingFetch returns a fetch function that counts its calls and reports the
+// call number as the ID of the single location it returns.
+func countingFetch(calls *int32) func() (rest.AvailableResources, error) {
+	return func() (rest.AvailableResources, error) {
+		n := atomic.AddInt32(calls, 1)
+
+		return rest.AvailableResources{Locations: []rest.LocationInfo{{ID: string(rune('0' + n))}}}, nil
+	}
+}
+
+func TestResourceCacheConcurrentGet(t *testing.T) {
+	var calls int32
+
+	release := make(chan struct{})
+	fetch := func() (rest.AvailableResources, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+
+		return rest.AvailableResources{}, nil
+	}
+
+	rc := &resourceCache{ttl: time.Minute}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := rc.get(kindAll, fetch); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+
+	// Let the goroutines pile up behind the first fetch.
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("expected 1 fetch, got %d", calls)
+	}
+}
+
+func TestResourceCacheInvalidate(t *testing.T) {
+	tCases := []struct {
+		name       string
+		invalidate ResourceKind
+		get        []ResourceKind
+		expCalls   int32
+	}{
+		{
+			name:     "Fresh",
+			get:      []ResourceKind{KindLocations},
+			expCalls: 1,
+		},
+		{
+			name:       "Other kind invalidated",
+			invalidate: KindSSHKeys,
+			get:        []ResourceKind{KindLocations},
+			expCalls:   1,
+		},
+		{
+			name:       "Same kind invalidated",
+			invalidate: KindSSHKeys,
+			get:        []ResourceKind{KindSSHKeys, KindLocations},
+			expCalls:   2,
+		},
+		{
+			name:       "Everything invalidated",
+			invalidate: kindAll,
+			get:        []ResourceKind{KindImages},
+			expCalls:   2,
+		},
+		{
+			name:       "Any kind requested",
+			invalidate: KindVolumes,
+			expCalls:   2,
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int32
+
+			fetch := countingFetch(&calls)
+			rc := &resourceCache{ttl: time.Minute}
+
+			if _, err := rc.get(kindAll, fetch); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			rc.invalidate(tc.invalidate)
+
+			// The second get refetches if required and clears the invalidation.
+			for i := 0; i < 2; i++ {
+				if _, err := rc.get(kindMask(tc.get), fetch); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if calls != tc.expCalls {
+				t.Fatalf("expected %d fetches, got %d", tc.expCalls, calls)
+			}
+		})
+	}
+}
+
+func TestResourceCacheTTL(t *testing.T) {
+	var calls int32
+
+	fetch := countingFetch(&calls)
+	rc := &resourceCache{ttl: time.Hour}
+
+	if _, err := rc.get(kindAll, fetch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := rc.get(kindAll, fetch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected 1 fetch before expiry, got %d", calls)
+	}
+
+	rc.mu.Lock()
+	rc.fetched = rc.fetched.Add(-2 * time.Hour)
+	rc.mu.Unlock()
+
+	ar, err := rc.get(kindAll, fetch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if calls != 2 || ar.Locations[0].ID != "2" {
+		t.Fatalf("expected a second fetch after expiry, got %d", calls)
+	}
+}
+
+func TestResourceCacheInvalidateDuringFetch(t *testing.T) {
+	var calls int32
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fetch := func() (rest.AvailableResources, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-release
+		}
+
+		return rest.AvailableResources{}, nil
+	}
+
+	rc := &resourceCache{}
+
+	done := make(chan error)
+
+	go func() {
+		_, err := rc.get(kindAll, fetch)
+		done <- err
+	}()
+
+	// A change made while the first fetch is in progress may not be included in
+	// its result, so it must not satisfy the next caller.
+	<-started
+	rc.invalidate(KindNetworks)
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := rc.get(KindNetworks, fetch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if calls != 2 {
+		t.Fatalf("expected 2 fetches, got %d", calls)
+	}
+}
+
+func TestResourceCacheFetchError(t *testing.T) {
+	var calls int32
+
+	expErr := errors.New("portal unavailable")
+	fetch := func() (rest.AvailableResources, error) {
+		atomic.AddInt32(&calls, 1)
+
+		return rest.AvailableResources{}, expErr
+	}
+
+	rc := &resourceCache{}
+
+	if _, err := rc.get(kindAll, fetch); !errors.Is(err, expErr) {
+		t.Fatalf("expected %v, got %v", expErr, err)
+	}
+
+	// Errors are not cached.
+	if _, err := rc.get(kindAll, fetch); !errors.Is(err, expErr) {
+		t.Fatalf("expected %v, got %v", expErr, err)
+	}
+
+	if calls != 2 {
+		t.Fatalf("expected 2 fetches, got %d", calls)
+	}
+}
+
+func TestResourceCacheNil(t *testing.T) {
+	var (
+		calls int32
+		rc    *resourceCache
+	)
+
+	fetch := countingFetch(&calls)
+
+	rc.invalidate(kindAll)
+
+	for i := 0; i < 2; i++ {
+		if _, err := rc.get(kindAll, fetch); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if calls != 2 {
+		t.Fatalf("expected 2 fetches, got %d", calls)
+	}
+}
@@ -0,0 +1,227 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package configuration
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultRetryMaxAttempts is the number of times a request is sent to the
+	// portal before its failure is returned.
+	DefaultRetryMaxAttempts = 5
+	// DefaultRetryMaxWait is the longest wait between attempts.
+	DefaultRetryMaxWait = 30 * time.Second
+
+	// retryBaseWait is the wait before the first retry, it doubles on each retry.
+	retryBaseWait = 500 * time.Millisecond
+)
+
+// retryTransport is an http.RoundTripper that retries requests that fail with
+// errors the portal returns when it is busy, e.g. 429 and 503, or on connection
+// errors. Reads are retried on any of these. Requests that change state are
+// only retried when the portal can not have acted on them: when it has rejected
+// them with 429 or 503, or when the connection could not be made.
+type retryTransport struct {
+	next        http.RoundTripper
+	maxAttempts int
+	maxWait     time.Duration
+	baseWait    time.Duration
+}
+
+// withRetries returns a copy of h whose transport retries failed requests, or
+// h itself if maxAttempts does not allow any retries.
+func withRetries(h *http.Client, maxAttempts int, maxWait time.Duration) *http.Client {
+	if maxAttempts <= 1 {
+		return h
+	}
+
+	next := h.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	c := *h
+	c.Transport = &retryTransport{
+		next:        next,
+		maxAttempts: maxAttempts,
+		maxWait:     maxWait,
+		baseWait:    retryBaseWait,
+	}
+
+	return &c
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+
+		if attempt >= t.maxAttempts || !t.shouldRetry(req, resp, err) {
+			return resp, err //nolint:wrapcheck // errors are returned as is to the metal client.
+		}
+
+		// A body that can't be sent again can't be retried.
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, err //nolint:wrapcheck // errors are returned as is to the metal client.
+			}
+
+			body, bErr := req.GetBody()
+			if bErr != nil {
+				return resp, err //nolint:wrapcheck // errors are returned as is to the metal client.
+			}
+
+			// RoundTrip must not modify the request, so retry with a copy.
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		wait := t.backoff(attempt, resp)
+
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+
+			// Drain the body so that the connection can be reused.
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+			resp.Body.Close()
+		}
+
+		log.Printf("[DEBUG] %s %s failed with %s, retrying in %v (attempt %d of %d)",
+			req.Method, req.URL.Path, reason, wait, attempt+1, t.maxAttempts)
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+
+			return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Path, req.Context().Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// shouldRetry reports whether the request can be sent again after it failed
+// with resp or err.
+func (t *retryTransport) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	read := isReadMethod(req.Method)
+
+	if err != nil {
+		if read {
+			return isTransientError(err)
+		}
+
+		// A request that never reached the portal is safe to send again.
+		return isDialError(err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		// A gateway may have forwarded the request before failing.
+		return read
+	}
+
+	return false
+}
+
+// backoff returns the wait before the retry that follows the attempt. It honors
+// the Retry-After header of resp, otherwise it is an exponential backoff with
+// jitter. The wait never exceeds maxWait.
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(wait, t.maxWait)
+		}
+	}
+
+	wait := t.maxWait
+	if shift := attempt - 1; shift < 32 && t.baseWait<<shift < t.maxWait {
+		wait = t.baseWait << shift
+	}
+
+	// Wait between half and all of the backoff so that concurrent requests that
+	// failed together don't all retry together.
+	if half := wait / 2; half > 0 {
+		wait = half + rand.N(half+1) //nolint:gosec // jitter doesn't need a secure random number.
+	}
+
+	return wait
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of
+// seconds or a date.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(v); err == nil {
+		return max(time.Until(at), 0), true
+	}
+
+	return 0, false
+}
+
+func isReadMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	return false
+}
+
+// isDialError reports whether err happened before the request was sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+
+	return errors.As(err, &dnsErr)
+}
+
+// isTransientError reports whether err is a connection error that may not
+// happen again.
+func isTransientError(err error) bool {
+	if isDialError(err) {
+		return true
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
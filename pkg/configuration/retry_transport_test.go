@@ -0,0 +1,350 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package configuration
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newTestRetryClient(maxAttempts int) *http.Client {
+	return &http.Client{Transport: &retryTransport{
+		next:        http.DefaultTransport,
+		maxAttempts: maxAttempts,
+		maxWait:     20 * time.Millisecond,
+		baseWait:    time.Millisecond,
+	}}
+}
+
+func TestRetryTransport(t *testing.T) {
+	tCases := []struct {
+		name      string
+		method    string
+		statuses  []int
+		expStatus int
+		expCalls  int32
+	}{
+		{
+			name:      "Read retried until success",
+			method:    http.MethodGet,
+			statuses:  []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			expStatus: http.StatusOK,
+			expCalls:  3,
+		},
+		{
+			name:      "Read retried on bad gateway",
+			method:    http.MethodGet,
+			statuses:  []int{http.StatusBadGateway, http.StatusOK},
+			expStatus: http.StatusOK,
+			expCalls:  2,
+		},
+		{
+			name:      "Read not retried on internal error",
+			method:    http.MethodGet,
+			statuses:  []int{http.StatusInternalServerError, http.StatusOK},
+			expStatus: http.StatusInternalServerError,
+			expCalls:  1,
+		},
+		{
+			name:      "Read retries exhausted",
+			method:    http.MethodGet,
+			statuses:  []int{http.StatusServiceUnavailable},
+			expStatus: http.StatusServiceUnavailable,
+			expCalls:  4,
+		},
+		{
+			name:      "Mutation retried on too many requests",
+			method:    http.MethodPost,
+			statuses:  []int{http.StatusTooManyRequests, http.StatusCreated},
+			expStatus: http.StatusCreated,
+			expCalls:  2,
+		},
+		{
+			name:      "Mutation not retried on bad gateway",
+			method:    http.MethodPut,
+			statuses:  []int{http.StatusBadGateway, http.StatusOK},
+			expStatus: http.StatusBadGateway,
+			expCalls:  1,
+		},
+		{
+			name:      "Mutation not retried on gateway timeout",
+			method:    http.MethodDelete,
+			statuses:  []int{http.StatusGatewayTimeout, http.StatusOK},
+			expStatus: http.StatusGatewayTimeout,
+			expCalls:  1,
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(atomic.AddInt32(&calls, 1))
+
+				body, _ := io.ReadAll(r.Body)
+				if r.Method != http.MethodGet && string(body) != `{"Name":"host1"}` {
+					t.Errorf("attempt %d sent body %q", n, body)
+				}
+
+				w.WriteHeader(tc.statuses[min(n, len(tc.statuses))-1])
+			}))
+			defer srv.Close()
+
+			var body io.Reader
+			if tc.method != http.MethodGet {
+				body = strings.NewReader(`{"Name":"host1"}`)
+			}
+
+			req, err := http.NewRequest(tc.method, srv.URL, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := newTestRetryClient(4).Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.expStatus {
+				t.Fatalf("expected status %d, got %d", tc.expStatus, resp.StatusCode)
+			}
+
+			if n := atomic.LoadInt32(&calls); n != tc.expCalls {
+				t.Fatalf("expected %d calls, got %d", tc.expCalls, n)
+			}
+		})
+	}
+}
+
+func TestRetryTransportConnectionReset(t *testing.T) {
+	tCases := []struct {
+		name     string
+		method   string
+		expErr   bool
+		expCalls int32
+	}{
+		{
+			name:     "Read retried",
+			method:   http.MethodGet,
+			expCalls: 2,
+		},
+		{
+			name:     "Mutation not retried",
+			method:   http.MethodPost,
+			expErr:   true,
+			expCalls: 1,
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					// Drop the connection without a response.
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+
+					return
+				}
+			}))
+			defer srv.Close()
+
+			req, err := http.NewRequest(tc.method, srv.URL, http.NoBody)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := newTestRetryClient(3).Do(req)
+			if err == nil {
+				resp.Body.Close()
+			}
+
+			if (err != nil) != tc.expErr {
+				t.Fatalf("expected error %v, got %v", tc.expErr, err)
+			}
+
+			if n := atomic.LoadInt32(&calls); n != tc.expCalls {
+				t.Fatalf("expected %d calls, got %d", tc.expCalls, n)
+			}
+		})
+	}
+}
+
+func TestRetryTransportCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &http.Client{Transport: &retryTransport{
+		next:        http.DefaultTransport,
+		maxAttempts: 3,
+		maxWait:     time.Minute,
+		baseWait:    time.Millisecond,
+	}}
+
+	start := time.Now()
+
+	resp, err := c.Do(req)
+	if err == nil {
+		resp.Body.Close()
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	if time.Since(start) > 10*time.Second {
+		t.Fatal("retry wait was not cancelled")
+	}
+}
+
+func TestRetryTransportBackoff(t *testing.T) {
+	rt := &retryTransport{maxWait: 10 * time.Second, baseWait: time.Second}
+
+	tCases := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		expMin     time.Duration
+		expMax     time.Duration
+	}{
+		{
+			name:    "First retry",
+			attempt: 1,
+			expMin:  500 * time.Millisecond,
+			expMax:  time.Second,
+		},
+		{
+			name:    "Third retry",
+			attempt: 3,
+			expMin:  2 * time.Second,
+			expMax:  4 * time.Second,
+		},
+		{
+			name:    "Capped at max wait",
+			attempt: 40,
+			expMin:  5 * time.Second,
+			expMax:  10 * time.Second,
+		},
+		{
+			name:       "Retry-After seconds",
+			attempt:    1,
+			retryAfter: "3",
+			expMin:     3 * time.Second,
+			expMax:     3 * time.Second,
+		},
+		{
+			name:       "Retry-After capped at max wait",
+			attempt:    1,
+			retryAfter: "120",
+			expMin:     10 * time.Second,
+			expMax:     10 * time.Second,
+		},
+		{
+			name:       "Retry-After date in the past",
+			attempt:    1,
+			retryAfter: "Wed, 21 Oct 2015 07:28:00 GMT",
+			expMin:     0,
+			expMax:     0,
+		},
+		{
+			name:       "Invalid Retry-After ignored",
+			attempt:    1,
+			retryAfter: "soon",
+			expMin:     500 * time.Millisecond,
+			expMax:     time.Second,
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tc.retryAfter != "" {
+				resp.Header.Set("Retry-After", tc.retryAfter)
+			}
+
+			wait := rt.backoff(tc.attempt, resp)
+			if wait < tc.expMin || wait > tc.expMax {
+				t.Fatalf("expected wait between %v and %v, got %v", tc.expMin, tc.expMax, wait)
+			}
+		})
+	}
+}
+
+func TestRetryTransportShouldRetryErrors(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}
+	resetErr := &net.OpError{Op: "read", Err: syscall.ECONNRESET}
+
+	tCases := []struct {
+		name   string
+		method string
+		err    error
+		expRet bool
+	}{
+		{name: "Read after dial error", method: http.MethodGet, err: dialErr, expRet: true},
+		{name: "Read after reset", method: http.MethodGet, err: resetErr, expRet: true},
+		{name: "Read after unexpected EOF", method: http.MethodGet, err: io.ErrUnexpectedEOF, expRet: true},
+		{name: "Read after other error", method: http.MethodGet, err: errors.New("tls: bad certificate")},
+		{name: "Mutation after dial error", method: http.MethodPost, err: dialErr, expRet: true},
+		{name: "Mutation after DNS error", method: http.MethodPost, err: &net.DNSError{Err: "no such host"}, expRet: true},
+		{name: "Mutation after reset", method: http.MethodPost, err: resetErr},
+		{name: "Mutation after EOF", method: http.MethodDelete, err: io.EOF},
+	}
+
+	rt := &retryTransport{}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "http://portal/rest/v1/hosts", nil)
+
+			if got := rt.shouldRetry(req, nil, tc.err); got != tc.expRet {
+				t.Fatalf("expected %v, got %v", tc.expRet, got)
+			}
+		})
+	}
+}
+
+func TestWithRetries(t *testing.T) {
+	h := &http.Client{Timeout: time.Minute}
+
+	if withRetries(h, 1, time.Second) != h {
+		t.Fatal("expected client to be unchanged when retries are disabled")
+	}
+
+	c := withRetries(h, 3, time.Second)
+	if c == h || c.Timeout != h.Timeout {
+		t.Fatal("expected a copy of the client")
+	}
+
+	rt, ok := c.Transport.(*retryTransport)
+	if !ok || rt.next != http.DefaultTransport || rt.maxAttempts != 3 {
+		t.Fatalf("unexpected transport %#v", c.Transport)
+	}
+
+	if h.Transport != nil {
+		t.Fatal("original client was modified")
+	}
+}
@@ -0,0 +1,135 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package configuration
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const pemPrefix = "-----BEGIN"
+
+// TLSOptions are the settings used to secure connections to the portal.
+// Certificates and keys are either the path of a PEM file or PEM content.
+type TLSOptions struct {
+	// CABundle holds the certificates of CAs that are trusted in addition to
+	// those of the system.
+	CABundle string
+	// InsecureSkipVerify disables verification of the portal's certificate.
+	InsecureSkipVerify bool
+	// ClientCert and ClientKey are presented to portals that require mutual TLS.
+	ClientCert string
+	ClientKey  string
+}
+
+// WithTLS returns a create option with the provided TLS settings. They are
+// ignored if an HTTP client is supplied with WithHTTPClient.
+func WithTLS(o TLSOptions) CreateOpt {
+	return func(c *Config) {
+		c.tls = o
+	}
+}
+
+// isSet reports whether any of the options differ from the defaults.
+func (o TLSOptions) isSet() bool {
+	return o != TLSOptions{}
+}
+
+// newHTTPClient returns an HTTP client whose connections use the TLS options,
+// or http.DefaultClient if none are set.
+func (o TLSOptions) newHTTPClient() (*http.Client, error) {
+	if !o.isSet() {
+		return http.DefaultClient, nil
+	}
+
+	tlsConfig, err := o.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("default HTTP transport is not an *http.Transport")
+	}
+
+	t = t.Clone()
+	t.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: t}, nil
+}
+
+func (o TLSOptions) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		//nolint:gosec // Verification is only skipped when asked for.
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	if o.InsecureSkipVerify {
+		log.Printf("[WARN] the Metal portal's TLS certificate will not be verified")
+	}
+
+	if o.CABundle != "" {
+		pem, err := readPEM(o.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("ca_bundle: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("ca_bundle: no PEM certificates found")
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if (o.ClientCert == "") != (o.ClientKey == "") {
+		return nil, errors.New("client_cert and client_key must be set together")
+	}
+
+	if o.ClientCert != "" {
+		certPEM, err := readPEM(o.ClientCert)
+		if err != nil {
+			return nil, fmt.Errorf("client_cert: %w", err)
+		}
+
+		keyPEM, err := readPEM(o.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("client_key: %w", err)
+		}
+
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("client_cert and client_key: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// readPEM returns v if it is PEM content, otherwise the contents of the file it names.
+func readPEM(v string) ([]byte, error) {
+	if strings.Contains(v, pemPrefix) {
+		return []byte(v), nil
+	}
+
+	b, err := os.ReadFile(filepath.Clean(v))
+	if err != nil {
+		return nil, fmt.Errorf("read PEM file: %w", err)
+	}
+
+	return b, nil
+}
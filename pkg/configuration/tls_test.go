@@ -0,0 +1,245 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package configuration
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestClientCert returns a self-signed client certificate and its key, both PEM encoded.
+func newTestClientCert(t *testing.T) (certPEM, keyPEM []byte, cert *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "terraform"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	return certPEM, keyPEM, cert
+}
+
+func writeTestFile(t *testing.T, name string, contents []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, contents, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// newTestTLSPortal starts a TLS portal that answers available resource
+// requests, and that requires a client certificate signed by clientCA if set.
+func newTestTLSPortal(t *testing.T, clientCA *x509.Certificate) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+
+	if clientCA != nil {
+		pool := x509.NewCertPool()
+		pool.AddCert(clientCA)
+
+		srv.TLS = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  pool,
+		}
+	}
+
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestTLS(t *testing.T) {
+	clientCertPEM, clientKeyPEM, clientCert := newTestClientCert(t)
+	clientCertPath := writeTestFile(t, "client.crt", clientCertPEM)
+	clientKeyPath := writeTestFile(t, "client.key", clientKeyPEM)
+
+	tCases := []struct {
+		name         string
+		mTLS         bool
+		noTLS        bool
+		glToken      bool
+		tls          func(caPEM []byte) TLSOptions
+		expConfigErr bool
+		expErr       bool
+	}{
+		{
+			name:   "Untrusted certificate",
+			tls:    func([]byte) TLSOptions { return TLSOptions{} },
+			expErr: true,
+		},
+		{
+			name:  "no_tls in .qjwt",
+			noTLS: true,
+			tls:   func([]byte) TLSOptions { return TLSOptions{} },
+		},
+		{
+			name: "insecure_skip_verify",
+			tls:  func([]byte) TLSOptions { return TLSOptions{InsecureSkipVerify: true} },
+		},
+		{
+			name: "ca_bundle content",
+			tls:  func(caPEM []byte) TLSOptions { return TLSOptions{CABundle: string(caPEM)} },
+		},
+		{
+			name: "ca_bundle path",
+			tls: func(caPEM []byte) TLSOptions {
+				return TLSOptions{CABundle: writeTestFile(t, "ca.pem", caPEM)}
+			},
+		},
+		{
+			name:    "ca_bundle with GL token",
+			glToken: true,
+			tls:     func(caPEM []byte) TLSOptions { return TLSOptions{CABundle: string(caPEM)} },
+		},
+		{
+			name: "Missing client certificate",
+			mTLS: true,
+			tls:  func(caPEM []byte) TLSOptions { return TLSOptions{CABundle: string(caPEM)} },
+			// The server rejects the handshake.
+			expErr: true,
+		},
+		{
+			name: "Client certificate paths",
+			mTLS: true,
+			tls: func(caPEM []byte) TLSOptions {
+				return TLSOptions{CABundle: string(caPEM), ClientCert: clientCertPath, ClientKey: clientKeyPath}
+			},
+		},
+		{
+			name:    "Client certificate content with GL token",
+			mTLS:    true,
+			glToken: true,
+			tls: func(caPEM []byte) TLSOptions {
+				return TLSOptions{CABundle: string(caPEM), ClientCert: string(clientCertPEM), ClientKey: string(clientKeyPEM)}
+			},
+		},
+		{
+			name: "Client certificate without key",
+			tls: func([]byte) TLSOptions {
+				return TLSOptions{ClientCert: clientCertPath}
+			},
+			expConfigErr: true,
+		},
+		{
+			name: "Client key does not match",
+			tls: func([]byte) TLSOptions {
+				_, otherKeyPEM, _ := newTestClientCert(t)
+
+				return TLSOptions{ClientCert: string(clientCertPEM), ClientKey: string(otherKeyPEM)}
+			},
+			expConfigErr: true,
+		},
+		{
+			name: "ca_bundle without certificates",
+			tls: func([]byte) TLSOptions {
+				return TLSOptions{CABundle: "-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----\n"}
+			},
+			expConfigErr: true,
+		},
+		{
+			name: "ca_bundle file not found",
+			tls: func([]byte) TLSOptions {
+				return TLSOptions{CABundle: filepath.Join(t.TempDir(), "missing.pem")}
+			},
+			expConfigErr: true,
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ca *x509.Certificate
+			if tc.mTLS {
+				ca = clientCert
+			}
+
+			srv := newTestTLSPortal(t, ca)
+			caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+			opts := []CreateOpt{WithTLS(tc.tls(caPEM)), WithRetry(1, 0)}
+
+			if tc.glToken {
+				home := t.TempDir()
+				t.Setenv("HOME", home)
+
+				gltform := "rest_url: " + srv.URL + "\nproject_id: project1\naccess_token: token\n"
+				if err := os.WriteFile(filepath.Join(home, ".gltform"), []byte(gltform), 0o600); err != nil {
+					t.Fatal(err)
+				}
+
+				opts = append(opts, WithGLToken(true))
+			} else {
+				opts = append(opts, WithQjwt(&Qjwt{
+					RestURL:  srv.URL,
+					Token:    "token",
+					MemberID: "member",
+					NoTLS:    tc.noTLS,
+				}))
+			}
+
+			c, err := NewConfig("", opts...)
+			if (err != nil) != tc.expConfigErr {
+				t.Fatalf("expected configuration error %v, got %v", tc.expConfigErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			//nolint:bodyclose // Response body is closed by metal client.
+			_, _, err = c.Client.AvailableResourcesApi.List(testContext(t, c), nil)
+			if (err != nil) != tc.expErr {
+				t.Fatalf("expected error %v, got %v", tc.expErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package configuration
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+)
+
+// Names of the ways of authenticating with the portal, used in error messages.
+const (
+	AuthModeMetalToken        = "Metal token"
+	AuthModeGLToken           = "GL token"
+	AuthModeTRF               = "GL token retrieve function (TRF)"
+	AuthModeClientCredentials = "GL client credentials"
+)
+
+// tokenCache holds the last token returned by the token retrieve function
+// until shortly before it expires.
+type tokenCache struct {
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// AuthMode returns the name of the way the provider authenticates with the portal.
+func (c *Config) AuthMode() string {
+	switch {
+	case c.tokenSource != nil:
+		return AuthModeClientCredentials
+	case c.trf != nil:
+		return AuthModeTRF
+	case c.useGLToken:
+		return AuthModeGLToken
+	default:
+		return AuthModeMetalToken
+	}
+}
+
+// GetContextWithError returns the context to pass to the Metal client, with the
+// access token in it. Tokens from the token retrieve function are cached until
+// shortly before they expire. An error naming the auth mode is returned if no
+// token can be retrieved or the token has expired.
+func (c *Config) GetContextWithError() (context.Context, error) {
+	ctx := c.context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return c.ContextWithToken(ctx)
+}
+
+// ContextWithToken is GetContextWithError for a context of the caller, so that
+// cancelling it cancels the requests made with the returned context.
+func (c *Config) ContextWithToken(ctx context.Context) (context.Context, error) {
+	if c.trf == nil {
+		if expiry, ok := jwtExpiry(c.token); ok && time.Now().After(expiry) {
+			return nil, fmt.Errorf("%s in %s expired at %s, log in again to get a new one",
+				c.AuthMode(), c.source, expiry.Format(time.RFC3339))
+		}
+
+		return context.WithValue(ctx, rest.ContextAccessToken, c.token), nil
+	}
+
+	token, err := c.retrieveToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", c.AuthMode(), err)
+	}
+
+	return context.WithValue(ctx, rest.ContextAccessToken, token), nil
+}
+
+// retrieveToken returns the cached token, or one from the token retrieve function.
+func (c *Config) retrieveToken(ctx context.Context) (string, error) {
+	tc := c.tokens
+	if tc == nil {
+		return c.fetchToken(ctx)
+	}
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if tc.token != "" && time.Now().Before(tc.expiry) {
+		return tc.token, nil
+	}
+
+	token, err := c.fetchToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	tc.token = ""
+
+	// Tokens whose expiry isn't known are not cached.
+	if expiry, ok := jwtExpiry(token); ok {
+		tc.token = token
+		tc.expiry = expiry.Add(-tokenExpiryMargin)
+	}
+
+	return token, nil
+}
+
+func (c *Config) fetchToken(ctx context.Context) (string, error) {
+	token, err := c.trf(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if token == "" {
+		return "", errors.New("no token was returned")
+	}
+
+	return token, nil
+}
+
+// jwtExpiry returns the expiry time of a JWT. The token is not verified, it
+// is only used to decide when to get a new one.
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var claims struct {
+		Exp json.Number `json:"exp"`
+	}
+
+	if err = json.Unmarshal(payload, &claims); err != nil || claims.Exp == "" {
+		return time.Time{}, false
+	}
+
+	exp, err := claims.Exp.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(exp), 0), true
+}
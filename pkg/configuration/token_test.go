@@ -0,0 +1,216 @@
+// (C) Copyright 2026 Hewlett Packard Enterprise Development LP
+
+package configuration
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	rest "github.com/hewlettpackard/hpegl-metal-client/v1/pkg/client"
+)
+
+// testJWT returns an unsigned JWT that expires at exp.
+func testJWT(exp time.Time) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix())))
+
+	return header + "." + payload + ".signature"
+}
+
+func testContext(t *testing.T, c *Config) context.Context {
+	t.Helper()
+
+	ctx, err := c.GetContextWithError()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return ctx
+}
+
+func TestGetContextWithError(t *testing.T) {
+	valid := testJWT(time.Now().Add(time.Hour))
+
+	tCases := []struct {
+		name     string
+		config   Config
+		tokens   []string
+		trfErr   error
+		expCalls int
+		expToken string
+		expErr   string
+	}{
+		{
+			name:     "TRF token cached",
+			config:   Config{tokens: &tokenCache{}},
+			tokens:   []string{valid},
+			expCalls: 1,
+			expToken: valid,
+		},
+		{
+			name:     "TRF token about to expire not cached",
+			config:   Config{tokens: &tokenCache{}},
+			tokens:   []string{testJWT(time.Now().Add(tokenExpiryMargin / 2)), valid},
+			expCalls: 2,
+			expToken: valid,
+		},
+		{
+			name:     "TRF token without expiry not cached",
+			config:   Config{tokens: &tokenCache{}},
+			tokens:   []string{"opaque-1", "opaque-2"},
+			expCalls: 2,
+			expToken: "opaque-2",
+		},
+		{
+			name:     "No cache",
+			tokens:   []string{valid, valid},
+			expCalls: 2,
+			expToken: valid,
+		},
+		{
+			name:     "TRF error",
+			config:   Config{tokens: &tokenCache{}},
+			trfErr:   errors.New("IAM is unavailable"),
+			expCalls: 1,
+			expErr:   "failed to get GL token retrieve function (TRF): IAM is unavailable",
+		},
+		{
+			name:     "TRF returns no token",
+			config:   Config{tokens: &tokenCache{}},
+			tokens:   []string{""},
+			expCalls: 1,
+			expErr:   "failed to get GL token retrieve function (TRF): no token was returned",
+		},
+		{
+			name:   "Metal token expired",
+			config: Config{token: testJWT(time.Unix(1700000000, 0)), source: "Metal token file .qjwt"},
+			expErr: "Metal token in Metal token file .qjwt expired at " + time.Unix(1700000000, 0).Format(time.RFC3339),
+		},
+		{
+			name:   "GL token expired",
+			config: Config{token: testJWT(time.Unix(1700000000, 0)), useGLToken: true, source: "provider configuration"},
+			expErr: "GL token in provider configuration expired at",
+		},
+		{
+			name:   "Metal token valid",
+			config: Config{token: valid},
+		},
+		{
+			name:   "Metal token not a JWT",
+			config: Config{token: "not-a-jwt"},
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.config
+			calls := 0
+
+			if tc.tokens != nil || tc.trfErr != nil {
+				c.trf = func(context.Context) (string, error) {
+					calls++
+					if tc.trfErr != nil {
+						return "", tc.trfErr
+					}
+
+					return tc.tokens[min(calls, len(tc.tokens))-1], nil
+				}
+			}
+
+			var (
+				ctx context.Context
+				err error
+			)
+
+			for i := 0; i < 2; i++ {
+				if ctx, err = c.GetContextWithError(); err != nil {
+					break
+				}
+			}
+
+			if calls != tc.expCalls {
+				t.Fatalf("expected %d calls of the token retrieve function, got %d", tc.expCalls, calls)
+			}
+
+			if tc.expErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if token, _ := ctx.Value(rest.ContextAccessToken).(string); c.trf != nil && token != tc.expToken {
+				t.Fatalf("expected token %s in context, got %s", tc.expToken, token)
+			}
+		})
+	}
+}
+
+func TestAuthMode(t *testing.T) {
+	trf := func(context.Context) (string, error) { return "", nil }
+
+	tCases := []struct {
+		name   string
+		config Config
+		expect string
+	}{
+		{
+			name:   "Metal token",
+			expect: AuthModeMetalToken,
+		},
+		{
+			name:   "GL token",
+			config: Config{useGLToken: true},
+			expect: AuthModeGLToken,
+		},
+		{
+			name:   "TRF",
+			config: Config{useGLToken: true, trf: trf},
+			expect: AuthModeTRF,
+		},
+		{
+			name:   "Client credentials",
+			config: Config{useGLToken: true, trf: trf, tokenSource: &clientCredentialsSource{}},
+			expect: AuthModeClientCredentials,
+		},
+	}
+
+	for _, tc := range tCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if mode := tc.config.AuthMode(); mode != tc.expect {
+				t.Fatalf("expected %s, got %s", tc.expect, mode)
+			}
+		})
+	}
+}
+
+func TestContextWithToken(t *testing.T) {
+	c := Config{token: "token"}
+
+	parent, cancel := context.WithCancel(context.Background())
+
+	ctx, err := c.ContextWithToken(parent)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if token, _ := ctx.Value(rest.ContextAccessToken).(string); token != "token" {
+		t.Fatalf("expected token in context, got %q", token)
+	}
+
+	cancel()
+
+	if ctx.Err() != context.Canceled {
+		t.Fatalf("expected the context to be cancelled with its parent, got %v", ctx.Err())
+	}
+}
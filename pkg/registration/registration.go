@@ -1,9 +1,13 @@
-// (C) Copyright 2020-2023 Hewlett Packard Enterprise Development LP
+// (C) Copyright 2020-2023, 2026 Hewlett Packard Enterprise Development LP
 
 package registration
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
 	"github.com/hewlettpackard/hpegl-metal-terraform-resources/internal/resources"
 )
@@ -11,16 +15,21 @@ import (
 const (
 	mPrefix = "hpegl_metal"
 
-	qProject = mPrefix + "_project"
-	qHost    = mPrefix + "_host"
-	qVolume  = mPrefix + "_volume"
-	qSSHKey  = mPrefix + "_ssh_key"
-	qNetwork = mPrefix + "_network"
-	qIP      = mPrefix + "_ip"
-	qImage   = mPrefix + "_image"
+	qProject   = mPrefix + "_project"
+	qHost      = mPrefix + "_host"
+	qHostGroup = mPrefix + "_host_group"
+	qVolume    = mPrefix + "_volume"
+	qSSHKey    = mPrefix + "_ssh_key"
+	qNetwork   = mPrefix + "_network"
+	qIP        = mPrefix + "_ip"
+	qImage     = mPrefix + "_image"
+
+	qVolumeAttach = mPrefix + "_volume_attachment"
 
 	qAvailableResource = mPrefix + "_available_resources"
 	qAvailableImages   = mPrefix + "_available_images"
+	qMachineSize       = mPrefix + "_machine_size"
+	qCloudInitPreview  = mPrefix + "_cloud_init_preview"
 
 	// These constants are used to set the optional hpegl provider "metal" block field-names
 	projectID    = "project_id"
@@ -29,6 +38,21 @@ const (
 	glToken      = "gl_token"
 	glpRole      = "glp_role"
 	glpWorkspace = "glp_workspace"
+
+	availableResourcesTTL = "available_resources_ttl"
+	retryMaxAttempts      = "retry_max_attempts"
+	retryMaxWait          = "retry_max_wait"
+	caBundle              = "ca_bundle"
+	insecureSkipVerify    = "insecure_skip_verify"
+	clientCert            = "client_cert"
+	clientKey             = "client_key"
+	token                 = "token"
+	memberID              = "member_id"
+	qjwtFile              = "qjwt_file"
+	profile               = "profile"
+	clientID              = "client_id"
+	clientSecret          = "client_secret"
+	tokenURL              = "token_url"
 )
 
 type Registration struct{}
@@ -41,19 +65,22 @@ func (r Registration) SupportedDataSources() map[string]*schema.Resource {
 	return map[string]*schema.Resource{
 		qAvailableResource: resources.DataSourceAvailableResources(),
 		qAvailableImages:   resources.DataSourceImage(),
+		qMachineSize:       resources.DataSourceMachineSize(),
+		qCloudInitPreview:  resources.DataSourceCloudInitPreview(),
 	}
 }
 
 func (r Registration) SupportedResources() map[string]*schema.Resource {
 	return map[string]*schema.Resource{
-		qHost:   resources.HostResource(),
-		qVolume: resources.VolumeResource(),
-		//qVolumeAttach: volumeAttachmentResource(),
-		qSSHKey:  resources.SshKeyResource(),
-		qProject: resources.ProjectResource(),
-		qNetwork: resources.ProjectNetworkResource(),
-		qIP:      resources.IPResource(),
-		qImage:   resources.ServiceImageResource(),
+		qHost:         resources.HostResource(),
+		qHostGroup:    resources.HostGroupResource(),
+		qVolume:       resources.VolumeResource(),
+		qVolumeAttach: resources.VolumeAttachmentResource(),
+		qSSHKey:       resources.SshKeyResource(),
+		qProject:      resources.ProjectResource(),
+		qNetwork:      resources.ProjectNetworkResource(),
+		qIP:           resources.IPResource(),
+		qImage:        resources.ServiceImageResource(),
 	}
 }
 
@@ -86,6 +113,58 @@ func (r Registration) ProviderSchemaEntry() *schema.Resource {
 				Description: `Field indicating whether the token is GreenLake (GLCS or GLP) IAM issued token or Metal Service issued one,
 				can also be set with the HPEGL_METAL_GL_TOKEN env-var`,
 			},
+			token: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("HPEGL_METAL_TOKEN", ""),
+				Description: `A Metal token, or a GreenLake access token when gl_token is true, used in place of those in the
+				.qjwt and .gltform files. A Metal token requires member_id and rest_url, a GreenLake token requires rest_url
+				and uses project_id and space_name, can also be set with the HPEGL_METAL_TOKEN env-var`,
+			},
+			memberID: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HPEGL_METAL_MEMBER_ID", ""),
+				Description: `The Metal membership that token is valid for, only used with a Metal token,
+				can also be set with the HPEGL_METAL_MEMBER_ID env-var`,
+			},
+			qjwtFile: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HPEGL_METAL_QJWT_FILE", ""),
+				Description: `The path of the .qjwt file to read Metal login details from when token is not set, by default
+				it is looked for in the home directory then in the current directory,
+				can also be set with the HPEGL_METAL_QJWT_FILE env-var`,
+			},
+			profile: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HPEGL_METAL_PROFILE", ""),
+				Description: `The name of the profile to use in a .qjwt file with several, by default the "default" profile
+				or the only one, can also be set with the HPEGL_METAL_PROFILE env-var`,
+			},
+			clientID: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HPEGL_METAL_CLIENT_ID", ""),
+				Description: `The OAuth2 client ID used with client_secret to request GreenLake access tokens from token_url,
+				in place of those in the .gltform file, can also be set with the HPEGL_METAL_CLIENT_ID env-var`,
+			},
+			clientSecret: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("HPEGL_METAL_CLIENT_SECRET", ""),
+				Description: `The OAuth2 client secret of client_id, can also be set with the HPEGL_METAL_CLIENT_SECRET env-var`,
+			},
+			tokenURL: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HPEGL_METAL_TOKEN_URL", ""),
+				Description: `The URL of the OAuth2 token endpoint that client_id and client_secret are sent to,
+				can also be set with the HPEGL_METAL_TOKEN_URL env-var`,
+			},
 			glpRole: {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -98,6 +177,69 @@ func (r Registration) ProviderSchemaEntry() *schema.Resource {
 				DefaultFunc: schema.EnvDefaultFunc("HPEGL_METAL_GLP_WORKSPACE", true),
 				Description: `Field indicating the GLP workspace to be used, can also be set with the HPEGL_METAL_GLP_WORKSPACE env-var`,
 			},
+			availableResourcesTTL: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("HPEGL_METAL_AVAILABLE_RESOURCES_TTL", "5m"),
+				ValidateFunc: validateDuration,
+				Description: `How long the available resources fetched from Metal are cached before they are fetched again,
+				as a duration such as "30s" or "5m", "0" caches them until they are changed by this provider,
+				can also be set with the HPEGL_METAL_AVAILABLE_RESOURCES_TTL env-var`,
+			},
+			retryMaxAttempts: {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("HPEGL_METAL_RETRY_MAX_ATTEMPTS", 5),
+				ValidateFunc: validation.IntAtLeast(1),
+				Description: `The number of times a request is sent to Metal before its failure is returned, 1 disables retries.
+				Reads are retried on 429, 502, 503 and 504 responses and on connection errors, requests that change state only
+				when Metal can not have acted on them, can also be set with the HPEGL_METAL_RETRY_MAX_ATTEMPTS env-var`,
+			},
+			retryMaxWait: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("HPEGL_METAL_RETRY_MAX_WAIT", "30s"),
+				ValidateFunc: validateDuration,
+				Description: `The longest wait between attempts to send a request to Metal, as a duration such as "30s",
+				can also be set with the HPEGL_METAL_RETRY_MAX_WAIT env-var`,
+			},
+			caBundle: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HPEGL_METAL_CA_BUNDLE", ""),
+				Description: `The path of a PEM file, or PEM content, with the certificates of CAs that are trusted in addition
+				to those of the system when connecting to Metal, can also be set with the HPEGL_METAL_CA_BUNDLE env-var`,
+			},
+			insecureSkipVerify: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HPEGL_METAL_INSECURE_SKIP_VERIFY", false),
+				Description: `Field indicating whether verification of Metal's TLS certificate is skipped, it is also skipped
+				when no_tls is set in the .qjwt file, can also be set with the HPEGL_METAL_INSECURE_SKIP_VERIFY env-var`,
+			},
+			clientCert: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HPEGL_METAL_CLIENT_CERT", ""),
+				Description: `The path of a PEM file, or PEM content, with the client certificate presented to Metal for mutual TLS,
+				can also be set with the HPEGL_METAL_CLIENT_CERT env-var`,
+			},
+			clientKey: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("HPEGL_METAL_CLIENT_KEY", ""),
+				Description: `The path of a PEM file, or PEM content, with the private key of client_cert,
+				can also be set with the HPEGL_METAL_CLIENT_KEY env-var`,
+			},
 		},
 	}
 }
+
+func validateDuration(v interface{}, k string) ([]string, []error) {
+	if _, err := time.ParseDuration(v.(string)); err != nil {
+		return nil, []error{fmt.Errorf("%s must be a duration such as \"5m\": %v", k, err)}
+	}
+
+	return nil, nil
+}